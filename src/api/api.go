@@ -2,10 +2,10 @@ package api
 
 import (
 	"fmt"
-	"github.com/rs/cors"
 	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"marlinraker/src/api/executors"
+	"marlinraker/src/auth"
 	"marlinraker/src/files"
 	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
@@ -25,105 +25,174 @@ func (err Error) Error() string {
 type Executor func(*connections.Connection, *http.Request, executors.Params) (any, error)
 
 var socketExecutors = map[string]Executor{
-	"access.oneshot_token":          executors.AccessOneshotToken,
-	"machine.proc_stats":            executors.MachineProcStats,
-	"machine.reboot":                executors.MachineReboot,
-	"machine.services.restart":      executors.MachineServicesRestart,
-	"machine.services.start":        executors.MachineServicesStart,
-	"machine.services.stop":         executors.MachineServicesStop,
-	"machine.shutdown":              executors.MachineShutdown,
-	"machine.system_info":           executors.MachineSystemInfo,
-	"printer.emergency_stop":        executors.PrinterEmergencyStop,
-	"printer.firmware_restart":      executors.PrinterFirmwareRestart,
-	"printer.gcode.help":            executors.PrinterGcodeHelp,
-	"printer.gcode.script":          executors.PrinterGcodeScript,
-	"printer.info":                  executors.PrinterInfo,
-	"printer.objects.list":          executors.PrinterObjectsList,
-	"printer.objects.query":         executors.PrinterObjectsQuerySocket,
-	"printer.objects.subscribe":     executors.PrinterObjectsSubscribeSocket,
-	"printer.print.cancel":          executors.PrinterPrintCancel,
-	"printer.print.pause":           executors.PrinterPrintPause,
-	"printer.print.resume":          executors.PrinterPrintResume,
-	"printer.print.start":           executors.PrinterPrintStart,
-	"printer.restart":               executors.PrinterRestart,
-	"server.config":                 executors.ServerConfig,
-	"server.connection.identify":    executors.ServerConnectionIdentify,
-	"server.database.delete_item":   executors.ServerDatabaseDeleteItem,
-	"server.database.get_item":      executors.ServerDatabaseGetItem,
-	"server.database.list":          executors.ServerDatabaseList,
-	"server.database.post_item":     executors.ServerDatabasePostItem,
-	"server.files.delete_directory": executors.ServerFilesDeleteDirectory,
-	"server.files.delete_file":      executors.ServerFilesDeleteFile,
-	"server.files.get_directory":    executors.ServerFilesGetDirectory,
-	"server.files.list":             executors.ServerFilesList,
-	"server.files.metadata":         executors.ServerFilesMetadata,
-	"server.files.metascan":         executors.ServerFilesMetadata,
-	"server.files.move":             executors.ServerFilesMove,
-	"server.files.post_directory":   executors.ServerFilesPostDirectory,
-	"server.files.roots":            executors.ServerFilesRoots,
-	"server.files.thumbnails":       executors.ServerFilesThumbnails,
-	"server.files.zip":              executors.ServerFilesZip,
-	"server.gcode_store":            executors.ServerGcodeStore,
-	"server.history.list":           executors.ServerHistoryList,
-	"server.info":                   executors.ServerInfo,
-	"server.logs.rollover":          executors.ServerLogsRollover,
-	"server.restart":                executors.ServerRestart,
-	"server.temperature_store":      executors.ServerTemperatureStore,
-	"server.webcams.list":           executors.ServerWebcamsList,
+	"access.delete_user":                 executors.AccessDeleteUser,
+	"access.get_api_key":                 executors.AccessGetApiKey,
+	"access.get_user":                    executors.AccessGetUser,
+	"access.info":                        executors.AccessInfo,
+	"access.login":                       executors.AccessLogin,
+	"access.logout":                      executors.AccessLogout,
+	"access.oneshot_token":               executors.AccessOneshotToken,
+	"access.post_api_key":                executors.AccessPostApiKey,
+	"access.post_user":                   executors.AccessPostUser,
+	"access.refresh_jwt":                 executors.AccessRefreshJwt,
+	"access.user.password":               executors.AccessUserPassword,
+	"access.users.list":                  executors.AccessUsersList,
+	"machine.proc_stats":                 executors.MachineProcStats,
+	"machine.reboot":                     executors.MachineReboot,
+	"machine.services.restart":           executors.MachineServicesRestart,
+	"machine.services.start":             executors.MachineServicesStart,
+	"machine.services.stop":              executors.MachineServicesStop,
+	"machine.shutdown":                   executors.MachineShutdown,
+	"machine.system_info":                executors.MachineSystemInfo,
+	"machine.update.client":              executors.MachineUpdateClient,
+	"machine.update.full":                executors.MachineUpdateFull,
+	"machine.update.refresh":             executors.MachineUpdateRefresh,
+	"machine.update.status":              executors.MachineUpdateStatus,
+	"machine.update.upgrade":             executors.MachineUpdateUpgrade,
+	"printer.emergency_stop":             executors.PrinterEmergencyStop,
+	"printer.firmware_restart":           executors.PrinterFirmwareRestart,
+	"printer.firmware.settings.history":  executors.PrinterFirmwareSettingsHistory,
+	"printer.firmware.settings.load":     executors.PrinterFirmwareSettingsLoad,
+	"printer.firmware.settings.reset":    executors.PrinterFirmwareSettingsReset,
+	"printer.firmware.settings.rollback": executors.PrinterFirmwareSettingsRollback,
+	"printer.firmware.settings.save":     executors.PrinterFirmwareSettingsSave,
+	"printer.firmware.settings.update":   executors.PrinterFirmwareSettingsUpdate,
+	"printer.firmware_settings":          executors.PrinterFirmwareSettings,
+	"printer.gcode.help":                 executors.PrinterGcodeHelp,
+	"printer.gcode.script":               executors.PrinterGcodeScript,
+	"printer.info":                       executors.PrinterInfo,
+	"printer.objects.list":               executors.PrinterObjectsList,
+	"printer.objects.query":              executors.PrinterObjectsQuerySocket,
+	"printer.objects.subscribe":          executors.PrinterObjectsSubscribeSocket,
+	"printer.print.cancel":               executors.PrinterPrintCancel,
+	"printer.print.pause":                executors.PrinterPrintPause,
+	"printer.print.recover":              executors.PrinterPrintRecover,
+	"printer.print.resume":               executors.PrinterPrintResume,
+	"printer.print.start":                executors.PrinterPrintStart,
+	"printer.restart":                    executors.PrinterRestart,
+	"server.config":                      executors.ServerConfig,
+	"server.connection.identify":         executors.ServerConnectionIdentify,
+	"server.database.delete_item":        executors.ServerDatabaseDeleteItem,
+	"server.database.get_item":           executors.ServerDatabaseGetItem,
+	"server.database.list":               executors.ServerDatabaseList,
+	"server.database.post_item":          executors.ServerDatabasePostItem,
+	"server.files.delete_directory":      executors.ServerFilesDeleteDirectory,
+	"server.files.delete_file":           executors.ServerFilesDeleteFile,
+	"server.files.get_directory":         executors.ServerFilesGetDirectory,
+	"server.files.list":                  executors.ServerFilesList,
+	"server.files.metadata":              executors.ServerFilesMetadata,
+	"server.files.metascan":              executors.ServerFilesMetadata,
+	"server.files.move":                  executors.ServerFilesMove,
+	"server.files.post_directory":        executors.ServerFilesPostDirectory,
+	"server.files.roots":                 executors.ServerFilesRoots,
+	"server.files.thumbnails":            executors.ServerFilesThumbnails,
+	"server.files.zip":                   executors.ServerFilesZip,
+	"server.gcode_store":                 executors.ServerGcodeStore,
+	"server.history.delete_job":          executors.ServerHistoryDeleteJob,
+	"server.history.get_job":             executors.ServerHistoryGetJob,
+	"server.history.list":                executors.ServerHistoryList,
+	"server.history.reset_totals":        executors.ServerHistoryResetTotals,
+	"server.history.totals":              executors.ServerHistoryTotals,
+	"server.info":                        executors.ServerInfo,
+	"server.job_queue.delete_job":        executors.ServerJobQueueDeleteJob,
+	"server.job_queue.jump":              executors.ServerJobQueueJump,
+	"server.job_queue.pause":             executors.ServerJobQueuePause,
+	"server.job_queue.post_job":          executors.ServerJobQueuePostJob,
+	"server.job_queue.start":             executors.ServerJobQueueStart,
+	"server.job_queue.status":            executors.ServerJobQueueStatus,
+	"server.logs.rollover":               executors.ServerLogsRollover,
+	"server.restart":                     executors.ServerRestart,
+	"server.temperature_store":           executors.ServerTemperatureStore,
+	"server.webcams.list":                executors.ServerWebcamsList,
 }
 
 var httpExecutors = map[string]map[string]Executor{
 	"GET": {
-		"/access/oneshot_token":     executors.AccessOneshotToken,
-		"/machine/proc_stats":       executors.MachineProcStats,
-		"/machine/system_info":      executors.MachineSystemInfo,
-		"/printer/gcode/help":       executors.PrinterGcodeHelp,
-		"/printer/info":             executors.PrinterInfo,
-		"/printer/objects/list":     executors.PrinterObjectsList,
-		"/printer/objects/query":    executors.PrinterObjectsQueryHttp,
-		"/server/config":            executors.ServerConfig,
-		"/server/database/item":     executors.ServerDatabaseGetItem,
-		"/server/database/list":     executors.ServerDatabaseList,
-		"/server/files/directory":   executors.ServerFilesGetDirectory,
-		"/server/files/list":        executors.ServerFilesList,
-		"/server/files/metadata":    executors.ServerFilesMetadata,
-		"/server/files/metascan":    executors.ServerFilesMetascan,
-		"/server/files/roots":       executors.ServerFilesRoots,
-		"/server/files/thumbnails":  executors.ServerFilesThumbnails,
-		"/server/gcode_store":       executors.ServerGcodeStore,
-		"/server/history/list":      executors.ServerHistoryList,
-		"/server/info":              executors.ServerInfo,
-		"/server/temperature_store": executors.ServerTemperatureStore,
+		"/access/api_key":                    executors.AccessGetApiKey,
+		"/access/info":                       executors.AccessInfo,
+		"/access/oneshot_token":              executors.AccessOneshotToken,
+		"/access/user":                       executors.AccessGetUser,
+		"/access/users/list":                 executors.AccessUsersList,
+		"/machine/proc_stats":                executors.MachineProcStats,
+		"/machine/system_info":               executors.MachineSystemInfo,
+		"/machine/update/status":             executors.MachineUpdateStatus,
+		"/printer/firmware/settings/history": executors.PrinterFirmwareSettingsHistory,
+		"/printer/firmware_settings":         executors.PrinterFirmwareSettings,
+		"/printer/gcode/help":                executors.PrinterGcodeHelp,
+		"/printer/info":                      executors.PrinterInfo,
+		"/printer/objects/list":              executors.PrinterObjectsList,
+		"/printer/objects/query":             executors.PrinterObjectsQueryHttp,
+		"/server/config":                     executors.ServerConfig,
+		"/server/database/item":              executors.ServerDatabaseGetItem,
+		"/server/database/list":              executors.ServerDatabaseList,
+		"/server/files/directory":            executors.ServerFilesGetDirectory,
+		"/server/files/list":                 executors.ServerFilesList,
+		"/server/files/metadata":             executors.ServerFilesMetadata,
+		"/server/files/metascan":             executors.ServerFilesMetascan,
+		"/server/files/roots":                executors.ServerFilesRoots,
+		"/server/files/thumbnails":           executors.ServerFilesThumbnails,
+		"/server/gcode_store":                executors.ServerGcodeStore,
+		"/server/history/job":                executors.ServerHistoryGetJob,
+		"/server/history/list":               executors.ServerHistoryList,
+		"/server/history/totals":             executors.ServerHistoryTotals,
+		"/server/info":                       executors.ServerInfo,
+		"/server/job_queue/status":           executors.ServerJobQueueStatus,
+		"/server/temperature_store":          executors.ServerTemperatureStore,
 	},
 	"POST": {
-		"/machine/reboot":            executors.MachineReboot,
-		"/machine/services/restart":  executors.MachineServicesRestart,
-		"/machine/services/start":    executors.MachineServicesStart,
-		"/machine/services/stop":     executors.MachineServicesStop,
-		"/machine/shutdown":          executors.MachineShutdown,
-		"/printer/emergency_stop":    executors.PrinterEmergencyStop,
-		"/printer/firmware_restart":  executors.PrinterFirmwareRestart,
-		"/printer/gcode/script":      executors.PrinterGcodeScript,
-		"/printer/objects/subscribe": executors.PrinterObjectsSubscribeHttp,
-		"/printer/print/cancel":      executors.PrinterPrintCancel,
-		"/printer/print/pause":       executors.PrinterPrintPause,
-		"/printer/print/resume":      executors.PrinterPrintResume,
-		"/printer/print/start":       executors.PrinterPrintStart,
-		"/printer/restart":           executors.PrinterRestart,
-		"/server/database/item":      executors.ServerDatabasePostItem,
-		"/server/files/directory":    executors.ServerFilesPostDirectory,
-		"/server/files/move":         executors.ServerFilesMove,
-		"/server/files/upload":       executors.ServerFilesUpload,
-		"/server/files/zip":          executors.ServerFilesZip,
-		"/server/logs/rollover":      executors.ServerLogsRollover,
-		"/server/restart":            executors.ServerRestart,
+		"/access/api_key":                     executors.AccessPostApiKey,
+		"/access/login":                       executors.AccessLogin,
+		"/access/logout":                      executors.AccessLogout,
+		"/access/refresh_jwt":                 executors.AccessRefreshJwt,
+		"/access/user":                        executors.AccessPostUser,
+		"/access/user/password":               executors.AccessUserPassword,
+		"/machine/reboot":                     executors.MachineReboot,
+		"/machine/services/restart":           executors.MachineServicesRestart,
+		"/machine/services/start":             executors.MachineServicesStart,
+		"/machine/services/stop":              executors.MachineServicesStop,
+		"/machine/shutdown":                   executors.MachineShutdown,
+		"/machine/update/client":              executors.MachineUpdateClient,
+		"/machine/update/full":                executors.MachineUpdateFull,
+		"/machine/update/refresh":             executors.MachineUpdateRefresh,
+		"/machine/update/upgrade":             executors.MachineUpdateUpgrade,
+		"/printer/emergency_stop":             executors.PrinterEmergencyStop,
+		"/printer/firmware/settings/load":     executors.PrinterFirmwareSettingsLoad,
+		"/printer/firmware/settings/reset":    executors.PrinterFirmwareSettingsReset,
+		"/printer/firmware/settings/rollback": executors.PrinterFirmwareSettingsRollback,
+		"/printer/firmware/settings/save":     executors.PrinterFirmwareSettingsSave,
+		"/printer/firmware/settings/update":   executors.PrinterFirmwareSettingsUpdate,
+		"/printer/firmware_restart":           executors.PrinterFirmwareRestart,
+		"/printer/gcode/script":               executors.PrinterGcodeScript,
+		"/printer/objects/subscribe":          executors.PrinterObjectsSubscribeHttp,
+		"/printer/print/cancel":               executors.PrinterPrintCancel,
+		"/printer/print/pause":                executors.PrinterPrintPause,
+		"/printer/print/resume":               executors.PrinterPrintResume,
+		"/printer/print/start":                executors.PrinterPrintStart,
+		"/printer/restart":                    executors.PrinterRestart,
+		"/server/database/item":               executors.ServerDatabasePostItem,
+		"/server/files/directory":             executors.ServerFilesPostDirectory,
+		"/server/files/move":                  executors.ServerFilesMove,
+		"/server/files/upload":                executors.ServerFilesUpload,
+		"/server/files/zip":                   executors.ServerFilesZip,
+		"/server/history/reset_totals":        executors.ServerHistoryResetTotals,
+		"/server/job_queue/job":               executors.ServerJobQueuePostJob,
+		"/server/job_queue/jump":              executors.ServerJobQueueJump,
+		"/server/job_queue/pause":             executors.ServerJobQueuePause,
+		"/server/job_queue/start":             executors.ServerJobQueueStart,
+		"/server/logs/rollover":               executors.ServerLogsRollover,
+		"/server/restart":                     executors.ServerRestart,
 	},
 	"DELETE": {
+		"/access/user":            executors.AccessDeleteUser,
 		"/server/database/item":   executors.ServerDatabaseDeleteItem,
 		"/server/files/directory": executors.ServerFilesDeleteDirectory,
+		"/server/history/job":     executors.ServerHistoryDeleteJob,
+		"/server/job_queue/job":   executors.ServerJobQueueDeleteJob,
 	},
 }
 
+var publicPaths = []string{"", "/access/login", "/access/refresh_jwt", "/access/info"}
+
 type HttpHandler struct{}
 
 func (HttpHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
@@ -131,7 +200,15 @@ func (HttpHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request)
 	log.WithField("method", request.Method).Debugln(request.URL.String())
 
 	requestPath := strings.TrimRight(request.URL.Path, "/")
-	err := handlePath(writer, request, requestPath)
+
+	var err error
+	if lo.Contains(publicPaths, requestPath) {
+		err = handlePath(writer, request, requestPath)
+	} else if user, authErr := auth.Authenticate(request, requestPath == "/websocket" || isFilePath(requestPath)); authErr != nil {
+		err = writeExecutorResponse(writer, request.Method, requestPath, nil, authErr)
+	} else {
+		err = handlePath(writer, request.WithContext(auth.WithUser(request.Context(), user)), requestPath)
+	}
 	if err != nil {
 		log.Errorf("Error while handling %s %s: %v", request.Method, requestPath, err)
 		writer.WriteHeader(500)
@@ -140,6 +217,13 @@ func (HttpHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request)
 }
 
 func handlePath(writer http.ResponseWriter, request *http.Request, requestPath string) error {
+	if name, path, isInstancePath := splitInstancePath(request.Method, requestPath); isInstancePath {
+		query := request.URL.Query()
+		query.Set("printer", name)
+		request.URL.Path, request.URL.RawQuery = path, query.Encode()
+		return handlePath(writer, request, path)
+	}
+
 	switch {
 
 	case requestPath == "":
@@ -167,12 +251,27 @@ func StartServer() {
 	address := fmt.Sprintf("%s:%d", marlinraker.Config.Web.BindAddress, marlinraker.Config.Web.Port)
 	log.Printf("Listening on %s", address)
 
-	err := http.ListenAndServe(address, cors.AllowAll().Handler(HttpHandler{}))
+	setupCors(marlinraker.Config.Web.CorsDomains)
+	err := http.ListenAndServe(address, corsHandler(HttpHandler{}))
 	if err != nil {
 		panic(err)
 	}
 }
 
+func splitInstancePath(method string, path string) (string, string, bool) {
+	if httpExecutors[method][path] != nil || !strings.HasPrefix(path, "/printer/") {
+		return "", "", false
+	}
+	name, rest, found := strings.Cut(strings.TrimPrefix(path, "/printer/"), "/")
+	if !found || name == "" {
+		return "", "", false
+	}
+	if _, err := marlinraker.GetInstance(name); err != nil {
+		return "", "", false
+	}
+	return name, "/" + rest, true
+}
+
 func isFilePath(path string) bool {
 	for _, root := range files.FileRoots {
 		if strings.HasPrefix(path, "/server/files/"+root.Name) {
@@ -47,9 +47,11 @@ func TestApi(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	marlinraker.State = marlinraker.Ready
 	marlinraker.Config = config.DefaultConfig()
+	instance := marlinraker.NewInstance("", marlinraker.Config.Serial)
+	marlinraker.Instances = []*marlinraker.Instance{instance}
 	notification.Testing = true
+	instance.SetState(marlinraker.Ready, "Printer is ready")
 
 	err = database.Init()
 	if err != nil {
@@ -85,7 +87,7 @@ func TestApi(t *testing.T) {
 			assert.DeepEqual(t, result, &executors.ServerInfoResult{
 				KlippyConnected:           true,
 				KlippyState:               "ready",
-				Components:                []string{"server", "file_manager", "machine", "database", "data_store", "proc_stats", "history"},
+				Components:                []string{"server", "file_manager", "machine", "database", "data_store", "proc_stats", "history", "job_queue"},
 				FailedComponents:          []string{},
 				RegisteredDirectories:     files.GetRegisteredDirectories(),
 				Warnings:                  []string{},
@@ -110,8 +112,8 @@ func TestApi(t *testing.T) {
 			}
 
 			assert.DeepEqual(t, result, &executors.PrinterObjectsListResult{
-				Objects: []string{"test_object"},
-			})
+				Objects: []string{"configfile", "test_object", "webhooks"},
+			}, cmpopts.SortSlices(func(a, b string) bool { return a < b }))
 		})
 
 	testSocket(t, "server.connection.identify", executors.Params{
@@ -139,6 +141,13 @@ func TestApi(t *testing.T) {
 		assert.DeepEqual(t, error, &Error{Code: 400, Message: "version param is required"})
 	})
 
+	testSocket(t, "server.history.list", executors.Params{
+		"start": -1,
+	}, func(t *testing.T, result *executors.ServerHistoryListResult, error *Error) {
+
+		assert.DeepEqual(t, error, &Error{Code: 400, Message: "start and limit must not be negative"})
+	})
+
 	testAll(t, "server.files.list", "GET", "/server/files/list", executors.Params{
 		"root": "config",
 	}, func(t *testing.T, response *httptest.ResponseRecorder, result *executors.ServerFilesResult, error *Error) {
@@ -303,8 +312,8 @@ func TestApi(t *testing.T) {
 			}
 
 			assert.DeepEqual(t, result, &executors.PrinterInfoResult{
-				State:           marlinraker.State,
-				StateMessage:    marlinraker.StateMessage,
+				State:           instance.State(),
+				StateMessage:    instance.StateMessage(),
 				Hostname:        hostname,
 				SoftwareVersion: constants.Version,
 				CpuInfo:         systemInfo.CpuInfo.CpuDesc,
@@ -328,7 +337,7 @@ func TestApi(t *testing.T) {
 				t.Fatal(error)
 			}
 
-			store := temp_store.GetStore()
+			store := instance.TempStore.GetStore()
 			assert.DeepEqual(t, (*temp_store.TempStore)(result), &store)
 		})
 
@@ -477,6 +486,45 @@ func TestApi(t *testing.T) {
 		assert.Equal(t, error.Message, "printer is not online")
 	})
 
+	left := marlinraker.NewInstance("left", marlinraker.Config.Serial)
+	left.SetState(marlinraker.Shutdown, "Disconnected from printer")
+	marlinraker.Instances = append(marlinraker.Instances, left)
+
+	testAll(t, "printer.info", "GET", "/printer/info", executors.Params{
+		"printer": "left",
+	}, func(t *testing.T, response *httptest.ResponseRecorder, result *executors.PrinterInfoResult, error *Error) {
+
+		if error != nil {
+			t.Fatal(error)
+		}
+
+		assert.Equal(t, result.State, marlinraker.Shutdown)
+		assert.Equal(t, result.StateMessage, "Disconnected from printer")
+	})
+
+	testHttp(t, "GET", "/printer/left/printer/info", executors.Params{},
+		func(t *testing.T, response *httptest.ResponseRecorder, result *executors.PrinterInfoResult, error *Error) {
+
+			if error != nil {
+				t.Fatal(error)
+			}
+
+			assert.Equal(t, result.State, marlinraker.Shutdown)
+		})
+
+	testAll(t, "printer.info", "GET", "/printer/info", executors.Params{
+		"printer": "right",
+	}, func(t *testing.T, response *httptest.ResponseRecorder, result *executors.PrinterInfoResult, error *Error) {
+
+		if result != nil {
+			t.Fatal(result)
+		}
+
+		assert.Equal(t, error.Code, 404)
+	})
+
+	marlinraker.Instances = marlinraker.Instances[:1]
+
 	testAll(t, "server.files.roots", "GET", "/server/files/roots", executors.Params{},
 		func(t *testing.T, response *httptest.ResponseRecorder, result *executors.ServerFilesRootsResult, error *Error) {
 
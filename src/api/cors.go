@@ -0,0 +1,75 @@
+package api
+
+import (
+	"github.com/rs/cors"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/auth"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var corsDomains []*regexp.Regexp
+
+func setupCors(domains []string) {
+	corsDomains = make([]*regexp.Regexp, 0, len(domains))
+	for _, domain := range domains {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(domain), `\*`, ".*")
+		corsDomains = append(corsDomains, regexp.MustCompile("(?i)^"+pattern+"$"))
+	}
+}
+
+func corsHandler(handler http.Handler) http.Handler {
+	return cors.New(cors.Options{
+		AllowOriginVaryRequestFunc: func(_ *http.Request, origin string) (bool, []string) {
+			return isOriginAllowed(origin), nil
+		},
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: true,
+	}).Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if origin := request.Header.Get("Origin"); origin != "" && !isOriginAllowed(origin) {
+			log.Warnf("Rejected request from origin %q", origin)
+			writer.WriteHeader(403)
+			_, _ = writer.Write([]byte("Forbidden"))
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	}))
+}
+
+func checkOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	return origin == "" || isOriginAllowed(origin)
+}
+
+func isOriginAllowed(origin string) bool {
+	originUrl, err := url.Parse(origin)
+	if err != nil || originUrl.Host == "" {
+		return false
+	}
+
+	hostname := originUrl.Hostname()
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(hostname); ip != nil && (ip.IsLoopback() || auth.IsTrustedHost(hostname)) {
+		return true
+	}
+
+	for _, domain := range corsDomains {
+		if domain.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/auth"
+	"marlinraker/src/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCors(t *testing.T) {
+	setupCors([]string{"*.local", "http://*.lan:*", "https://app.example.com"})
+	assert.NilError(t, auth.Init(&config.Config{Authorization: config.Authorization{TrustedClients: []string{"192.168.1.0/24"}}}))
+
+	for origin, allowed := range map[string]bool{
+		"http://printer.local":           true,
+		"http://printer.local:8080":      false,
+		"http://mainsail.lan:80":         true,
+		"https://mainsail.lan:443":       false,
+		"https://app.example.com":        true,
+		"https://evil.example.com":       false,
+		"http://printer.home:7125":       false,
+		"http://192.168.1.20":            true,
+		"http://localhost:3000":          true,
+		"http://127.0.0.1:8080":          true,
+		"http://evil.com":                false,
+		"null":                           false,
+		"http://app.example.com.evil.io": false,
+	} {
+		assert.Equal(t, isOriginAllowed(origin), allowed, origin)
+	}
+
+	rebound := httptest.NewRequest("GET", "http://attacker.example:7125/server/info", nil)
+	rebound.Header.Set("Origin", "http://attacker.example:7125")
+	assert.Assert(t, !checkOrigin(rebound))
+
+	handler := corsHandler(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(200)
+	}))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "http://printer.lan:7125/printer/gcode/script", nil)
+	request.Header.Set("Origin", "http://evil.com")
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, recorder.Code, 403)
+
+	recorder = httptest.NewRecorder()
+	request.Header.Set("Origin", "http://printer.local")
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, recorder.Code, 200)
+	assert.Equal(t, recorder.Header().Get("Access-Control-Allow-Origin"), "http://printer.local")
+
+	request = httptest.NewRequest("GET", "http://printer.lan:7125/websocket", nil)
+	assert.Assert(t, checkOrigin(request))
+	request.Header.Set("Origin", "http://evil.com")
+	assert.Assert(t, !checkOrigin(request))
+}
@@ -0,0 +1,39 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/util"
+	"net/http"
+)
+
+type AccessDeleteUserResult struct {
+	Username string `json:"username"`
+	Action   string `json:"action"`
+}
+
+func AccessDeleteUser(connection *connections.Connection, request *http.Request, params Params) (any, error) {
+	username, err := params.RequireString("username")
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := currentUser(connection, request)
+	if err != nil {
+		return nil, err
+	}
+	if current.Username == username {
+		return nil, util.NewError(400, "cannot delete logged in user")
+	}
+
+	user, err := auth.DeleteUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	publishUserNotification("notify_user_deleted", user.Username)
+	return AccessDeleteUserResult{
+		Username: user.Username,
+		Action:   "user_deleted",
+	}, nil
+}
@@ -0,0 +1,13 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessApiKeyResult string
+
+func AccessGetApiKey(*connections.Connection, *http.Request, Params) (any, error) {
+	return auth.GetApiKey()
+}
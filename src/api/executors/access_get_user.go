@@ -0,0 +1,25 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessGetUserResult struct {
+	Username  string  `json:"username"`
+	Source    string  `json:"source"`
+	CreatedOn float64 `json:"created_on"`
+}
+
+func AccessGetUser(connection *connections.Connection, request *http.Request, _ Params) (any, error) {
+	user, err := currentUser(connection, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return AccessGetUserResult{
+		Username:  user.Username,
+		Source:    user.Source,
+		CreatedOn: user.CreatedOn,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessInfoResult struct {
+	DefaultSource    string   `json:"default_source"`
+	AvailableSources []string `json:"available_sources"`
+}
+
+func AccessInfo(*connections.Connection, *http.Request, Params) (any, error) {
+	return AccessInfoResult{
+		DefaultSource:    "moonraker",
+		AvailableSources: []string{"moonraker"},
+	}, nil
+}
@@ -0,0 +1,55 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/util"
+	"net/http"
+)
+
+type AccessLoginResult struct {
+	Username     string `json:"username"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	Action       string `json:"action"`
+	Source       string `json:"source"`
+}
+
+func AccessLogin(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	username, err := params.RequireString("username")
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := params.RequireString("password")
+	if err != nil {
+		return nil, err
+	}
+
+	if source, exists := params.GetString("source"); exists && source != "moonraker" {
+		return nil, util.NewErrorf(400, "unsupported source %q", source)
+	}
+
+	user, err := auth.Login(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.IssueRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return AccessLoginResult{
+		Username:     user.Username,
+		Token:        token,
+		RefreshToken: refreshToken,
+		Action:       "user_logged_in",
+		Source:       user.Source,
+	}, nil
+}
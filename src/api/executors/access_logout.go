@@ -0,0 +1,29 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessLogoutResult struct {
+	Username string `json:"username"`
+	Action   string `json:"action"`
+}
+
+func AccessLogout(connection *connections.Connection, request *http.Request, _ Params) (any, error) {
+	user, err := currentUser(connection, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Logout(user); err != nil {
+		return nil, err
+	}
+
+	publishUserNotification("notify_user_logged_out", user.Username)
+	return AccessLogoutResult{
+		Username: user.Username,
+		Action:   "user_logged_out",
+	}, nil
+}
@@ -8,6 +8,10 @@ import (
 
 type AccessOneshotTokenResult string
 
-func AccessOneshotToken(*connections.Connection, *http.Request, Params) (any, error) {
-	return auth.GenerateOneshotToken()
+func AccessOneshotToken(connection *connections.Connection, request *http.Request, _ Params) (any, error) {
+	user, err := currentUser(connection, request)
+	if err != nil {
+		return nil, err
+	}
+	return auth.GenerateOneshotToken(user.Username)
 }
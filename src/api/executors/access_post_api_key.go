@@ -0,0 +1,11 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+func AccessPostApiKey(*connections.Connection, *http.Request, Params) (any, error) {
+	return auth.GenerateApiKey()
+}
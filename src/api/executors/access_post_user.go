@@ -0,0 +1,51 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessPostUserResult struct {
+	Username     string `json:"username"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	Source       string `json:"source"`
+	Action       string `json:"action"`
+}
+
+func AccessPostUser(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	username, err := params.RequireString("username")
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := params.RequireString("password")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := auth.CreateUser(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.IssueRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	publishUserNotification("notify_user_created", user.Username)
+	return AccessPostUserResult{
+		Username:     user.Username,
+		Token:        token,
+		RefreshToken: refreshToken,
+		Source:       user.Source,
+		Action:       "user_created",
+	}, nil
+}
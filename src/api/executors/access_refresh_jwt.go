@@ -0,0 +1,33 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessRefreshJwtResult struct {
+	Username string `json:"username"`
+	Token    string `json:"token"`
+	Source   string `json:"source"`
+	Action   string `json:"action"`
+}
+
+func AccessRefreshJwt(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	refreshToken, err := params.RequireString("refresh_token")
+	if err != nil {
+		return nil, err
+	}
+
+	user, token, err := auth.RefreshAccessToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return AccessRefreshJwtResult{
+		Username: user.Username,
+		Token:    token,
+		Source:   user.Source,
+		Action:   "user_jwt_refresh",
+	}, nil
+}
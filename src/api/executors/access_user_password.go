@@ -0,0 +1,38 @@
+package executors
+
+import (
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessUserPasswordResult struct {
+	Username string `json:"username"`
+	Action   string `json:"action"`
+}
+
+func AccessUserPassword(connection *connections.Connection, request *http.Request, params Params) (any, error) {
+	password, err := params.RequireString("password")
+	if err != nil {
+		return nil, err
+	}
+
+	newPassword, err := params.RequireString("new_password")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := currentUser(connection, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.ChangePassword(user, password, newPassword); err != nil {
+		return nil, err
+	}
+
+	return AccessUserPasswordResult{
+		Username: user.Username,
+		Action:   "user_password_reset",
+	}, nil
+}
@@ -0,0 +1,29 @@
+package executors
+
+import (
+	"github.com/samber/lo"
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+type AccessUsersListResult struct {
+	Users []AccessGetUserResult `json:"users"`
+}
+
+func AccessUsersList(*connections.Connection, *http.Request, Params) (any, error) {
+	users, err := auth.ListUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	return AccessUsersListResult{
+		Users: lo.Map(users, func(user auth.User, _ int) AccessGetUserResult {
+			return AccessGetUserResult{
+				Username:  user.Username,
+				Source:    user.Source,
+				CreatedOn: user.CreatedOn,
+			}
+		}),
+	}, nil
+}
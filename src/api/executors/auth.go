@@ -0,0 +1,29 @@
+package executors
+
+import (
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/auth"
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/util"
+	"net/http"
+)
+
+func currentUser(connection *connections.Connection, request *http.Request) (*auth.User, error) {
+	if connection != nil {
+		return auth.GetUser(connection.Username)
+	}
+	if request != nil {
+		if user := auth.UserFromContext(request.Context()); user != nil {
+			return user, nil
+		}
+	}
+	return nil, util.NewError(401, "Unauthorized")
+}
+
+func publishUserNotification(method string, username string) {
+	notify := notification.New(method, []any{map[string]string{"username": username}})
+	if err := notification.Publish(notify); err != nil {
+		log.Errorf("Failed to publish notification: %v", err)
+	}
+}
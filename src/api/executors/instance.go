@@ -0,0 +1,24 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker"
+	"marlinraker/src/printer"
+	"marlinraker/src/util"
+)
+
+func getInstance(params Params) (*marlinraker.Instance, error) {
+	name, _ := params.GetString("printer")
+	return marlinraker.GetInstance(name)
+}
+
+func getPrinter(params Params) (*printer.Printer, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+	printer := instance.Printer()
+	if printer == nil {
+		return nil, util.NewError(500, "printer is not online")
+	}
+	return printer, nil
+}
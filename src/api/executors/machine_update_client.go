@@ -0,0 +1,18 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
+	"net/http"
+)
+
+func MachineUpdateClient(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	name, err := params.RequireString("name")
+	if err != nil {
+		return nil, err
+	}
+	if err := update_manager.Upgrade(name); err != nil {
+		return nil, err
+	}
+	return "ok", nil
+}
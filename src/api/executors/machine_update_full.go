@@ -0,0 +1,14 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
+	"net/http"
+)
+
+func MachineUpdateFull(*connections.Connection, *http.Request, Params) (any, error) {
+	if err := update_manager.Upgrade(""); err != nil {
+		return nil, err
+	}
+	return "ok", nil
+}
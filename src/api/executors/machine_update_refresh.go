@@ -0,0 +1,15 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
+	"net/http"
+)
+
+func MachineUpdateRefresh(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	name, _ := params.GetString("name")
+	if err := update_manager.Refresh(name); err != nil {
+		return nil, err
+	}
+	return update_manager.GetStatus()
+}
@@ -0,0 +1,16 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
+	"net/http"
+)
+
+func MachineUpdateStatus(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	if refresh, _ := params.GetString("refresh"); refresh == "true" {
+		if err := update_manager.Refresh(""); err != nil {
+			return nil, err
+		}
+	}
+	return update_manager.GetStatus()
+}
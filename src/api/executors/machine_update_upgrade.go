@@ -0,0 +1,15 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
+	"net/http"
+)
+
+func MachineUpdateUpgrade(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	name, _ := params.GetString("name")
+	if err := update_manager.Upgrade(name); err != nil {
+		return nil, err
+	}
+	return "ok", nil
+}
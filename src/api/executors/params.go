@@ -5,6 +5,7 @@ import (
 	"github.com/samber/lo"
 	"marlinraker/src/util"
 	"strconv"
+	"strings"
 )
 
 type Params map[string]any
@@ -64,6 +65,25 @@ func (params Params) RequireInt64(name string) (int64, error) {
 	return value, nil
 }
 
+func (params Params) GetFloat64(name string) (float64, bool) {
+	value, exists := params[name]
+	if !exists {
+		return 0, false
+	}
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case int64:
+		return float64(value), true
+	default:
+		str := fmt.Sprintf("%v", value)
+		if value, err := strconv.ParseFloat(str, 64); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
 func (params Params) GetBool(name string) (bool, bool) {
 	value, exists := params[name]
 	if !exists {
@@ -97,6 +117,8 @@ func (params Params) GetStringSlice(name string) ([]string, bool) {
 	case []any:
 		strings := lo.Map(value, func(item any, _ int) string { return fmt.Sprintf("%v", item) })
 		return strings, true
+	case string:
+		return lo.Filter(strings.Split(value, ","), func(item string, _ int) bool { return item != "" }), true
 	}
 	return nil, false
 }
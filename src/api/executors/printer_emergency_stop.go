@@ -1,14 +1,17 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
 	"net/http"
 )
 
 type PrinterEmergencyStopResult string
 
-func PrinterEmergencyStop(*connections.Connection, *http.Request, Params) (any, error) {
-	marlinraker.Printer.EmergencyStop()
+func PrinterEmergencyStop(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	printer.EmergencyStop()
 	return "ok", nil
 }
@@ -1,17 +1,17 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
 	"net/http"
 )
 
-func PrinterFirmwareRestart(*connections.Connection, *http.Request, Params) (any, error) {
-	if marlinraker.Printer != nil {
-		if err := marlinraker.Printer.Disconnect(); err != nil {
-			return nil, err
-		}
+func PrinterFirmwareRestart(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := instance.Restart(); err != nil {
+		return nil, err
 	}
-	go marlinraker.Connect()
 	return "ok", nil
 }
@@ -0,0 +1,17 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/printer/parser"
+	"net/http"
+)
+
+type PrinterFirmwareSettingsResult parser.FirmwareSettings
+
+func PrinterFirmwareSettings(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	return PrinterFirmwareSettingsResult(printer.FirmwareSettings()), nil
+}
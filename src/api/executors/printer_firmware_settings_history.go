@@ -0,0 +1,24 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/printer"
+	"net/http"
+)
+
+type PrinterFirmwareSettingsHistoryResult struct {
+	Records []printer.FirmwareSettingsRecord `json:"records"`
+}
+
+func PrinterFirmwareSettingsHistory(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := printer.LoadFirmwareSettingsRecords(instance.Name)
+	if err != nil {
+		return nil, err
+	}
+	return PrinterFirmwareSettingsHistoryResult{records}, nil
+}
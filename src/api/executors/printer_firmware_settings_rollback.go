@@ -0,0 +1,16 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+func PrinterFirmwareSettingsRollback(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	save, _ := params.GetBool("save")
+
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	return printer.RollbackFirmwareSettings(printer.MainExecutorContext(), save)
+}
@@ -0,0 +1,14 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+func PrinterFirmwareSettingsSave(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	return printer.SaveFirmwareSettings(printer.MainExecutorContext())
+}
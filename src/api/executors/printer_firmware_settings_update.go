@@ -0,0 +1,56 @@
+package executors
+
+import (
+	"fmt"
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/util"
+	"net/http"
+	"strconv"
+)
+
+func PrinterFirmwareSettingsUpdate(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	settings, err := params.RequireAny("settings")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]float64)
+	if err := flattenFirmwareSettings(changes, "", settings); err != nil {
+		return nil, err
+	}
+	save, _ := params.GetBool("save")
+
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	return printer.UpdateFirmwareSettings(printer.MainExecutorContext(), changes, save)
+}
+
+func flattenFirmwareSettings(changes map[string]float64, prefix string, value any) error {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, child := range value {
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			if err := flattenFirmwareSettings(changes, name, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case float64:
+		changes[prefix] = value
+		return nil
+	case int64:
+		changes[prefix] = float64(value)
+		return nil
+	default:
+		number, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil || prefix == "" {
+			return util.NewErrorf(400, "invalid value for firmware setting %q", prefix)
+		}
+		changes[prefix] = number
+		return nil
+	}
+}
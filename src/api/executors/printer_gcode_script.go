@@ -1,9 +1,7 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
-	"marlinraker/src/util"
 	"net/http"
 )
 
@@ -13,9 +11,10 @@ func PrinterGcodeScript(_ *connections.Connection, _ *http.Request, params Param
 		return nil, err
 	}
 
-	if marlinraker.Printer == nil {
-		return nil, util.NewError(500, "printer is not online")
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
 	}
-	<-marlinraker.Printer.MainExecutorContext().QueueGcode(script, false)
+	<-printer.MainExecutorContext().QueueGcode(script, false)
 	return "ok", err
 }
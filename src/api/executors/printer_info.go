@@ -21,7 +21,12 @@ type PrinterInfoResult struct {
 	ConfigFile      string                  `json:"config_file"`
 }
 
-func PrinterInfo(*connections.Connection, *http.Request, Params) (any, error) {
+func PrinterInfo(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+
 	hostname, err := os.Hostname()
 	if err != nil {
 		return nil, err
@@ -33,8 +38,8 @@ func PrinterInfo(*connections.Connection, *http.Request, Params) (any, error) {
 	}
 
 	return PrinterInfoResult{
-		State:           marlinraker.State,
-		StateMessage:    marlinraker.StateMessage,
+		State:           instance.State(),
+		StateMessage:    instance.StateMessage(),
 		Hostname:        hostname,
 		SoftwareVersion: constants.Version,
 		CpuInfo:         systemInfo.CpuInfo.CpuDesc,
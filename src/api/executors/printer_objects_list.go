@@ -3,7 +3,6 @@ package executors
 import (
 	"github.com/samber/lo"
 	"marlinraker/src/marlinraker/connections"
-	"marlinraker/src/printer_objects"
 	"net/http"
 )
 
@@ -11,8 +10,12 @@ type PrinterObjectsListResult struct {
 	Objects []string `json:"objects"`
 }
 
-func PrinterObjectsList(*connections.Connection, *http.Request, Params) (any, error) {
+func PrinterObjectsList(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
 	return PrinterObjectsListResult{
-		Objects: lo.Keys(printer_objects.GetObjects()),
+		Objects: lo.Keys(instance.Objects.GetObjects()),
 	}, nil
 }
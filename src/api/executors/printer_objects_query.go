@@ -18,6 +18,11 @@ type PrinterObjectsQueryResult struct {
 
 func PrinterObjectsQueryHttp(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
 
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+
 	eventTime, err := procfs.GetUptime()
 	if err != nil {
 		return nil, err
@@ -29,12 +34,15 @@ func PrinterObjectsQueryHttp(_ *connections.Connection, _ *http.Request, params
 	}
 
 	for name, attributes := range params {
+		if name == "printer" {
+			continue
+		}
 		attributesStr := fmt.Sprintf("%v", attributes)
 		var attributes []string = nil
 		if attributesStr != "" {
 			attributes = strings.Split(attributesStr, ",")
 		}
-		if result, err := query(name, attributes); err == nil {
+		if result, err := query(instance.Objects, name, attributes); err == nil {
 			results.Status[name] = result
 		} else {
 			return nil, err
@@ -51,6 +59,11 @@ func PrinterObjectsQuerySocket(_ *connections.Connection, _ *http.Request, param
 		return nil, util.NewError(400, "objects param is required")
 	}
 
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+
 	eventTime, err := procfs.GetUptime()
 	if err != nil {
 		return nil, err
@@ -72,7 +85,7 @@ func PrinterObjectsQuerySocket(_ *connections.Connection, _ *http.Request, param
 				return nil, util.NewError(400, "subscribed topics have to be nil or string list")
 			}
 		}
-		if result, err := query(name, attributesStr); err == nil {
+		if result, err := query(instance.Objects, name, attributesStr); err == nil {
 			results.Status[name] = result
 		} else {
 			return nil, err
@@ -82,8 +95,8 @@ func PrinterObjectsQuerySocket(_ *connections.Connection, _ *http.Request, param
 	return results, nil
 }
 
-func query(name string, attributes []string) (printer_objects.QueryResult, error) {
-	result, err := printer_objects.Query(name)
+func query(objects *printer_objects.Registry, name string, attributes []string) (printer_objects.QueryResult, error) {
+	result, err := objects.Query(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query %s: %v", name, err)
 	}
@@ -35,7 +35,7 @@ func PrinterObjectsSubscribeHttp(_ *connections.Connection, _ *http.Request, par
 	subscriptions := make(map[string][]string)
 	for name, attributes := range params {
 		attributes := fmt.Sprintf("%v", attributes)
-		if name == "connection_id" {
+		if name == "connection_id" || name == "printer" {
 			continue
 		}
 		if attributes == "" {
@@ -45,7 +45,11 @@ func PrinterObjectsSubscribeHttp(_ *connections.Connection, _ *http.Request, par
 		}
 	}
 
-	return subscribe(&connection, subscriptions)
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(&connection, instance.Objects, subscriptions)
 }
 
 func PrinterObjectsSubscribeSocket(connection *connections.Connection, _ *http.Request, params Params) (any, error) {
@@ -64,10 +68,14 @@ func PrinterObjectsSubscribeSocket(connection *connections.Connection, _ *http.R
 			})
 		}
 	}
-	return subscribe(connection, subscriptions)
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(connection, instance.Objects, subscriptions)
 }
 
-func subscribe(connection *connections.Connection, subscriptions map[string][]string) (any, error) {
+func subscribe(connection *connections.Connection, objects *printer_objects.Registry, subscriptions map[string][]string) (any, error) {
 
 	eventTime, err := procfs.GetUptime()
 	if err != nil {
@@ -80,14 +88,14 @@ func subscribe(connection *connections.Connection, subscriptions map[string][]st
 	}
 
 	if len(subscriptions) == 0 {
-		printer_objects.Unsubscribe(connection)
+		objects.Unsubscribe(connection)
 		return results, nil
 	}
 
 	var errs []error
 	for name, attributes := range subscriptions {
-		printer_objects.Subscribe(connection, name, attributes)
-		result, err := printer_objects.Query(name)
+		objects.Subscribe(connection, name, attributes)
+		result, err := objects.Query(name)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to query %s: %v", name, err))
 			continue
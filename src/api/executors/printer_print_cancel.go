@@ -1,16 +1,15 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
-	"marlinraker/src/util"
 	"net/http"
 )
 
-func PrinterPrintCancel(*connections.Connection, *http.Request, Params) (any, error) {
-	if marlinraker.Printer == nil {
-		return nil, util.NewError(500, "printer is not online")
+func PrinterPrintCancel(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
 	}
-	<-marlinraker.Printer.MainExecutorContext().QueueGcode("CANCEL_PRINT", true)
+	<-printer.MainExecutorContext().QueueGcode("CANCEL_PRINT", true)
 	return "ok", nil
 }
@@ -1,16 +1,15 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
-	"marlinraker/src/util"
 	"net/http"
 )
 
-func PrinterPrintPause(*connections.Connection, *http.Request, Params) (any, error) {
-	if marlinraker.Printer == nil {
-		return nil, util.NewError(500, "printer is not online")
+func PrinterPrintPause(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
 	}
-	<-marlinraker.Printer.MainExecutorContext().QueueGcode("PAUSE", true)
+	<-printer.MainExecutorContext().QueueGcode("PAUSE", true)
 	return "ok", nil
 }
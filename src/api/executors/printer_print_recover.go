@@ -0,0 +1,15 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"net/http"
+)
+
+func PrinterPrintRecover(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
+	}
+	<-printer.MainExecutorContext().QueueGcode("RECOVER_PRINT", true)
+	return "ok", nil
+}
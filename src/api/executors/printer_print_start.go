@@ -1,16 +1,15 @@
 package executors
 
 import (
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
-	"marlinraker/src/util"
 	"net/http"
 	"strconv"
 )
 
 func PrinterPrintStart(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
-	if marlinraker.Printer == nil {
-		return nil, util.NewError(500, "printer is not online")
+	printer, err := getPrinter(params)
+	if err != nil {
+		return nil, err
 	}
 
 	fileName, err := params.RequireString("filename")
@@ -18,6 +17,6 @@ func PrinterPrintStart(_ *connections.Connection, _ *http.Request, params Params
 		return nil, err
 	}
 
-	<-marlinraker.Printer.MainExecutorContext().QueueGcode("SDCARD_PRINT_FILE FILENAME="+strconv.Quote(fileName), true)
+	<-printer.MainExecutorContext().QueueGcode("SDCARD_PRINT_FILE FILENAME="+strconv.Quote(fileName), true)
 	return "ok", nil
 }
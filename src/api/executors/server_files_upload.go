@@ -2,8 +2,8 @@ package executors
 
 import (
 	"marlinraker/src/files"
-	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
 	"marlinraker/src/util"
 	"net/http"
 	"path/filepath"
@@ -12,7 +12,7 @@ import (
 
 type ServerFilesUploadResult files.FileUploadAction
 
-func ServerFilesUpload(_ *connections.Connection, request *http.Request, _ Params) (any, error) {
+func ServerFilesUpload(_ *connections.Connection, request *http.Request, params Params) (any, error) {
 
 	reader, err := request.MultipartReader()
 	if err != nil {
@@ -61,16 +61,22 @@ func ServerFilesUpload(_ *connections.Connection, request *http.Request, _ Param
 		return nil, err
 	}
 
-	if startPrint {
-		printStarted := false
-		if marlinraker.Printer != nil {
-			fileName := filepath.Join(path, headers[0].Filename)
-			printStarted = marlinraker.Printer.PrintManager.CanPrint(fileName)
+	if startPrint && root == "gcodes" {
+		printStarted, printQueued := false, false
+		fileName := filepath.Join(path, headers[0].Filename)
+		if printer, err := getPrinter(params); err == nil {
+			printStarted = printer.PrintManager.CanPrint(fileName)
 			if printStarted {
-				<-marlinraker.Printer.MainExecutorContext().QueueGcode("SDCARD_PRINT_FILE FILENAME="+strconv.Quote(fileName), true)
+				<-printer.MainExecutorContext().QueueGcode("SDCARD_PRINT_FILE FILENAME="+strconv.Quote(fileName), true)
 			}
 		}
-		action.PrintStarted = &printStarted
+		if !printStarted {
+			if err := job_queue.PostJobs(queuePrinter(), []string{fileName}, false); err != nil {
+				return nil, err
+			}
+			printQueued = true
+		}
+		action.PrintStarted, action.PrintQueued = &printStarted, &printQueued
 	}
 	return action, nil
 }
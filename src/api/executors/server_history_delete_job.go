@@ -0,0 +1,33 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/history"
+	"net/http"
+)
+
+type ServerHistoryDeleteJobResult struct {
+	DeletedJobs []string `json:"deleted_jobs"`
+}
+
+func ServerHistoryDeleteJob(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+
+	var (
+		deleted []string
+		err     error
+	)
+
+	if all, _ := params.GetBool("all"); all {
+		deleted, err = history.DeleteAllJobs()
+	} else {
+		var uid string
+		if uid, err = params.RequireString("uid"); err != nil {
+			return nil, err
+		}
+		deleted, err = history.DeleteJob(uid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ServerHistoryDeleteJobResult{deleted}, nil
+}
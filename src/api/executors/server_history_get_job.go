@@ -0,0 +1,24 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/history"
+	"net/http"
+)
+
+type ServerHistoryGetJobResult struct {
+	Job history.Job `json:"job"`
+}
+
+func ServerHistoryGetJob(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	uid, err := params.RequireString("uid")
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := history.GetJob(uid)
+	if err != nil {
+		return nil, err
+	}
+	return ServerHistoryGetJobResult{job}, nil
+}
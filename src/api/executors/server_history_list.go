@@ -1,19 +1,33 @@
-// TODO
-
 package executors
 
 import (
 	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/util"
 	"net/http"
 )
 
 type ServerHistoryListResult struct {
-	Count int   `json:"count"`
-	Jobs  []any `json:"jobs"`
+	Count int           `json:"count"`
+	Jobs  []history.Job `json:"jobs"`
 }
 
-func ServerHistoryList(*connections.Connection, *http.Request, Params) (any, error) {
+func ServerHistoryList(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+
+	start, _ := params.GetInt64("start")
+	limit, exists := params.GetInt64("limit")
+	if !exists {
+		limit = 50
+	}
+	if start < 0 || limit < 0 {
+		return nil, util.NewError(400, "start and limit must not be negative")
+	}
+	since, _ := params.GetFloat64("since")
+	before, _ := params.GetFloat64("before")
+	order, _ := params.GetString("order")
+
+	count, jobs := history.ListJobs(int(start), int(limit), since, before, order == "asc")
 	return ServerHistoryListResult{
-		Count: 0, Jobs: make([]any, 0),
+		Count: count, Jobs: jobs,
 	}, nil
 }
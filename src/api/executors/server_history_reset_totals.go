@@ -0,0 +1,19 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/history"
+	"net/http"
+)
+
+type ServerHistoryResetTotalsResult struct {
+	LastTotals history.Totals `json:"last_totals"`
+}
+
+func ServerHistoryResetTotals(*connections.Connection, *http.Request, Params) (any, error) {
+	last, err := history.ResetTotals()
+	if err != nil {
+		return nil, err
+	}
+	return ServerHistoryResetTotalsResult{last}, nil
+}
@@ -0,0 +1,15 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/history"
+	"net/http"
+)
+
+type ServerHistoryTotalsResult struct {
+	JobTotals history.Totals `json:"job_totals"`
+}
+
+func ServerHistoryTotals(*connections.Connection, *http.Request, Params) (any, error) {
+	return ServerHistoryTotalsResult{history.GetTotals()}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"marlinraker/src/files"
 	"marlinraker/src/marlinraker"
 	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/update_manager"
 	"net/http"
 )
 
@@ -24,10 +25,14 @@ type ServerInfoResult struct {
 }
 
 func ServerInfo(*connections.Connection, *http.Request, Params) (any, error) {
+	components := []string{"server", "file_manager", "machine", "database", "data_store", "proc_stats", "history", "job_queue"}
+	if update_manager.Enabled() {
+		components = append(components, "update_manager")
+	}
 	return ServerInfoResult{
 		KlippyConnected:           true,
-		KlippyState:               string(marlinraker.State),
-		Components:                []string{"server", "file_manager", "machine", "database", "data_store", "proc_stats", "history"},
+		KlippyState:               string(marlinraker.Instances[0].State()),
+		Components:                components,
 		FailedComponents:          []string{},
 		RegisteredDirectories:     files.GetRegisteredDirectories(),
 		Warnings:                  []string{},
@@ -0,0 +1,28 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
+	"net/http"
+)
+
+type ServerJobQueueDeleteJobResult ServerJobQueueStatusResult
+
+func ServerJobQueueDeleteJob(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+
+	if all, _ := params.GetBool("all"); all {
+		if err := job_queue.DeleteAllJobs(); err != nil {
+			return nil, err
+		}
+		return jobQueueStatus(), nil
+	}
+
+	jobIds, err := params.RequireStringSlice("job_ids")
+	if err != nil {
+		return nil, err
+	}
+	if err := job_queue.DeleteJobs(jobIds); err != nil {
+		return nil, err
+	}
+	return jobQueueStatus(), nil
+}
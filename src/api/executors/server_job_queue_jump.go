@@ -0,0 +1,20 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
+	"net/http"
+)
+
+type ServerJobQueueJumpResult ServerJobQueueStatusResult
+
+func ServerJobQueueJump(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	jobId, err := params.RequireString("job_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := job_queue.Jump(jobId); err != nil {
+		return nil, err
+	}
+	return jobQueueStatus(), nil
+}
@@ -0,0 +1,14 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
+	"net/http"
+)
+
+type ServerJobQueuePauseResult ServerJobQueueStatusResult
+
+func ServerJobQueuePause(*connections.Connection, *http.Request, Params) (any, error) {
+	job_queue.Pause()
+	return jobQueueStatus(), nil
+}
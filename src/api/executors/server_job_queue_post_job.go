@@ -0,0 +1,33 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
+	"marlinraker/src/util"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+type ServerJobQueuePostJobResult ServerJobQueueStatusResult
+
+func ServerJobQueuePostJob(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	fileNames, err := params.RequireStringSlice("filenames")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, fileName := range fileNames {
+		fileName = util.SanitizePath(fileName)
+		if !strings.EqualFold(filepath.Ext(fileName), ".gcode") {
+			return nil, util.NewErrorf(400, "invalid file %q", fileName)
+		}
+		fileNames[i] = fileName
+	}
+
+	reset, _ := params.GetBool("reset")
+	if err := job_queue.PostJobs(queuePrinter(), fileNames, reset); err != nil {
+		return nil, err
+	}
+	return jobQueueStatus(), nil
+}
@@ -0,0 +1,37 @@
+package executors
+
+import (
+	"marlinraker/src/marlinraker"
+	"marlinraker/src/marlinraker/connections"
+	"marlinraker/src/marlinraker/job_queue"
+	"marlinraker/src/shared"
+	"net/http"
+)
+
+type ServerJobQueueStatusResult struct {
+	QueuedJobs []job_queue.QueuedJob `json:"queued_jobs"`
+	QueueState job_queue.State       `json:"queue_state"`
+}
+
+func ServerJobQueueStatus(*connections.Connection, *http.Request, Params) (any, error) {
+	return jobQueueStatus(), nil
+}
+
+func jobQueueStatus() ServerJobQueueStatusResult {
+	state, jobs := job_queue.Status()
+	return ServerJobQueueStatusResult{
+		QueuedJobs: jobs,
+		QueueState: state,
+	}
+}
+
+func queuePrinter() shared.Printer {
+	instance, err := marlinraker.GetInstance(job_queue.PrinterName())
+	if err != nil {
+		return nil
+	}
+	if printer := instance.Printer(); printer != nil {
+		return printer
+	}
+	return nil
+}
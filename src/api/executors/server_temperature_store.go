@@ -8,6 +8,10 @@ import (
 
 type ServerTemperatureStoreResult temp_store.TempStore
 
-func ServerTemperatureStore(*connections.Connection, *http.Request, Params) (any, error) {
-	return temp_store.GetStore(), nil
+func ServerTemperatureStore(_ *connections.Connection, _ *http.Request, params Params) (any, error) {
+	instance, err := getInstance(params)
+	if err != nil {
+		return nil, err
+	}
+	return instance.TempStore.GetStore(), nil
 }
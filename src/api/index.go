@@ -48,7 +48,7 @@ func handleIndex(writer http.ResponseWriter, request *http.Request) error {
 		RequestIP:      getAddress(request),
 		Version:        constants.Version,
 		WebsocketCount: len(connections.GetConnections()),
-		State:          marlinraker.State,
+		State:          marlinraker.Instances[0].State(),
 		Arch:           info.CpuInfo.Processor,
 		Os:             info.Distribution.Name,
 		CPU:            info.CpuInfo.CpuDesc,
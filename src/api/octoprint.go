@@ -4,11 +4,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"marlinraker/src/api/executors"
+	"marlinraker/src/files"
 	"marlinraker/src/marlinraker"
+	"marlinraker/src/printer"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/util"
+	"math"
 	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -62,55 +71,6 @@ var (
 		},
 	}
 
-	apiJobResult = map[string]any{
-		"job": map[string]any{
-			"file": map[string]any{
-				"name": nil,
-			},
-			"estimatedPrintTime": nil,
-			"filament": map[string]any{
-				"length": nil,
-			},
-			"user": nil,
-		},
-		"progress": map[string]any{
-			"completion":      nil,
-			"filepos":         nil,
-			"printTime":       nil,
-			"printTimeLeft":   nil,
-			"printTimeOrigin": nil,
-		},
-		"state": "Offline",
-	}
-
-	apiPrinterResult = map[string]any{
-		"temperature": map[string]any{
-			"tool0": map[string]any{
-				"actual": 22.25,
-				"offset": 0.,
-				"target": 0.,
-			},
-			"bed": map[string]any{
-				"actual": 22.25,
-				"offset": 0.,
-				"target": 0.,
-			},
-		},
-		"state": map[string]any{
-			"text": "state",
-			"flags": map[string]any{
-				"operational":   true,
-				"paused":        false,
-				"printing":      false,
-				"cancelling":    false,
-				"pausing":       false,
-				"error":         false,
-				"ready":         false,
-				"closedOrError": false,
-			},
-		},
-	}
-
 	apiPrinterProfilesResult = map[string]any{
 		"profiles": map[string]any{
 			"_default": map[string]any{
@@ -127,10 +87,22 @@ var (
 	}
 )
 
+var (
+	octoPrintStateTexts = map[string]string{
+		"printing": "Printing",
+		"paused":   "Paused",
+	}
+
+	octoPrintBaudRates = []int{250000, 115200, 19200}
+)
+
+type octoPrintNoContent struct{}
+
 func handleOctoPrint(writer http.ResponseWriter, request *http.Request) error {
 
 	method := request.Method
 	path := strings.TrimRight(request.URL.Path, "/")
+	instance, _ := marlinraker.GetInstance(request.URL.Query().Get("printer"))
 
 	var (
 		result any
@@ -148,9 +120,17 @@ func handleOctoPrint(writer http.ResponseWriter, request *http.Request) error {
 		case "/api/settings":
 			result = apiSettingsResult
 		case "/api/job":
-			result = apiJobResult
+			result, err = getApiJob(instance)
 		case "/api/printer":
-			result = apiPrinterResult
+			result, err = getApiPrinter(instance, request)
+		case "/api/printer/tool":
+			result, err = getApiPrinterHeaters(instance, "tool")
+		case "/api/printer/bed":
+			result, err = getApiPrinterHeaters(instance, "bed")
+		case "/api/files", "/api/files/local":
+			result, err = getApiFiles()
+		case "/api/connection":
+			result = getApiConnection(instance)
 		case "/api/printerprofiles":
 			result = apiPrinterProfilesResult
 		}
@@ -159,14 +139,31 @@ func handleOctoPrint(writer http.ResponseWriter, request *http.Request) error {
 		switch path {
 		case "/api/files/local":
 			result, err = executors.ServerFilesUpload(nil, request, nil)
+		case "/api/job":
+			result, err = handleApiJobCommand(instance, request)
 		case "/api/printer/command":
-			result, err = handleApiPrinterCommand(request)
-		}
-		if err != nil {
-			return err
+			result, err = handleApiPrinterCommand(instance, request)
+		case "/api/printer/tool":
+			result, err = handleApiToolCommand(instance, request)
+		case "/api/printer/bed":
+			result, err = handleApiBedCommand(instance, request)
 		}
 	}
 
+	var executorErr *util.ExecutorError
+	if errors.As(err, &executorErr) {
+		writer.WriteHeader(executorErr.Code)
+		_, err := writer.Write([]byte(executorErr.Message))
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if _, isNoContent := result.(octoPrintNoContent); isNoContent {
+		writer.WriteHeader(204)
+		return nil
+	}
+
 	if result == nil {
 		log.Errorf("Cannot find OctoPrint API endpoint %s %s", method, path)
 		writer.WriteHeader(404)
@@ -183,28 +180,491 @@ func handleOctoPrint(writer http.ResponseWriter, request *http.Request) error {
 	return err
 }
 
-func handleApiPrinterCommand(request *http.Request) (any, error) {
-
-	printer := marlinraker.Printer
+func getOctoPrintPrinter(instance *marlinraker.Instance) (*printer.Printer, error) {
+	if instance == nil || instance.State() != marlinraker.Ready {
+		return nil, util.NewError(409, "Printer is not operational")
+	}
+	printer := instance.Printer()
 	if printer == nil {
-		return nil, errors.New("printer not connected")
+		return nil, util.NewError(409, "Printer is not operational")
+	}
+	return printer, nil
+}
+
+func getOctoPrintState(instance *marlinraker.Instance) (string, map[string]any) {
+
+	text, printState := "Offline", ""
+	if printer, err := getOctoPrintPrinter(instance); err == nil {
+		printState = printer.PrintManager.GetState()
+		if text = octoPrintStateTexts[printState]; text == "" {
+			text = "Operational"
+		}
+	} else if instance != nil && instance.State() == marlinraker.Error {
+		text = "Error"
+	}
+
+	operational := text != "Offline" && text != "Error"
+	return text, map[string]any{
+		"operational":   operational,
+		"printing":      printState == "printing",
+		"paused":        printState == "paused",
+		"pausing":       false,
+		"resuming":      false,
+		"cancelling":    false,
+		"finishing":     false,
+		"error":         text == "Error",
+		"ready":         text == "Operational",
+		"closedOrError": !operational,
+		"sdReady":       false,
+	}
+}
+
+func getOctoPrintHeaterKey(heater string) (string, bool) {
+	if heater == "heater_bed" {
+		return "bed", true
+	}
+	if index, isExtruder := strings.CutPrefix(heater, "extruder"); isExtruder {
+		if index == "" {
+			return "tool0", true
+		}
+		if _, err := strconv.Atoi(index); err == nil {
+			return "tool" + index, true
+		}
+	}
+	return "", false
+}
+
+func getOctoPrintTemperatures(printer *printer.Printer, prefix string) (map[string]any, error) {
+
+	heaters, err := printer.Objects.Query("heaters")
+	if err != nil {
+		return nil, err
 	}
+	availableHeaters, _ := heaters["available_heaters"].([]string)
 
-	if request.Body != nil && request.ContentLength > 0 {
-		bodyBytes, err := io.ReadAll(request.Body)
+	temperatures := make(map[string]any)
+	for _, heater := range availableHeaters {
+		key, isKnown := getOctoPrintHeaterKey(heater)
+		if !isKnown || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		result, err := printer.Objects.Query(heater)
 		if err != nil {
-			return nil, fmt.Errorf("unable to read request body: %w", err)
+			return nil, err
 		}
-		var body struct {
-			Commands []string `json:"commands"`
+		temperatures[key] = map[string]any{
+			"actual": result["temperature"],
+			"target": result["target"],
+			"offset": 0.,
 		}
-		if err := json.Unmarshal(bodyBytes, &body); err != nil {
-			return nil, fmt.Errorf("unable to unmarshal request body: %w", err)
+	}
+	return temperatures, nil
+}
+
+func getOctoPrintFilament(length float64) map[string]any {
+	return map[string]any{
+		"tool0": map[string]any{
+			"length": length,
+			"volume": nil,
+		},
+	}
+}
+
+func getApiJob(instance *marlinraker.Instance) (any, error) {
+
+	text, _ := getOctoPrintState(instance)
+	file := map[string]any{
+		"name":    nil,
+		"display": nil,
+		"path":    nil,
+		"origin":  nil,
+		"size":    nil,
+		"date":    nil,
+	}
+	job := map[string]any{
+		"file":               file,
+		"estimatedPrintTime": nil,
+		"filament":           nil,
+		"user":               nil,
+	}
+	progress := map[string]any{
+		"completion":          nil,
+		"filepos":             nil,
+		"printTime":           nil,
+		"printTimeLeft":       nil,
+		"printTimeLeftOrigin": nil,
+	}
+	result := map[string]any{
+		"job":      job,
+		"progress": progress,
+		"state":    text,
+	}
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return result, nil
+	}
+
+	status := make(map[string]printer_objects.QueryResult)
+	for _, name := range []string{"print_stats", "virtual_sdcard", "display_status"} {
+		if status[name], err = printer.Objects.Query(name); err != nil {
+			return nil, err
 		}
+	}
 
-		for _, command := range body.Commands {
-			<-printer.MainExecutorContext().QueueGcode(command, false)
+	fileName, _ := status["print_stats"]["filename"].(string)
+	if fileName == "" {
+		return result, nil
+	}
+
+	file["name"], file["display"], file["path"], file["origin"] =
+		filepath.Base(fileName), filepath.Base(fileName), fileName, "local"
+	file["size"] = status["virtual_sdcard"]["file_size"]
+	if metadata, err := files.LoadMetadata(fileName); err == nil {
+		file["date"] = int64(metadata.Modified)
+		if metadata.EstimatedTime > 0 {
+			job["estimatedPrintTime"] = metadata.EstimatedTime
+		}
+		if metadata.FilamentTotal > 0 {
+			job["filament"] = getOctoPrintFilament(metadata.FilamentTotal)
 		}
 	}
-	return struct{}{}, nil
+	job["user"] = "_api"
+
+	completion, _ := status["display_status"]["progress"].(float64)
+	printTime, _ := status["print_stats"]["print_duration"].(float64)
+	progress["completion"] = completion * 100
+	progress["filepos"] = status["virtual_sdcard"]["file_position"]
+	progress["printTime"] = int(printTime)
+	if printer.PrintManager.IsPrinting() {
+		progress["printTimeLeft"] = int(math.Round(printer.PrintManager.GetRemainingTime()))
+		progress["printTimeLeftOrigin"] = "estimate"
+	}
+	return result, nil
+}
+
+func getApiPrinter(instance *marlinraker.Instance, request *http.Request) (any, error) {
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := strings.Split(request.URL.Query().Get("exclude"), ",")
+	result := make(map[string]any)
+
+	if !lo.Contains(exclude, "temperature") {
+		if result["temperature"], err = getOctoPrintTemperatures(printer, ""); err != nil {
+			return nil, err
+		}
+	}
+	if !lo.Contains(exclude, "sd") {
+		result["sd"] = map[string]any{"ready": false}
+	}
+	if !lo.Contains(exclude, "state") {
+		text, flags := getOctoPrintState(instance)
+		result["state"] = map[string]any{
+			"text":  text,
+			"flags": flags,
+		}
+	}
+	return result, nil
+}
+
+func getApiPrinterHeaters(instance *marlinraker.Instance, prefix string) (any, error) {
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+	return getOctoPrintTemperatures(printer, prefix)
+}
+
+func getApiFiles() (any, error) {
+
+	gcodeFiles, err := files.ListFiles("gcodes")
+	if err != nil {
+		return nil, err
+	}
+	dirInfo, err := files.GetDirInfo("gcodes", false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := lo.FilterMap(gcodeFiles, func(file files.File, _ int) (map[string]any, bool) {
+		if !strings.EqualFold(filepath.Ext(file.Path), ".gcode") {
+			return nil, false
+		}
+		path := filepath.ToSlash(file.Path)
+		entry := map[string]any{
+			"name":     filepath.Base(path),
+			"display":  filepath.Base(path),
+			"path":     path,
+			"type":     "machinecode",
+			"typePath": []string{"machinecode", "gcode"},
+			"origin":   "local",
+			"size":     file.Size,
+			"date":     int64(file.Modified),
+			"refs": map[string]any{
+				"resource": "/api/files/local/" + path,
+				"download": "/server/files/gcodes/" + path,
+			},
+		}
+		if metadata, err := files.LoadMetadata(path); err == nil {
+			analysis := map[string]any{"estimatedPrintTime": nil, "filament": nil}
+			if metadata.EstimatedTime > 0 {
+				analysis["estimatedPrintTime"] = metadata.EstimatedTime
+			}
+			if metadata.FilamentTotal > 0 {
+				analysis["filament"] = getOctoPrintFilament(metadata.FilamentTotal)
+			}
+			entry["gcodeAnalysis"] = analysis
+		}
+		return entry, true
+	})
+
+	return map[string]any{
+		"files": result,
+		"free":  dirInfo.DiskUsage.Free,
+		"total": dirInfo.DiskUsage.Total,
+	}, nil
+}
+
+func getApiConnection(instance *marlinraker.Instance) any {
+
+	text, _ := getOctoPrintState(instance)
+	var (
+		port, baudRate, portPreference, baudRatePreference any
+		ports                                              = make([]string, 0)
+	)
+
+	if instance != nil {
+		if currentPort := instance.Port(); currentPort != "" {
+			port, ports = currentPort, append(ports, currentPort)
+			if currentBaudRate := instance.BaudRate(); currentBaudRate > 0 {
+				baudRate = currentBaudRate
+			}
+		}
+		if instance.Serial.Port != "" && instance.Serial.Port != "auto" {
+			portPreference = instance.Serial.Port
+		}
+		switch preference := instance.Serial.BaudRate.(type) {
+		case int, int64:
+			baudRatePreference = preference
+		}
+	}
+
+	return map[string]any{
+		"current": map[string]any{
+			"state":          text,
+			"port":           port,
+			"baudrate":       baudRate,
+			"printerProfile": "_default",
+		},
+		"options": map[string]any{
+			"ports":                    ports,
+			"baudrates":                octoPrintBaudRates,
+			"printerProfiles":          []map[string]any{{"id": "_default", "name": "Default"}},
+			"portPreference":           portPreference,
+			"baudratePreference":       baudRatePreference,
+			"printerProfilePreference": "_default",
+			"autoconnect":              marlinraker.Config.Reconnect.Enabled,
+		},
+	}
+}
+
+func readOctoPrintBody(request *http.Request, body any) error {
+	if request.Body == nil || request.ContentLength <= 0 {
+		return util.NewError(400, "Expected content-type JSON")
+	}
+	bodyBytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read request body: %w", err)
+	}
+	if err := json.Unmarshal(bodyBytes, body); err != nil {
+		return util.NewErrorf(400, "Malformed JSON body in request: %v", err)
+	}
+	return nil
+}
+
+func handleApiJobCommand(instance *marlinraker.Instance, request *http.Request) (any, error) {
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Command string `json:"command"`
+		Action  string `json:"action"`
+	}
+	if err := readOctoPrintBody(request, &body); err != nil {
+		return nil, err
+	}
+
+	var gcode string
+	state := printer.PrintManager.GetState()
+
+	switch body.Command {
+	case "start":
+		if printer.PrintManager.IsPrinting() {
+			return nil, util.NewError(409, "Printer already has an active print job")
+		}
+		printStats, err := printer.Objects.Query("print_stats")
+		if err != nil {
+			return nil, err
+		}
+		fileName, _ := printStats["filename"].(string)
+		if fileName == "" {
+			return nil, util.NewError(409, "Cannot start printing, no file selected")
+		}
+		gcode = "SDCARD_PRINT_FILE FILENAME=" + strconv.Quote(fileName)
+
+	case "cancel":
+		if !printer.PrintManager.IsPrinting() {
+			return nil, util.NewError(409, "No active print job to cancel")
+		}
+		gcode = "CANCEL_PRINT"
+
+	case "pause":
+		action := lo.Ternary(body.Action == "", "toggle", body.Action)
+		switch {
+		case !lo.Contains([]string{"pause", "resume", "toggle"}, action):
+			return nil, util.NewErrorf(400, "Unknown action %q", action)
+		case state == "printing" && action != "resume":
+			gcode = "PAUSE"
+		case state == "paused" && action != "pause":
+			gcode = "RESUME"
+		default:
+			return nil, util.NewErrorf(409, "Cannot %s the current job", action)
+		}
+
+	default:
+		return nil, util.NewErrorf(400, "Unknown command %q", body.Command)
+	}
+
+	<-printer.MainExecutorContext().QueueGcode(gcode, true)
+	return octoPrintNoContent{}, nil
+}
+
+func handleApiPrinterCommand(instance *marlinraker.Instance, request *http.Request) (any, error) {
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Commands []string `json:"commands"`
+	}
+	if err := readOctoPrintBody(request, &body); err != nil {
+		return nil, err
+	}
+
+	for _, command := range body.Commands {
+		<-printer.MainExecutorContext().QueueGcode(command, false)
+	}
+	return octoPrintNoContent{}, nil
+}
+
+func handleApiToolCommand(instance *marlinraker.Instance, request *http.Request) (any, error) {
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Command string             `json:"command"`
+		Targets map[string]float64 `json:"targets"`
+		Tool    string             `json:"tool"`
+		Amount  float64            `json:"amount"`
+		Speed   float64            `json:"speed"`
+		Factor  float64            `json:"factor"`
+	}
+	if err := readOctoPrintBody(request, &body); err != nil {
+		return nil, err
+	}
+
+	tools, err := getOctoPrintTemperatures(printer, "tool")
+	if err != nil {
+		return nil, err
+	}
+	getToolIndex := func(tool string) (int, error) {
+		if _, exists := tools[tool]; !exists {
+			return 0, util.NewErrorf(400, "Invalid tool %q", tool)
+		}
+		return strconv.Atoi(strings.TrimPrefix(tool, "tool"))
+	}
+
+	gcodes := make([]string, 0)
+	switch body.Command {
+	case "target":
+		keys := lo.Keys(body.Targets)
+		sort.Strings(keys)
+		for _, tool := range keys {
+			index, err := getToolIndex(tool)
+			if err != nil {
+				return nil, err
+			}
+			gcodes = append(gcodes, fmt.Sprintf("M104 T%d S%g", index, body.Targets[tool]))
+		}
+
+	case "offset":
+
+	case "select":
+		if printer.PrintManager.IsPrinting() {
+			return nil, util.NewError(409, "Printer is currently printing")
+		}
+		index, err := getToolIndex(body.Tool)
+		if err != nil {
+			return nil, err
+		}
+		gcodes = append(gcodes, fmt.Sprintf("T%d", index))
+
+	case "extrude":
+		if printer.PrintManager.IsPrinting() {
+			return nil, util.NewError(409, "Printer is currently printing")
+		}
+		speed := lo.Ternary(body.Speed > 0, body.Speed, 300)
+		gcodes = append(gcodes, "M83", fmt.Sprintf("G1 E%g F%g", body.Amount, speed))
+		if printer.GcodeState.Snapshot().IsAbsoluteExtrude {
+			gcodes = append(gcodes, "M82")
+		}
+
+	case "flowrate":
+		gcodes = append(gcodes, fmt.Sprintf("M221 S%g", body.Factor))
+
+	default:
+		return nil, util.NewErrorf(400, "Unknown command %q", body.Command)
+	}
+
+	for _, gcode := range gcodes {
+		<-printer.MainExecutorContext().QueueGcode(gcode, true)
+	}
+	return octoPrintNoContent{}, nil
+}
+
+func handleApiBedCommand(instance *marlinraker.Instance, request *http.Request) (any, error) {
+
+	printer, err := getOctoPrintPrinter(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Command string  `json:"command"`
+		Target  float64 `json:"target"`
+	}
+	if err := readOctoPrintBody(request, &body); err != nil {
+		return nil, err
+	}
+
+	switch body.Command {
+	case "target":
+		<-printer.MainExecutorContext().QueueGcode(fmt.Sprintf("M140 S%g", body.Target), true)
+	case "offset":
+	default:
+		return nil, util.NewErrorf(400, "Unknown command %q", body.Command)
+	}
+	return octoPrintNoContent{}, nil
 }
@@ -108,12 +108,15 @@ func TestOctoPrint(t *testing.T) {
 		{
             "job": {
                 "file": {
-                    "name": null
+                    "name": null,
+                    "display": null,
+                    "path": null,
+                    "origin": null,
+                    "size": null,
+                    "date": null
                 },
                 "estimatedPrintTime": null,
-                "filament": {
-                    "length": null
-                },
+                "filament": null,
                 "user": null
             },
             "progress": {
@@ -121,7 +124,7 @@ func TestOctoPrint(t *testing.T) {
                 "filepos": null,
                 "printTime": null,
                 "printTimeLeft": null,
-                "printTimeOrigin": null
+                "printTimeLeftOrigin": null
             },
             "state": "Offline"
         }
@@ -129,32 +132,34 @@ func TestOctoPrint(t *testing.T) {
 	})
 
 	testOctoPrintEndpoint(t, "GET", "/api/printer", nil, func(t *testing.T, result string) {
+		assert.Equal(t, "Printer is not operational", result)
+	})
+
+	testOctoPrintEndpoint(t, "GET", "/api/printer/tool", nil, func(t *testing.T, result string) {
+		assert.Equal(t, "Printer is not operational", result)
+	})
+
+	testOctoPrintEndpoint(t, "POST", "/api/job", []byte(`{"command":"start"}`), func(t *testing.T, result string) {
+		assert.Equal(t, "Printer is not operational", result)
+	})
+
+	testOctoPrintEndpoint(t, "GET", "/api/connection", nil, func(t *testing.T, result string) {
 		assert.JSONEq(t, `
 		{
-            "temperature": {
-                "tool0": {
-                    "actual": 22.25,
-                    "offset": 0,
-                    "target": 0
-                },
-                "bed": {
-                    "actual": 22.25,
-                    "offset": 0,
-                    "target": 0
-                }
+            "current": {
+                "state": "Offline",
+                "port": null,
+                "baudrate": null,
+                "printerProfile": "_default"
             },
-            "state": {
-                "text": "state",
-                "flags": {
-                    "operational": true,
-                    "paused": false,
-                    "printing": false,
-                    "cancelling": false,
-                    "pausing": false,
-                    "error": false,
-                    "ready": false,
-                    "closedOrError": false
-                }
+            "options": {
+                "ports": [],
+                "baudrates": [250000, 115200, 19200],
+                "printerProfiles": [{"id": "_default", "name": "Default"}],
+                "portPreference": null,
+                "baudratePreference": null,
+                "printerProfilePreference": "_default",
+                "autoconnect": false
             }
         }
 		`, result)
@@ -199,9 +204,11 @@ func TestOctoPrintFileUpload(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	marlinraker.State = marlinraker.Ready
 	marlinraker.Config = config.DefaultConfig()
+	instance := marlinraker.NewInstance("", marlinraker.Config.Serial)
+	marlinraker.Instances = []*marlinraker.Instance{instance}
 	notification.Testing = true
+	instance.SetState(marlinraker.Ready, "Printer is ready")
 
 	testFileUpload(t, "/api/files/local", map[string]string{
 		"root": "config",
@@ -5,6 +5,7 @@ import (
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
 	"marlinraker/src/api/executors"
+	"marlinraker/src/auth"
 	"marlinraker/src/marlinraker/connections"
 	"marlinraker/src/printer_objects"
 	"marlinraker/src/util"
@@ -33,9 +34,7 @@ type RpcErrorResponse struct {
 }
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	CheckOrigin: checkOrigin,
 }
 
 func handleSocket(writer http.ResponseWriter, request *http.Request) error {
@@ -45,6 +44,9 @@ func handleSocket(writer http.ResponseWriter, request *http.Request) error {
 	}
 
 	connection := connections.RegisterConnection(socket)
+	if user := auth.UserFromContext(request.Context()); user != nil {
+		connection.Username = user.Username
+	}
 
 	for {
 		_, message, err := socket.ReadMessage()
@@ -74,14 +74,10 @@ func testHttp[Result any](t *testing.T, method string, endpoint string, params e
 func testSocket[Result any](t *testing.T, method string, params executors.Params, f func(*testing.T, *Result, *Error)) {
 	t.Run(method, func(t *testing.T) {
 
-		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-			if err := handleSocket(writer, request); err != nil {
-				t.Fatal(err)
-			}
-		}))
+		server := httptest.NewServer(HttpHandler{})
 
 		defer server.Close()
-		socketUrl := fmt.Sprintf("ws%s", server.URL[4:])
+		socketUrl := fmt.Sprintf("ws%s/websocket", server.URL[4:])
 
 		socket, _, err := websocket.DefaultDialer.Dial(socketUrl, nil)
 		if err != nil {
@@ -151,14 +147,10 @@ func testAll[Result any](t *testing.T, rpcMethod string, httpMethod string, http
 
 func makeConnection(t *testing.T) (*websocket.Conn, int) {
 
-	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		if err := handleSocket(writer, request); err != nil {
-			t.Fatal(err)
-		}
-	}))
+	server := httptest.NewServer(HttpHandler{})
 
 	defer server.Close()
-	socketUrl := fmt.Sprintf("ws%s", server.URL[4:])
+	socketUrl := fmt.Sprintf("ws%s/websocket", server.URL[4:])
 
 	socket, _, err := websocket.DefaultDialer.Dial(socketUrl, nil)
 	if err != nil {
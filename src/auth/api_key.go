@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"errors"
+	"marlinraker/src/database"
+	"marlinraker/src/util"
+	"sync"
+)
+
+var apiKeyMutex = &sync.Mutex{}
+
+func GetApiKey() (string, error) {
+	apiKeyMutex.Lock()
+	defer apiKeyMutex.Unlock()
+
+	item, err := database.GetItem(usersNamespace, apiKeyUsername+".api_key", true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if !errors.As(err, &executorErr) || executorErr.Code != 404 {
+			return "", err
+		}
+		return generateApiKey()
+	}
+	if apiKey, isString := item.(string); isString {
+		return apiKey, nil
+	}
+	return generateApiKey()
+}
+
+func GenerateApiKey() (string, error) {
+	apiKeyMutex.Lock()
+	defer apiKeyMutex.Unlock()
+	return generateApiKey()
+}
+
+func generateApiKey() (string, error) {
+	apiKey, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+	apiKey = apiKey[:32]
+	if _, err := database.PostItem(usersNamespace, apiKeyUsername+".api_key", apiKey, true); err != nil {
+		return "", err
+	}
+	return apiKey, nil
+}
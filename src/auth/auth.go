@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"marlinraker/src/config"
+	"marlinraker/src/util"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+var (
+	authConfig      config.Authorization
+	trustedNetworks []*net.IPNet
+)
+
+func Init(cfg *config.Config) error {
+	authConfig = cfg.Authorization
+	trustedNetworks = make([]*net.IPNet, 0, len(cfg.Authorization.TrustedClients))
+	for _, client := range cfg.Authorization.TrustedClients {
+		if !strings.Contains(client, "/") {
+			if ip := net.ParseIP(client); ip != nil && ip.To4() != nil {
+				client += "/32"
+			} else {
+				client += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(client)
+		if err != nil {
+			return fmt.Errorf("invalid trusted client %q: %w", client, err)
+		}
+		trustedNetworks = append(trustedNetworks, network)
+	}
+	return nil
+}
+
+func Authenticate(request *http.Request, allowOneshot bool) (*User, error) {
+	if !authConfig.Enabled {
+		return trustedUser, nil
+	}
+
+	if key := request.Header.Get("X-Api-Key"); key != "" {
+		apiKey, err := GetApiKey()
+		if err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			return nil, util.NewError(401, "invalid API key")
+		}
+		return apiKeyUser, nil
+	}
+
+	token, isBearer := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if !isBearer {
+		token = request.URL.Query().Get("access_token")
+	}
+	if token != "" {
+		return ValidateToken(token, accessToken)
+	}
+
+	if token := request.URL.Query().Get("token"); allowOneshot && token != "" {
+		username, valid := ConsumeOneshotToken(token)
+		if !valid {
+			return nil, util.NewError(401, "invalid oneshot token")
+		}
+		return GetUser(username)
+	}
+
+	if host, _, err := net.SplitHostPort(request.RemoteAddr); err == nil && IsTrustedHost(host) {
+		return trustedUser, nil
+	}
+	return nil, util.NewError(401, "Unauthorized")
+}
+
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(contextKey{}).(*User)
+	return user
+}
+
+func IsTrustedHost(host string) bool {
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"net/http/httptest"
+	"testing"
+)
+
+func setup(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	assert.NilError(t, database.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.Authorization.Enabled = true
+	cfg.Authorization.TrustedClients = []string{"192.168.1.0/24", "10.0.0.5"}
+	assert.NilError(t, Init(cfg))
+}
+
+func TestUsers(t *testing.T) {
+	setup(t)
+
+	user, err := CreateUser("alice", "secret")
+	assert.NilError(t, err)
+	assert.Equal(t, user.Username, "alice")
+	assert.Assert(t, user.Password != "secret")
+
+	_, err = CreateUser("alice", "other")
+	assert.Error(t, err, `user "alice" already exists`)
+	_, err = CreateUser("_admin", "secret")
+	assert.Error(t, err, `invalid username "_admin"`)
+
+	_, err = Login("alice", "wrong")
+	assert.Error(t, err, "invalid username or password")
+	user, err = Login("alice", "secret")
+	assert.NilError(t, err)
+
+	apiKey, err := GetApiKey()
+	assert.NilError(t, err)
+	users, err := ListUsers()
+	assert.NilError(t, err)
+	assert.Equal(t, len(users), 1)
+	assert.Equal(t, users[0].Username, "alice")
+
+	assert.NilError(t, ChangePassword(user, "secret", "new"))
+	_, err = Login("alice", "secret")
+	assert.Error(t, err, "invalid username or password")
+
+	_, err = DeleteUser("alice")
+	assert.NilError(t, err)
+	_, err = GetUser("alice")
+	assert.Error(t, err, `user "alice" does not exist`)
+
+	sameKey, err := GetApiKey()
+	assert.NilError(t, err)
+	assert.Equal(t, sameKey, apiKey)
+}
+
+func TestTokens(t *testing.T) {
+	setup(t)
+
+	user, err := CreateUser("bob", "secret")
+	assert.NilError(t, err)
+
+	access, err := IssueAccessToken(user)
+	assert.NilError(t, err)
+	refresh, err := IssueRefreshToken(user)
+	assert.NilError(t, err)
+
+	validated, err := ValidateToken(access, accessToken)
+	assert.NilError(t, err)
+	assert.Equal(t, validated.Username, "bob")
+	_, err = ValidateToken(refresh, accessToken)
+	assert.Error(t, err, "expected access token")
+	_, err = ValidateToken(access[:len(access)-2], accessToken)
+	assert.Error(t, err, "invalid token")
+
+	_, access, err = RefreshAccessToken(refresh)
+	assert.NilError(t, err)
+
+	assert.NilError(t, Logout(user))
+	_, err = ValidateToken(access, accessToken)
+	assert.Error(t, err, "invalid token")
+}
+
+func TestAuthenticate(t *testing.T) {
+	setup(t)
+
+	user, err := CreateUser("carol", "secret")
+	assert.NilError(t, err)
+	access, err := IssueAccessToken(user)
+	assert.NilError(t, err)
+	apiKey, err := GetApiKey()
+	assert.NilError(t, err)
+
+	request := httptest.NewRequest("GET", "/server/info", nil)
+	request.RemoteAddr = "192.168.1.20:50000"
+	authenticated, err := Authenticate(request, false)
+	assert.NilError(t, err)
+	assert.Equal(t, authenticated.Username, trustedUsername)
+
+	request.RemoteAddr = "10.0.0.6:50000"
+	_, err = Authenticate(request, false)
+	assert.Error(t, err, "Unauthorized")
+
+	request.Header.Set("X-Api-Key", apiKey)
+	authenticated, err = Authenticate(request, false)
+	assert.NilError(t, err)
+	assert.Equal(t, authenticated.Username, apiKeyUsername)
+
+	request.Header.Set("X-Api-Key", "invalid")
+	_, err = Authenticate(request, false)
+	assert.Error(t, err, "invalid API key")
+
+	request.Header.Del("X-Api-Key")
+	request.Header.Set("Authorization", "Bearer "+access)
+	authenticated, err = Authenticate(request, false)
+	assert.NilError(t, err)
+	assert.Equal(t, authenticated.Username, "carol")
+
+	request.Header.Set("Authorization", access)
+	_, err = Authenticate(request, false)
+	assert.Error(t, err, "Unauthorized")
+
+	request.RemoteAddr = "192.168.1.20:50000"
+	request.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	authenticated, err = Authenticate(request, false)
+	assert.NilError(t, err)
+	assert.Equal(t, authenticated.Username, trustedUsername)
+
+	token, err := GenerateOneshotToken("carol")
+	assert.NilError(t, err)
+	request = httptest.NewRequest("GET", "/websocket?token="+token, nil)
+	request.RemoteAddr = "10.0.0.6:50000"
+	authenticated, err = Authenticate(request, true)
+	assert.NilError(t, err)
+	assert.Equal(t, authenticated.Username, "carol")
+	_, err = Authenticate(request, true)
+	assert.Error(t, err, "invalid oneshot token")
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"marlinraker/src/util"
+	"strings"
+	"time"
+)
+
+type tokenType string
+
+const (
+	accessToken  tokenType = "access"
+	refreshToken tokenType = "refresh"
+)
+
+type claims struct {
+	Issuer    string    `json:"iss"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+	Username  string    `json:"username"`
+	TokenType tokenType `json:"token_type"`
+}
+
+var (
+	jwtEncoding = base64.RawURLEncoding
+	jwtHeader   = jwtEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+func IssueAccessToken(user *User) (string, error) {
+	return signToken(user, accessToken, time.Hour)
+}
+
+func IssueRefreshToken(user *User) (string, error) {
+	return signToken(user, refreshToken, time.Duration(authConfig.LoginTimeout)*24*time.Hour)
+}
+
+func RefreshAccessToken(token string) (*User, string, error) {
+	user, err := ValidateToken(token, refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+	access, err := IssueAccessToken(user)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, access, nil
+}
+
+func ValidateToken(token string, expectedType tokenType) (*User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, util.NewError(401, "invalid token")
+	}
+
+	payload, err := jwtEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, util.NewError(401, "invalid token")
+	}
+	var tokenClaims claims
+	if err := json.Unmarshal(payload, &tokenClaims); err != nil {
+		return nil, util.NewError(401, "invalid token")
+	}
+
+	user, err := GetUser(tokenClaims.Username)
+	if err != nil || user.IsSpecial() {
+		return nil, util.NewError(401, "invalid token")
+	}
+
+	signature, err := jwtEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(user, parts[0]+"."+parts[1])) {
+		return nil, util.NewError(401, "invalid token")
+	}
+	if tokenClaims.TokenType != expectedType {
+		return nil, util.NewErrorf(401, "expected %s token", expectedType)
+	}
+	if time.Now().Unix() >= tokenClaims.ExpiresAt {
+		return nil, util.NewError(401, "token expired")
+	}
+	return user, nil
+}
+
+func signToken(user *User, tokenType tokenType, lifetime time.Duration) (string, error) {
+	now := time.Now()
+	payload, err := json.Marshal(claims{
+		Issuer:    "Marlinraker",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(lifetime).Unix(),
+		Username:  user.Username,
+		TokenType: tokenType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + jwtEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtEncoding.EncodeToString(sign(user, unsigned)), nil
+}
+
+func sign(user *User, unsigned string) []byte {
+	secret, err := hex.DecodeString(user.Secret)
+	if err != nil {
+		secret = []byte(user.Secret)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
@@ -10,8 +10,9 @@ import (
 )
 
 type oneshotToken struct {
-	buf    []byte
-	expire time.Time
+	buf      []byte
+	username string
+	expire   time.Time
 }
 
 var (
@@ -20,7 +21,7 @@ var (
 	encoding           = base32.StdEncoding.WithPadding(base32.NoPadding)
 )
 
-func GenerateOneshotToken() (string, error) {
+func GenerateOneshotToken(username string) (string, error) {
 	buf := make([]byte, 20)
 	for {
 		if _, err := rand.Read(buf); err != nil {
@@ -37,8 +38,9 @@ func GenerateOneshotToken() (string, error) {
 	}
 
 	token := oneshotToken{
-		buf:    buf,
-		expire: time.Now().Add(time.Second * 5),
+		buf:      buf,
+		username: username,
+		expire:   time.Now().Add(time.Second * 5),
 	}
 
 	oneshotTokensMutex.Lock()
@@ -52,15 +54,15 @@ func GenerateOneshotToken() (string, error) {
 	return encoding.EncodeToString(buf), nil
 }
 
-func ConsumeOneshotToken(base32 string) (bool, error) {
+func ConsumeOneshotToken(base32 string) (string, bool) {
 	buf, err := encoding.DecodeString(base32)
 	if err != nil {
-		return false, err
+		return "", false
 	}
-	return consumeOneshotToken(buf), nil
+	return consumeOneshotToken(buf)
 }
 
-func consumeOneshotToken(buf []byte) bool {
+func consumeOneshotToken(buf []byte) (string, bool) {
 	oneshotTokensMutex.Lock()
 	defer oneshotTokensMutex.Unlock()
 
@@ -68,8 +70,8 @@ func consumeOneshotToken(buf []byte) bool {
 	for i, token := range oneshotTokens {
 		if bytes.Equal(token.buf, buf) {
 			oneshotTokens = append(oneshotTokens[:i], oneshotTokens[i+1:]...)
-			return now.Before(token.expire)
+			return token.username, now.Before(token.expire)
 		}
 	}
-	return false
+	return "", false
 }
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"marlinraker/src/database"
+	"marlinraker/src/util"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type User struct {
+	Username  string  `json:"username"`
+	Source    string  `json:"source"`
+	CreatedOn float64 `json:"created_on"`
+	Password  string  `json:"password,omitempty"`
+	Secret    string  `json:"jwt_secret,omitempty"`
+}
+
+const (
+	usersNamespace  = "authorized_users"
+	trustedUsername = "_TRUSTED_USER_"
+	apiKeyUsername  = "_API_KEY_USER_"
+	defaultSource   = "moonraker"
+)
+
+var (
+	trustedUser   = &User{Username: trustedUsername, Source: defaultSource}
+	apiKeyUser    = &User{Username: apiKeyUsername, Source: defaultSource}
+	usernameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+func (user *User) IsSpecial() bool {
+	return user.Username == trustedUsername || user.Username == apiKeyUsername
+}
+
+func GetUser(username string) (*User, error) {
+	switch username {
+	case trustedUsername:
+		return trustedUser, nil
+	case apiKeyUsername:
+		return apiKeyUser, nil
+	}
+	if !usernameRegex.MatchString(username) {
+		return nil, util.NewErrorf(404, "user %q does not exist", username)
+	}
+
+	item, err := database.GetItem(usersNamespace, username, true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return nil, util.NewErrorf(404, "user %q does not exist", username)
+		}
+		return nil, err
+	}
+	return decodeUser(item)
+}
+
+func ListUsers() ([]User, error) {
+	item, err := database.GetItem(usersNamespace, "", true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return []User{}, nil
+		}
+		return nil, err
+	}
+
+	entries, _ := item.(map[string]any)
+	users := make([]User, 0, len(entries))
+	for name, entry := range entries {
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		user, err := decodeUser(entry)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users, nil
+}
+
+func CreateUser(username string, password string) (*User, error) {
+	if !usernameRegex.MatchString(username) || strings.HasPrefix(username, "_") {
+		return nil, util.NewErrorf(400, "invalid username %q", username)
+	}
+	if password == "" {
+		return nil, util.NewError(400, "password must not be empty")
+	}
+	if _, err := GetUser(username); err == nil {
+		return nil, util.NewErrorf(400, "user %q already exists", username)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{
+		Username:  username,
+		Source:    defaultSource,
+		CreatedOn: float64(time.Now().UnixMilli()) / 1000.0,
+		Password:  string(hash),
+		Secret:    secret,
+	}
+	if err := saveUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func DeleteUser(username string) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSpecial() {
+		return nil, util.NewErrorf(400, "cannot delete user %q", username)
+	}
+	if _, err := database.DeleteItem(usersNamespace, username, true); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func Login(username string, password string) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil || user.IsSpecial() {
+		return nil, util.NewError(401, "invalid username or password")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, util.NewError(401, "invalid username or password")
+	}
+	return user, nil
+}
+
+func Logout(user *User) error {
+	if user.IsSpecial() {
+		return util.NewErrorf(400, "cannot log out user %q", user.Username)
+	}
+	return rotateSecret(user)
+}
+
+func ChangePassword(user *User, password string, newPassword string) error {
+	if user.IsSpecial() {
+		return util.NewErrorf(400, "cannot change password of user %q", user.Username)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return util.NewError(401, "invalid password")
+	}
+	if newPassword == "" {
+		return util.NewError(400, "password must not be empty")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hash)
+	return rotateSecret(user)
+}
+
+func rotateSecret(user *User) error {
+	secret, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	user.Secret = secret
+	return saveUser(user)
+}
+
+func saveUser(user *User) error {
+	_, err := database.PostItem(usersNamespace, user.Username, user, true)
+	return err
+}
+
+func decodeUser(item any) (*User, error) {
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{}
+	if err := json.Unmarshal(bytes, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
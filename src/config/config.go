@@ -18,11 +18,35 @@ type Web struct {
 	CorsDomains []string `toml:"cors_domains"`
 }
 
+type Authorization struct {
+	Enabled        bool     `toml:"enabled"`
+	TrustedClients []string `toml:"trusted_clients"`
+	LoginTimeout   int      `toml:"login_timeout"`
+}
+
 type Serial struct {
 	Port                  string      `toml:"port"`
 	BaudRate              interface{} `toml:"baud_rate"`
 	MaxConnectionAttempts int         `toml:"max_connection_attempts"`
 	ConnectionTimeout     int         `toml:"connection_timeout"`
+	Checksum              bool        `toml:"checksum"`
+}
+
+type Reconnect struct {
+	Enabled       bool     `toml:"enabled"`
+	InitialDelay  float64  `toml:"initial_delay"`
+	MaxDelay      float64  `toml:"max_delay"`
+	BackoffFactor float64  `toml:"backoff_factor"`
+	WatchPaths    []string `toml:"watch_paths"`
+	WatchInterval float64  `toml:"watch_interval"`
+}
+
+type VirtualPrinter struct {
+	MachineType  string          `toml:"machine_type"`
+	Capabilities map[string]bool `toml:"capabilities"`
+	TimeScale    float64         `toml:"time_scale"`
+	HeatingRate  float64         `toml:"heating_rate"`
+	AmbientTemp  float64         `toml:"ambient_temp"`
 }
 
 type Misc struct {
@@ -31,6 +55,29 @@ type Misc struct {
 	AllowedServices []string `toml:"allowed_services"`
 }
 
+type JobQueue struct {
+	Printer             string  `toml:"printer"`
+	LoadOnStartup       bool    `toml:"load_on_startup"`
+	AutomaticTransition bool    `toml:"automatic_transition"`
+	TransitionDelay     float64 `toml:"transition_delay"`
+	WaitForCooldown     bool    `toml:"wait_for_cooldown"`
+	CooldownTemp        float64 `toml:"cooldown_temp"`
+}
+
+type UpdateClient struct {
+	Path     string `toml:"path"`
+	Manifest string `toml:"manifest"`
+	Channel  string `toml:"channel"`
+}
+
+type UpdateManager struct {
+	Enabled    bool                    `toml:"enabled"`
+	Manifest   string                  `toml:"manifest"`
+	Channel    string                  `toml:"channel"`
+	BinaryPath string                  `toml:"binary_path"`
+	Clients    map[string]UpdateClient `toml:"clients"`
+}
+
 type Heater struct {
 	MinTemp int `toml:"min_temp"`
 	MaxTemp int `toml:"max_temp"`
@@ -47,31 +94,70 @@ type HeaterBed struct {
 }
 
 type Gcode struct {
-	SendM73        bool `toml:"send_m73"`
-	ReportVelocity bool `toml:"report_velocity"`
+	SendM73        bool    `toml:"send_m73"`
+	M73Interval    float64 `toml:"m73_interval"`
+	ReportVelocity bool    `toml:"report_velocity"`
+}
+
+type Fans struct {
+	PartFan    int            `toml:"part_fan"`
+	Generic    map[string]int `toml:"generic"`
+	HeaterFans map[string]int `toml:"heater_fans"`
+	ReportRpm  bool           `toml:"report_rpm"`
+}
+
+type PowerLossRecovery struct {
+	Enabled            bool    `toml:"enabled"`
+	CheckpointInterval float64 `toml:"checkpoint_interval"`
+	ZLift              float64 `toml:"z_lift"`
+}
+
+type ThermalSafety struct {
+	Enabled        bool    `toml:"enabled"`
+	HeatingTimeout float64 `toml:"heating_timeout"`
+	MaxDeviation   float64 `toml:"max_deviation"`
+	StuckTimeout   float64 `toml:"stuck_timeout"`
+	ReportTimeout  float64 `toml:"report_timeout"`
 }
 
 type Printer struct {
-	BedMesh     bool      `toml:"bed_mesh"`
-	AxisMinimum [3]int    `toml:"axis_minimum"`
-	AxisMaximum [3]int    `toml:"axis_maximum"`
-	Extruder    Extruder  `toml:"extruder"`
-	HeaterBed   HeaterBed `toml:"heater_bed"`
-	Gcode       Gcode     `toml:"gcode"`
+	BedMesh           bool              `toml:"bed_mesh"`
+	AxisMinimum       [3]int            `toml:"axis_minimum"`
+	AxisMaximum       [3]int            `toml:"axis_maximum"`
+	Extruder          Extruder          `toml:"extruder"`
+	HeaterBed         HeaterBed         `toml:"heater_bed"`
+	Gcode             Gcode             `toml:"gcode"`
+	Fans              Fans              `toml:"fans"`
+	PowerLossRecovery PowerLossRecovery `toml:"power_loss_recovery"`
+	ThermalSafety     ThermalSafety     `toml:"thermal_safety"`
 }
 
 type Macro struct {
 	RenameExisting string         `toml:"rename_existing"`
+	TemplateEngine string         `toml:"template_engine"`
 	Variables      map[string]any `toml:"variables"`
 	Gcode          string         `toml:"gcode"`
 }
 
+type DelayedGcode struct {
+	InitialDuration float64 `toml:"initial_duration"`
+	TemplateEngine  string  `toml:"template_engine"`
+	Gcode           string  `toml:"gcode"`
+}
+
 type Config struct {
-	Web     Web              `toml:"web"`
-	Serial  Serial           `toml:"serial"`
-	Misc    Misc             `toml:"misc"`
-	Printer Printer          `toml:"printer"`
-	Macros  map[string]Macro `toml:"macros"`
+	Web            Web                     `toml:"web"`
+	Authorization  Authorization           `toml:"authorization"`
+	Serial         Serial                  `toml:"serial"`
+	Printers       map[string]Serial       `toml:"printers"`
+	Reconnect      Reconnect               `toml:"reconnect"`
+	VirtualPrinter VirtualPrinter          `toml:"virtual_printer"`
+	Misc           Misc                    `toml:"misc"`
+	JobQueue       JobQueue                `toml:"job_queue"`
+	UpdateManager  UpdateManager           `toml:"update_manager"`
+	Printer        Printer                 `toml:"printer"`
+	Macros         map[string]Macro        `toml:"macros"`
+	DelayedGcodes  map[string]DelayedGcode `toml:"delayed_gcode"`
 }
 
 var includeRegex = regexp.MustCompile(`(?mi)^#include +(\S+).*$`)
@@ -162,17 +248,54 @@ func DefaultConfig() *Config {
 			Port:        7125,
 			CorsDomains: []string{},
 		},
+		Authorization: Authorization{
+			Enabled: true,
+			TrustedClients: []string{"10.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+				"192.168.0.0/16", "fe80::/10", "::1/128"},
+			LoginTimeout: 90,
+		},
 		Serial: Serial{
 			Port:                  "auto",
 			BaudRate:              "auto",
 			MaxConnectionAttempts: 5,
 			ConnectionTimeout:     5000,
 		},
+		Printers: map[string]Serial{},
+		Reconnect: Reconnect{
+			Enabled:       false,
+			InitialDelay:  1,
+			MaxDelay:      60,
+			BackoffFactor: 2,
+			WatchPaths:    []string{"/dev/serial/by-id"},
+			WatchInterval: 2,
+		},
+		VirtualPrinter: VirtualPrinter{
+			MachineType:  "Virtual Printer",
+			Capabilities: map[string]bool{},
+			TimeScale:    1,
+			HeatingRate:  3,
+			AmbientTemp:  25,
+		},
 		Misc: Misc{
 			OctoprintCompat: true,
 			ExtendedLogs:    false,
 			AllowedServices: []string{"marlinraker", "crowsnest", "MoonCord", "moonraker-telegram-bot", "KlipperScreen", "sonar", "webcamd"},
 		},
+		JobQueue: JobQueue{
+			Printer:             "",
+			LoadOnStartup:       false,
+			AutomaticTransition: false,
+			TransitionDelay:     0,
+			WaitForCooldown:     false,
+			CooldownTemp:        40,
+		},
+		UpdateManager: UpdateManager{
+			Enabled:    false,
+			Manifest:   "",
+			Channel:    "stable",
+			BinaryPath: "",
+			Clients:    map[string]UpdateClient{},
+		},
 		Printer: Printer{
 			BedMesh:     false,
 			AxisMinimum: [3]int{0, 0, 0},
@@ -193,15 +316,64 @@ func DefaultConfig() *Config {
 			},
 			Gcode: Gcode{
 				SendM73:        true,
+				M73Interval:    30,
 				ReportVelocity: true,
 			},
+			Fans: Fans{
+				PartFan:    0,
+				Generic:    map[string]int{},
+				HeaterFans: map[string]int{},
+				ReportRpm:  false,
+			},
+			PowerLossRecovery: PowerLossRecovery{
+				Enabled:            false,
+				CheckpointInterval: 10,
+				ZLift:              2,
+			},
+			ThermalSafety: ThermalSafety{
+				Enabled:        false,
+				HeatingTimeout: 300,
+				MaxDeviation:   15,
+				StuckTimeout:   60,
+				ReportTimeout:  10,
+			},
 		},
-		Macros: map[string]Macro{},
+		Macros:        map[string]Macro{},
+		DelayedGcodes: map[string]DelayedGcode{},
 	}
 }
 
 func parseConfig(contents string) (*Config, error) {
 	config := DefaultConfig()
-	_, err := toml.Decode(contents, config)
-	return config, err
+	metadata, err := toml.Decode(contents, config)
+	if err != nil {
+		return config, err
+	}
+
+	for name, serial := range config.Printers {
+		if !metadata.IsDefined("printers", name, "port") {
+			serial.Port = config.Serial.Port
+		}
+		if !metadata.IsDefined("printers", name, "baud_rate") {
+			serial.BaudRate = config.Serial.BaudRate
+		}
+		if !metadata.IsDefined("printers", name, "max_connection_attempts") {
+			serial.MaxConnectionAttempts = config.Serial.MaxConnectionAttempts
+		}
+		if !metadata.IsDefined("printers", name, "connection_timeout") {
+			serial.ConnectionTimeout = config.Serial.ConnectionTimeout
+		}
+		if !metadata.IsDefined("printers", name, "checksum") {
+			serial.Checksum = config.Serial.Checksum
+		}
+		config.Printers[name] = serial
+	}
+
+	for name, client := range config.UpdateManager.Clients {
+		if !metadata.IsDefined("update_manager", "clients", name, "channel") {
+			client.Channel = config.UpdateManager.Channel
+		}
+		config.UpdateManager.Clients[name] = client
+	}
+	return config, nil
 }
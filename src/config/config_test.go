@@ -27,11 +27,65 @@ func TestLoadConfig(t *testing.T) {
 			MaxConnectionAttempts: 5,
 			ConnectionTimeout:     5000,
 		},
+		Printers: map[string]Serial{
+			"left": {
+				Port:                  "/dev/ttyUSB0",
+				BaudRate:              int64(115200),
+				MaxConnectionAttempts: 5,
+				ConnectionTimeout:     5000,
+			},
+			"right": {
+				Port:                  "/dev/ttyUSB1",
+				BaudRate:              int64(250000),
+				MaxConnectionAttempts: 5,
+				ConnectionTimeout:     5000,
+				Checksum:              true,
+			},
+		},
+		Authorization: Authorization{
+			Enabled:        true,
+			TrustedClients: []string{"192.168.1.0/24", "127.0.0.1"},
+			LoginTimeout:   90,
+		},
+		Reconnect: Reconnect{
+			Enabled:       false,
+			InitialDelay:  5,
+			MaxDelay:      120,
+			BackoffFactor: 2,
+			WatchPaths:    []string{"/dev/serial/by-id", "/dev/ttyACM0"},
+			WatchInterval: 2,
+		},
+		VirtualPrinter: VirtualPrinter{
+			MachineType:  "Virtual Printer",
+			Capabilities: map[string]bool{},
+			TimeScale:    1,
+			HeatingRate:  3,
+			AmbientTemp:  25,
+		},
 		Misc: Misc{
 			OctoprintCompat: true,
 			ExtendedLogs:    false,
 			AllowedServices: []string{"service1", "service2"},
 		},
+		JobQueue: JobQueue{
+			LoadOnStartup:       false,
+			AutomaticTransition: true,
+			TransitionDelay:     30,
+			WaitForCooldown:     true,
+			CooldownTemp:        35,
+		},
+		UpdateManager: UpdateManager{
+			Enabled:  true,
+			Manifest: "https://example.com/marlinraker/manifest.json",
+			Channel:  "beta",
+			Clients: map[string]UpdateClient{
+				"mainsail": {
+					Path:     "/home/pi/mainsail",
+					Manifest: "/home/pi/releases/mainsail",
+					Channel:  "beta",
+				},
+			},
+		},
 		Printer: Printer{
 			BedMesh:     false,
 			AxisMinimum: [3]int{0, 0, 0},
@@ -52,8 +106,27 @@ func TestLoadConfig(t *testing.T) {
 			},
 			Gcode: Gcode{
 				SendM73:        true,
+				M73Interval:    10,
 				ReportVelocity: true,
 			},
+			Fans: Fans{
+				PartFan:    0,
+				Generic:    map[string]int{"exhaust": 1, "aux": 2},
+				HeaterFans: map[string]int{"hotend_fan": 0},
+				ReportRpm:  true,
+			},
+			PowerLossRecovery: PowerLossRecovery{
+				Enabled:            false,
+				CheckpointInterval: 10,
+				ZLift:              2,
+			},
+			ThermalSafety: ThermalSafety{
+				Enabled:        false,
+				HeatingTimeout: 300,
+				MaxDeviation:   15,
+				StuckTimeout:   60,
+				ReportTimeout:  10,
+			},
 		},
 		Macros: map[string]Macro{
 			"start_print": {
@@ -61,7 +134,14 @@ func TestLoadConfig(t *testing.T) {
 				Gcode:          "multiline\nmacro\n",
 			},
 			"test": {
-				Gcode: "another test macro",
+				TemplateEngine: "jinja",
+				Gcode:          "another test macro",
+			},
+		},
+		DelayedGcodes: map[string]DelayedGcode{
+			"bed_off": {
+				InitialDuration: 600,
+				Gcode:           "M140 S0",
 			},
 		},
 	})
@@ -38,7 +38,7 @@ func GenerateFakeKlipperConfig(config *Config) (map[string]any, map[string]any)
 		}
 	}
 
-	configuration := stringifySettings(settings)
+	configuration := StringifySettings(settings)
 
 	for name, macro := range config.Macros {
 		macroJson := map[string]any{
@@ -54,15 +54,24 @@ func GenerateFakeKlipperConfig(config *Config) (map[string]any, map[string]any)
 		configuration["gcode_macro "+strings.ToUpper(name)] = macroJson
 	}
 
+	for id, delayedGcode := range config.DelayedGcodes {
+		delayedGcodeJson := map[string]any{
+			"gcode":            delayedGcode.Gcode,
+			"initial_duration": delayedGcode.InitialDuration,
+		}
+		settings["delayed_gcode "+strings.ToLower(id)] = delayedGcodeJson
+		configuration["delayed_gcode "+strings.ToLower(id)] = StringifySettings(delayedGcodeJson)
+	}
+
 	return settings, configuration
 }
 
-func stringifySettings(settings map[string]any) map[string]any {
+func StringifySettings(settings map[string]any) map[string]any {
 	configuration := make(map[string]any)
 	for key, value := range settings {
 		switch value := value.(type) {
 		case map[string]any:
-			configuration[key] = stringifySettings(value)
+			configuration[key] = StringifySettings(value)
 		case []int:
 			configuration[key] = strings.Join(lo.Map(value, func(i int, _ int) string {
 				return fmt.Sprint(i)
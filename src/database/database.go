@@ -16,13 +16,15 @@ import (
 )
 
 var (
-	ReservedNamespaces = []string{"marlinraker", "moonraker", "gcode_metadata", "history"}
+	ReservedNamespaces = []string{"marlinraker", "moonraker", "gcode_metadata", "history", "authorized_users"}
 	dbFile             string
 	json               string
 	mu                 = &sync.RWMutex{}
 )
 
 func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
 
 	dbFile = filepath.Join(files.DataDir, "db.json")
 	file, err := files.Fs.OpenFile(dbFile, os.O_CREATE|os.O_RDWR, 0755)
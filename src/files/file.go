@@ -30,6 +30,7 @@ type FileUploadAction struct {
 	Item         ActionItem `json:"item"`
 	Action       string     `json:"action"`
 	PrintStarted *bool      `json:"print_started,omitempty"`
+	PrintQueued  *bool      `json:"print_queued,omitempty"`
 }
 
 type FileDeleteAction struct {
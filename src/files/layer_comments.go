@@ -0,0 +1,40 @@
+package files
+
+import (
+	"strconv"
+	"strings"
+)
+
+type LayerCommentKind int
+
+const (
+	NoLayerComment LayerCommentKind = iota
+	LayerChange
+	LayerNumber
+	LayerCount
+)
+
+func ParseLayerComment(line string) (LayerCommentKind, int) {
+	if !strings.HasPrefix(line, ";") {
+		return NoLayerComment, 0
+	}
+	comment := strings.TrimSpace(line[1:])
+
+	switch {
+	case comment == "LAYER_CHANGE":
+		return LayerChange, 0
+
+	case strings.HasPrefix(comment, "LAYER:"):
+		layer, err := strconv.Atoi(strings.TrimSpace(comment[6:]))
+		if err == nil {
+			return LayerNumber, layer + 1
+		}
+
+	case strings.HasPrefix(comment, "LAYER_COUNT:"):
+		count, err := strconv.Atoi(strings.TrimSpace(comment[12:]))
+		if err == nil {
+			return LayerCount, count
+		}
+	}
+	return NoLayerComment, 0
+}
@@ -19,27 +19,29 @@ type Thumbnail struct {
 }
 
 type Metadata struct {
-	FileName            string      `json:"filename"`
-	Size                int64       `json:"size"`
-	Modified            float64     `json:"modified"`
-	PrintStartTime      float64     `json:"print_start_time,omitempty"`
-	JobId               string      `json:"job_id,omitempty"`
-	Slicer              string      `json:"slicer,omitempty"`
-	SlicerVersion       string      `json:"slicer_version,omitempty"`
-	LayerHeight         float64     `json:"layer_height,omitempty"`
-	FirstLayerHeight    float64     `json:"first_layer_height,omitempty"`
-	ObjectHeight        float64     `json:"object_height,omitempty"`
-	FilamentTotal       float64     `json:"filament_total,omitempty"`
-	EstimatedTime       float64     `json:"estimated_time,omitempty"`
-	Thumbnails          []Thumbnail `json:"thumbnails,omitempty"`
-	FirstLayerBedTemp   float64     `json:"first_layer_bed_temp,omitempty"`
-	FirstLayerExtrTemp  float64     `json:"first_layer_extr_temp,omitempty"`
-	GcodeStartByte      int64       `json:"gcode_start_byte,omitempty"`
-	GcodeEndByte        int64       `json:"gcode_end_byte,omitempty"`
-	NozzleDiameter      float64     `json:"nozzle_diameter,omitempty"`
-	FilamentName        string      `json:"filament_name,omitempty"`
-	FilamentType        string      `json:"filament_type,omitempty"`
-	FilamentWeightTotal float64     `json:"filament_weight_total,omitempty"`
+	FileName            string             `json:"filename"`
+	Size                int64              `json:"size"`
+	Modified            float64            `json:"modified"`
+	PrintStartTime      float64            `json:"print_start_time,omitempty"`
+	JobId               string             `json:"job_id,omitempty"`
+	Slicer              string             `json:"slicer,omitempty"`
+	SlicerVersion       string             `json:"slicer_version,omitempty"`
+	LayerHeight         float64            `json:"layer_height,omitempty"`
+	FirstLayerHeight    float64            `json:"first_layer_height,omitempty"`
+	ObjectHeight        float64            `json:"object_height,omitempty"`
+	FilamentTotal       float64            `json:"filament_total,omitempty"`
+	EstimatedTime       float64            `json:"estimated_time,omitempty"`
+	Thumbnails          []Thumbnail        `json:"thumbnails,omitempty"`
+	FirstLayerBedTemp   float64            `json:"first_layer_bed_temp,omitempty"`
+	FirstLayerExtrTemp  float64            `json:"first_layer_extr_temp,omitempty"`
+	GcodeStartByte      int64              `json:"gcode_start_byte,omitempty"`
+	GcodeEndByte        int64              `json:"gcode_end_byte,omitempty"`
+	NozzleDiameter      float64            `json:"nozzle_diameter,omitempty"`
+	FilamentName        string             `json:"filament_name,omitempty"`
+	FilamentType        string             `json:"filament_type,omitempty"`
+	FilamentWeightTotal float64            `json:"filament_weight_total,omitempty"`
+	LayerCount          int                `json:"layer_count,omitempty"`
+	Objects             []ObjectDefinition `json:"objects,omitempty"`
 }
 
 func RemoveUnusedMetadata() error {
@@ -55,6 +55,7 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 	}
 
 	thumbnailData := make([]imageData, 0)
+	var layers layerScan
 	reader := bufio.NewReader(file)
 	var position int64
 	for {
@@ -74,6 +75,7 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 		}
 		if !strings.HasPrefix(line, ";") {
 			metadata.GcodeStartByte = startPos
+			scanGcodeLine(metadata, &layers, line)
 			break
 		}
 
@@ -132,13 +134,17 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 		}
 	}
 
-	toDiscard := stat.Size() - position - 50000
-	if toDiscard > 0 {
-		discarded, err := reader.Discard(int(toDiscard))
+	tailStart := stat.Size() - 50000
+	for position < tailStart {
+		bytes, err := reader.ReadBytes('\n')
 		if err != nil {
-			return nil, err
+			if err != io.EOF {
+				return nil, err
+			}
+			break
 		}
-		position += int64(discarded)
+		position += int64(len(bytes))
+		scanGcodeLine(metadata, &layers, strings.TrimSpace(string(bytes)))
 	}
 
 	for {
@@ -156,6 +162,7 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 			continue
 		}
 
+		scanGcodeLine(metadata, &layers, line)
 		if !strings.HasPrefix(line, ";") {
 			metadata.GcodeEndByte = position
 			continue
@@ -245,6 +252,19 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 		}
 	}
 
+	switch {
+	case layers.count > 0:
+		metadata.LayerCount = layers.count
+	case layers.changes > 0:
+		metadata.LayerCount = layers.changes
+	case metadata.LayerHeight > 0 && metadata.ObjectHeight > 0:
+		firstLayerHeight := metadata.FirstLayerHeight
+		if firstLayerHeight == 0 {
+			firstLayerHeight = metadata.LayerHeight
+		}
+		metadata.LayerCount = int(math.Ceil((metadata.ObjectHeight-firstLayerHeight)/metadata.LayerHeight-1e-6)) + 1
+	}
+
 	if len(thumbnailData) > 0 {
 		var has32, has300 bool
 		for _, data := range thumbnailData {
@@ -311,6 +331,29 @@ func ScanMetadata(fileName string) (*Metadata, error) {
 	return metadata, nil
 }
 
+type layerScan struct {
+	changes int
+	count   int
+}
+
+func scanGcodeLine(metadata *Metadata, layers *layerScan, line string) {
+	switch kind, value := ParseLayerComment(line); kind {
+	case LayerChange:
+		layers.changes++
+	case LayerCount:
+		layers.count = value
+	}
+
+	kind, definition, err := ParseObjectLabel(line)
+	if err != nil {
+		log.Warnf("Failed to parse object label in %q: %v", metadata.FileName, err)
+		return
+	}
+	if kind == ObjectDefine || kind == ObjectStart {
+		metadata.Objects = AddObjectDefinition(metadata.Objects, definition)
+	}
+}
+
 func extractThumbnail(header string, reader *bufio.Reader) (int64, imageData, error) {
 
 	var width, height int
@@ -48,6 +48,8 @@ func TestMetadata(t *testing.T) {
 		FilamentName:        `"Prusament PLA"`,
 		FilamentType:        "PLA",
 		FilamentWeightTotal: 12.52,
+		LayerCount:          240,
+		Objects:             []ObjectDefinition{{Name: "3DBENCHY_STL_ID_0_COPY_0"}},
 		Thumbnails: []Thumbnail{
 			{
 				Width:        160,
@@ -86,3 +88,49 @@ func TestMetadata(t *testing.T) {
 	hasMetadata = HasMetadata(fileName)
 	assert.Equal(t, hasMetadata, false)
 }
+
+func TestParseObjectLabel(t *testing.T) {
+	for _, test := range []struct {
+		line       string
+		kind       ObjectLabelKind
+		definition ObjectDefinition
+	}{
+		{"G1 X10 Y10", NoObjectLabel, ObjectDefinition{}},
+		{"; printing object 3DBenchy.stl id:0 copy 0", ObjectStart, ObjectDefinition{Name: "3DBENCHY_STL_ID_0_COPY_0"}},
+		{"; stop printing object 3DBenchy.stl id:0 copy 0", ObjectEnd, ObjectDefinition{Name: "3DBENCHY_STL_ID_0_COPY_0"}},
+		{";MESH:cube.stl", ObjectStart, ObjectDefinition{Name: "CUBE_STL"}},
+		{";MESH:NONMESH", ObjectEnd, ObjectDefinition{}},
+		{
+			"EXCLUDE_OBJECT_DEFINE NAME=cube_1 CENTER=110.5,100 POLYGON=[[100,90],[120,90],[120,110]]",
+			ObjectDefine,
+			ObjectDefinition{Name: "CUBE_1", Center: []float64{110.5, 100}, Polygon: [][]float64{{100, 90}, {120, 90}, {120, 110}}},
+		},
+		{"EXCLUDE_OBJECT_START NAME=cube_1", ObjectStart, ObjectDefinition{Name: "CUBE_1"}},
+		{"EXCLUDE_OBJECT_END NAME=cube_1", ObjectEnd, ObjectDefinition{Name: "CUBE_1"}},
+		{"EXCLUDE_OBJECT_END", ObjectEnd, ObjectDefinition{}},
+	} {
+		kind, definition, err := ParseObjectLabel(test.line)
+		assert.NilError(t, err, test.line)
+		assert.Equal(t, kind, test.kind, test.line)
+		assert.DeepEqual(t, definition, test.definition)
+	}
+
+	_, _, err := ParseObjectLabel("EXCLUDE_OBJECT_DEFINE CENTER=1,2")
+	assert.Error(t, err, `missing object name in "EXCLUDE_OBJECT_DEFINE CENTER=1,2"`)
+	_, _, err = ParseObjectLabel("EXCLUDE_OBJECT_DEFINE NAME=a CENTER=1")
+	assert.Error(t, err, `invalid object center "1"`)
+}
+
+func TestParseLayerComment(t *testing.T) {
+	for line, expected := range map[string][2]int{
+		"G1 Z0.2":         {int(NoLayerComment), 0},
+		";LAYER_CHANGE":   {int(LayerChange), 0},
+		";LAYER:0":        {int(LayerNumber), 1},
+		";LAYER:12":       {int(LayerNumber), 13},
+		";LAYER_COUNT:80": {int(LayerCount), 80},
+		";LAYER:abc":      {int(NoLayerComment), 0},
+	} {
+		kind, value := ParseLayerComment(line)
+		assert.DeepEqual(t, [2]int{int(kind), value}, expected)
+	}
+}
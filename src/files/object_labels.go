@@ -0,0 +1,109 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ObjectLabelKind int
+
+const (
+	NoObjectLabel ObjectLabelKind = iota
+	ObjectDefine
+	ObjectStart
+	ObjectEnd
+)
+
+type ObjectDefinition struct {
+	Name    string      `json:"name"`
+	Center  []float64   `json:"center,omitempty"`
+	Polygon [][]float64 `json:"polygon,omitempty"`
+}
+
+var (
+	excludeObjectRegex      = regexp.MustCompile(`^EXCLUDE_OBJECT_(DEFINE|START|END)(?:\s|$)`)
+	excludeObjectParamRegex = regexp.MustCompile(`(?i)(NAME|CENTER|POLYGON)=(\S+)`)
+	objectNameRegex         = regexp.MustCompile(`\W+`)
+)
+
+func NormalizeObjectName(name string) string {
+	return strings.ToUpper(strings.Trim(objectNameRegex.ReplaceAllString(strings.TrimSpace(name), "_"), "_"))
+}
+
+func ParseObjectLabel(line string) (ObjectLabelKind, ObjectDefinition, error) {
+
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, ";") {
+		comment := strings.TrimSpace(line[1:])
+		switch {
+		case strings.HasPrefix(comment, "printing object "):
+			return ObjectStart, ObjectDefinition{Name: NormalizeObjectName(comment[16:])}, nil
+		case strings.HasPrefix(comment, "stop printing object "):
+			return ObjectEnd, ObjectDefinition{Name: NormalizeObjectName(comment[21:])}, nil
+		case strings.HasPrefix(comment, "MESH:"):
+			if name := comment[5:]; name != "NONMESH" {
+				return ObjectStart, ObjectDefinition{Name: NormalizeObjectName(name)}, nil
+			}
+			return ObjectEnd, ObjectDefinition{}, nil
+		}
+		return NoObjectLabel, ObjectDefinition{}, nil
+	}
+
+	if !strings.HasPrefix(line, "EXCLUDE_OBJECT_") {
+		return NoObjectLabel, ObjectDefinition{}, nil
+	}
+	match := excludeObjectRegex.FindStringSubmatch(line)
+	if match == nil {
+		return NoObjectLabel, ObjectDefinition{}, nil
+	}
+
+	kind := map[string]ObjectLabelKind{"DEFINE": ObjectDefine, "START": ObjectStart, "END": ObjectEnd}[match[1]]
+	definition := ObjectDefinition{}
+	for _, param := range excludeObjectParamRegex.FindAllStringSubmatch(line, -1) {
+		value := param[2]
+		switch strings.ToUpper(param[1]) {
+		case "NAME":
+			definition.Name = NormalizeObjectName(value)
+
+		case "CENTER":
+			parts := strings.Split(value, ",")
+			if len(parts) != 2 {
+				return kind, definition, fmt.Errorf("invalid object center %q", value)
+			}
+			for _, part := range parts {
+				coordinate, err := strconv.ParseFloat(part, 64)
+				if err != nil {
+					return kind, definition, fmt.Errorf("invalid object center %q: %w", value, err)
+				}
+				definition.Center = append(definition.Center, coordinate)
+			}
+
+		case "POLYGON":
+			if err := json.Unmarshal([]byte(value), &definition.Polygon); err != nil {
+				return kind, definition, fmt.Errorf("invalid object polygon %q: %w", value, err)
+			}
+		}
+	}
+	if definition.Name == "" && kind != ObjectEnd {
+		return kind, definition, fmt.Errorf("missing object name in %q", line)
+	}
+	return kind, definition, nil
+}
+
+func AddObjectDefinition(objects []ObjectDefinition, definition ObjectDefinition) []ObjectDefinition {
+	for i, object := range objects {
+		if object.Name == definition.Name {
+			if definition.Center != nil {
+				objects[i].Center = definition.Center
+			}
+			if definition.Polygon != nil {
+				objects[i].Polygon = definition.Polygon
+			}
+			return objects
+		}
+	}
+	return append(objects, definition)
+}
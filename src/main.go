@@ -5,12 +5,16 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"marlinraker/src/api"
+	"marlinraker/src/auth"
 	"marlinraker/src/config"
 	"marlinraker/src/database"
 	"marlinraker/src/files"
 	"marlinraker/src/logger"
 	"marlinraker/src/marlinraker"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/marlinraker/job_queue"
 	"marlinraker/src/service"
+	"marlinraker/src/update_manager"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -68,17 +72,42 @@ func main() {
 		return
 	}
 
+	if err := history.Init(); err != nil {
+		log.Errorf("Unable to initialize job history: %v", err)
+		return
+	}
+
 	cfg, err := config.LoadConfig(filepath.Join(dataDir, "config/marlinraker.toml"))
 	if err != nil {
 		log.Errorf("Unable to load configuration: %v", err)
 		return
 	}
 
+	if err := auth.Init(cfg); err != nil {
+		log.Errorf("Unable to initialize authorization: %v", err)
+		return
+	}
+
+	if err := job_queue.Init(cfg); err != nil {
+		log.Errorf("Unable to initialize job queue: %v", err)
+		return
+	}
+
 	if err := service.Init(cfg); err != nil {
 		log.Warnf("Could not initialize service manager: %v", err)
 	}
 	defer service.Close()
 
+	if err := update_manager.Init(cfg); err != nil {
+		log.Errorf("Unable to initialize update manager: %v", err)
+	} else if update_manager.Enabled() {
+		go func() {
+			if err := update_manager.Refresh(""); err != nil {
+				log.Errorf("Failed to refresh update status: %v", err)
+			}
+		}()
+	}
+
 	marlinraker.Init(cfg)
 
 	go api.StartServer()
@@ -88,6 +117,7 @@ func main() {
 
 	<-ch
 	log.Println("Received interrupt, shutting down")
+	marlinraker.Close()
 }
 
 func checkAlreadyRunning(pidFilePath string) {
@@ -1,15 +1,83 @@
 package marlinraker
 
-import "marlinraker/src/printer_objects"
+import (
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer_objects"
+)
 
-type configFileObject struct{}
+type configFileObject struct {
+	instance *Instance
+}
+
+func (object configFileObject) Query() (printer_objects.QueryResult, error) {
+
+	settings, configuration := KlipperSettings, KlipperConfig
+	if printer := object.instance.Printer(); printer != nil {
+		settings, configuration = applyFirmwareSettings(printer.FirmwareSettings())
+	}
 
-func (configfileObject configFileObject) Query() (printer_objects.QueryResult, error) {
 	return printer_objects.QueryResult{
-		"settings":                  KlipperSettings,
-		"config":                    KlipperConfig,
+		"settings":                  settings,
+		"config":                    configuration,
 		"save_config_pending":       false,
 		"save_config_pending_items": []string{},
 		"warnings":                  []string{},
 	}, nil
 }
+
+func applyFirmwareSettings(firmwareSettings parser.FirmwareSettings) (map[string]any, map[string]any) {
+
+	sections := map[string]map[string]any{
+		"printer": {
+			"max_velocity":   max(firmwareSettings.MaxFeedrate[0], firmwareSettings.MaxFeedrate[1]),
+			"max_accel":      max(firmwareSettings.MaxAccel[0], firmwareSettings.MaxAccel[1]),
+			"max_z_velocity": firmwareSettings.MaxFeedrate[2],
+			"max_z_accel":    firmwareSettings.MaxAccel[2],
+		},
+		"extruder": {},
+	}
+	if firmwareSettings.Acceleration.Print > 0 {
+		sections["printer"]["max_accel"] = firmwareSettings.Acceleration.Print
+	}
+	if firmwareSettings.LinearAdvance != nil {
+		sections["extruder"]["pressure_advance"] = *firmwareSettings.LinearAdvance
+	}
+	if pid := firmwareSettings.HotendPid; pid != nil {
+		sections["extruder"]["control"] = "pid"
+		sections["extruder"]["pid_kp"], sections["extruder"]["pid_ki"], sections["extruder"]["pid_kd"] = pid.P, pid.I, pid.D
+	}
+	if pid := firmwareSettings.BedPid; pid != nil {
+		sections["heater_bed"] = map[string]any{"control": "pid", "pid_kp": pid.P, "pid_ki": pid.I, "pid_kd": pid.D}
+	}
+	if offset := firmwareSettings.ProbeOffset; offset != nil {
+		sections["probe"] = map[string]any{"x_offset": offset[0], "y_offset": offset[1], "z_offset": offset[2]}
+	}
+
+	settings, configuration := copySections(KlipperSettings), copySections(KlipperConfig)
+	for name, section := range sections {
+		settingsSection, _ := settings[name].(map[string]any)
+		configurationSection, _ := configuration[name].(map[string]any)
+		settings[name], configuration[name] = mergeSection(settingsSection, section), mergeSection(configurationSection, config.StringifySettings(section))
+	}
+	return settings, configuration
+}
+
+func copySections(sections map[string]any) map[string]any {
+	copied := make(map[string]any, len(sections))
+	for name, section := range sections {
+		copied[name] = section
+	}
+	return copied
+}
+
+func mergeSection(section map[string]any, values map[string]any) map[string]any {
+	merged := make(map[string]any, len(section)+len(values))
+	for key, value := range section {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
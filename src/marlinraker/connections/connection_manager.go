@@ -19,6 +19,7 @@ type Connection struct {
 	Version    string
 	ClientType string
 	Url        string
+	Username   string
 }
 
 func (connection *Connection) WriteText(bytes []byte) error {
@@ -0,0 +1,325 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/util"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	InProgress  JobStatus = "in_progress"
+	Completed   JobStatus = "completed"
+	Cancelled   JobStatus = "cancelled"
+	Error       JobStatus = "error"
+	Interrupted JobStatus = "interrupted"
+)
+
+type Job struct {
+	JobId         string          `json:"job_id"`
+	Exists        bool            `json:"exists"`
+	EndTime       *float64        `json:"end_time"`
+	FilamentUsed  float64         `json:"filament_used"`
+	FileName      string          `json:"filename"`
+	Metadata      *files.Metadata `json:"metadata"`
+	PrintDuration float64         `json:"print_duration"`
+	Status        JobStatus       `json:"status"`
+	StartTime     float64         `json:"start_time"`
+	TotalDuration float64         `json:"total_duration"`
+}
+
+type Totals struct {
+	TotalJobs         int     `json:"total_jobs"`
+	TotalTime         float64 `json:"total_time"`
+	TotalPrintTime    float64 `json:"total_print_time"`
+	TotalFilamentUsed float64 `json:"total_filament_used"`
+	LongestJob        float64 `json:"longest_job"`
+	LongestPrint      float64 `json:"longest_print"`
+}
+
+type Progress struct {
+	TotalDuration float64
+	PrintDuration float64
+	FilamentUsed  float64
+}
+
+const namespace = "history"
+
+var (
+	jobs      = make([]Job, 0)
+	totals    Totals
+	nextJobId int64
+	mu        = &sync.RWMutex{}
+)
+
+func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	jobs, totals, nextJobId = make([]Job, 0), Totals{}, 0
+
+	if err := loadItem("jobs", &jobs); err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
+	}
+	if err := loadItem("job_totals", &totals); err != nil {
+		return fmt.Errorf("failed to load job totals: %w", err)
+	}
+	if err := loadItem("next_job_id", &nextJobId); err != nil {
+		return fmt.Errorf("failed to load next job id: %w", err)
+	}
+
+	interrupted := false
+	for i, job := range jobs {
+		if job.Status == InProgress {
+			jobs[i].Status = Interrupted
+			interrupted = true
+		}
+	}
+	if interrupted {
+		log.Println("Marked unfinished print jobs in history as interrupted")
+		return saveJobs()
+	}
+	return nil
+}
+
+func AddJob(fileName string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	metadata, err := files.LoadOrScanMetadata(fileName)
+	if err != nil {
+		log.Errorf("Failed to load metadata for %q: %v", fileName, err)
+		metadata = nil
+	}
+
+	nextJobId++
+	if _, err := database.PostItem(namespace, "next_job_id", nextJobId, true); err != nil {
+		return "", err
+	}
+
+	job := Job{
+		JobId:     fmt.Sprintf("%06X", nextJobId),
+		FileName:  fileName,
+		Metadata:  metadata,
+		Status:    InProgress,
+		StartTime: now(),
+	}
+	jobs = append(jobs, job)
+	if err := saveJobs(); err != nil {
+		return "", err
+	}
+
+	publishChanged("added", job)
+	return job.JobId, nil
+}
+
+func UpdateJob(jobId string, progress Progress) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx := findJob(jobId)
+	if idx == -1 {
+		return util.NewErrorf(404, "job %q not found", jobId)
+	}
+
+	job := &jobs[idx]
+	job.TotalDuration, job.PrintDuration, job.FilamentUsed =
+		progress.TotalDuration, progress.PrintDuration, progress.FilamentUsed
+	return saveJobs()
+}
+
+func FinishJob(jobId string, status JobStatus, progress Progress) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx := findJob(jobId)
+	if idx == -1 {
+		return util.NewErrorf(404, "job %q not found", jobId)
+	}
+
+	endTime := now()
+	job := &jobs[idx]
+	job.Status, job.EndTime = status, &endTime
+	job.TotalDuration, job.PrintDuration, job.FilamentUsed =
+		progress.TotalDuration, progress.PrintDuration, progress.FilamentUsed
+
+	totals.TotalJobs++
+	totals.TotalTime += job.TotalDuration
+	totals.TotalPrintTime += job.PrintDuration
+	totals.TotalFilamentUsed += job.FilamentUsed
+	totals.LongestJob = max(totals.LongestJob, job.TotalDuration)
+	totals.LongestPrint = max(totals.LongestPrint, job.PrintDuration)
+
+	if err := saveJobs(); err != nil {
+		return err
+	}
+	if _, err := database.PostItem(namespace, "job_totals", totals, true); err != nil {
+		return err
+	}
+
+	publishChanged("finished", *job)
+	return nil
+}
+
+func ListJobs(start int, limit int, since float64, before float64, ascending bool) (int, []Job) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	filtered := lo.Filter(jobs, func(job Job, _ int) bool {
+		return (since <= 0 || job.StartTime > since) && (before <= 0 || job.StartTime < before)
+	})
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if ascending {
+			return filtered[i].StartTime < filtered[j].StartTime
+		}
+		return filtered[i].StartTime > filtered[j].StartTime
+	})
+
+	count := len(filtered)
+	start = max(start, 0)
+	if start >= count {
+		return count, make([]Job, 0)
+	}
+	filtered = filtered[start:]
+	if limit > 0 && limit < len(filtered) {
+		filtered = filtered[:limit]
+	}
+	return count, lo.Map(filtered, func(job Job, _ int) Job {
+		return withExists(job)
+	})
+}
+
+func GetJob(jobId string) (Job, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	idx := findJob(jobId)
+	if idx == -1 {
+		return Job{}, util.NewErrorf(404, "job %q not found", jobId)
+	}
+	return withExists(jobs[idx]), nil
+}
+
+func DeleteJob(jobId string) ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx := findJob(jobId)
+	if idx == -1 {
+		return nil, util.NewErrorf(404, "job %q not found", jobId)
+	}
+	if jobs[idx].Status == InProgress {
+		return nil, util.NewErrorf(400, "job %q is still in progress", jobId)
+	}
+
+	jobs = append(jobs[:idx], jobs[idx+1:]...)
+	if err := saveJobs(); err != nil {
+		return nil, err
+	}
+	return []string{jobId}, nil
+}
+
+func DeleteAllJobs() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	deleted := make([]string, 0)
+	kept := make([]Job, 0)
+	for _, job := range jobs {
+		if job.Status == InProgress {
+			kept = append(kept, job)
+		} else {
+			deleted = append(deleted, job.JobId)
+		}
+	}
+
+	jobs = kept
+	if err := saveJobs(); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func GetTotals() Totals {
+	mu.RLock()
+	defer mu.RUnlock()
+	return totals
+}
+
+func ResetTotals() (Totals, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	last := totals
+	totals = Totals{}
+	if _, err := database.PostItem(namespace, "job_totals", totals, true); err != nil {
+		return last, err
+	}
+	return last, nil
+}
+
+func findJob(jobId string) int {
+	_, idx, found := lo.FindIndexOf(jobs, func(job Job) bool {
+		return job.JobId == jobId
+	})
+	if !found {
+		return -1
+	}
+	return idx
+}
+
+func withExists(job Job) Job {
+	_, err := files.Fs.Stat(filepath.Join(files.DataDir, "gcodes", job.FileName))
+	job.Exists = err == nil
+	return job
+}
+
+func saveJobs() error {
+	if _, err := database.PostItem(namespace, "jobs", jobs, true); err != nil {
+		return fmt.Errorf("failed to save jobs: %w", err)
+	}
+	return nil
+}
+
+func loadItem(key string, v any) error {
+	item, err := database.GetItem(namespace, key, true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return nil
+		}
+		return err
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+func publishChanged(action string, job Job) {
+	err := notification.Publish(notification.New("notify_history_changed", []any{map[string]any{
+		"action": action,
+		"job":    withExists(job),
+	}}))
+	if err != nil {
+		log.Errorf("Failed to publish notification: %v", err)
+	}
+}
+
+func now() float64 {
+	return float64(time.Now().UnixMilli()) / 1000.0
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"testing"
+)
+
+func setup(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+	assert.NilError(t, Init())
+}
+
+func TestJobLifecycle(t *testing.T) {
+	setup(t)
+
+	id1, err := AddJob("first.gcode")
+	assert.NilError(t, err)
+	assert.Equal(t, id1, "000001")
+
+	id2, err := AddJob("second.gcode")
+	assert.NilError(t, err)
+	assert.Equal(t, id2, "000002")
+
+	_, err = DeleteJob(id1)
+	assert.Error(t, err, `job "000001" is still in progress`)
+
+	err = FinishJob(id1, Completed, Progress{TotalDuration: 100, PrintDuration: 80, FilamentUsed: 1000})
+	assert.NilError(t, err)
+	err = FinishJob(id2, Cancelled, Progress{TotalDuration: 50, PrintDuration: 60, FilamentUsed: 200})
+	assert.NilError(t, err)
+
+	job, err := GetJob(id1)
+	assert.NilError(t, err)
+	assert.Equal(t, job.Status, Completed)
+	assert.Equal(t, job.FileName, "first.gcode")
+	assert.Equal(t, job.Exists, false)
+	assert.Assert(t, job.EndTime != nil)
+
+	assert.DeepEqual(t, GetTotals(), Totals{
+		TotalJobs:         2,
+		TotalTime:         150,
+		TotalPrintTime:    140,
+		TotalFilamentUsed: 1200,
+		LongestJob:        100,
+		LongestPrint:      80,
+	})
+
+	count, jobs := ListJobs(0, 1, 0, 0, true)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, len(jobs), 1)
+	assert.Equal(t, jobs[0].JobId, id1)
+
+	_, jobs = ListJobs(5, 50, 0, 0, false)
+	assert.Equal(t, len(jobs), 0)
+
+	count, jobs = ListJobs(-1, -1, 0, 0, true)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, len(jobs), 2)
+
+	last, err := ResetTotals()
+	assert.NilError(t, err)
+	assert.Equal(t, last.TotalJobs, 2)
+	assert.DeepEqual(t, GetTotals(), Totals{})
+
+	deleted, err := DeleteJob(id1)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, deleted, []string{id1})
+
+	_, err = GetJob(id1)
+	assert.Error(t, err, `job "000001" not found`)
+}
+
+func TestInterruptedJobs(t *testing.T) {
+	setup(t)
+
+	id, err := AddJob("test.gcode")
+	assert.NilError(t, err)
+
+	// simulate restart
+	assert.NilError(t, database.Init())
+	assert.NilError(t, Init())
+
+	job, err := GetJob(id)
+	assert.NilError(t, err)
+	assert.Equal(t, job.Status, Interrupted)
+
+	next, err := AddJob("test.gcode")
+	assert.NilError(t, err)
+	assert.Equal(t, next, "000002")
+
+	deleted, err := DeleteAllJobs()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, deleted, []string{id})
+}
@@ -0,0 +1,79 @@
+package marlinraker
+
+import (
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func watchDevices(paths []string, interval time.Duration) {
+	known := listDevices(paths)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		known = checkDevices(paths, known)
+	}
+}
+
+func checkDevices(paths []string, known map[string]string) map[string]string {
+	devices := listDevices(paths)
+	added, removed := lo.Difference(lo.Keys(devices), lo.Keys(known))
+
+	for _, device := range removed {
+		log.Printf("Serial device %s was removed", device)
+		for _, instance := range Instances {
+			printer := instance.Printer()
+			if printer == nil || instance.Port() != known[device] {
+				continue
+			}
+			if err := printer.Disconnect(); err != nil {
+				log.Errorf("Failed to disconnect from printer: %v", err)
+			}
+		}
+	}
+
+	if len(added) > 0 {
+		log.Printf("Serial devices %v were added", added)
+		for _, instance := range Instances {
+			if state := instance.State(); !instance.isHalted() && (state == Error || state == Shutdown) {
+				instance.Wake()
+			}
+		}
+	}
+
+	return devices
+}
+
+func listDevices(paths []string) map[string]string {
+	devices := make(map[string]string)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			devices[path] = resolveDevice(path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			log.Errorf("Failed to read directory %q: %v", path, err)
+			continue
+		}
+		for _, entry := range entries {
+			device := filepath.Join(path, entry.Name())
+			devices[device] = resolveDevice(device)
+		}
+	}
+	return devices
+}
+
+func resolveDevice(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
@@ -0,0 +1,347 @@
+package job_queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	Ready    State = "ready"
+	Loading  State = "loading"
+	Starting State = "starting"
+	Paused   State = "paused"
+)
+
+type QueuedJob struct {
+	FileName    string  `json:"filename"`
+	JobId       string  `json:"job_id"`
+	TimeAdded   float64 `json:"time_added"`
+	TimeInQueue float64 `json:"time_in_queue"`
+}
+
+var (
+	queue       = make([]QueuedJob, 0)
+	state       = Ready
+	nextJobId   int64
+	cfg         config.JobQueue
+	printerName string
+	mu          = &sync.Mutex{}
+)
+
+func Init(config *config.Config) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cfg = config.JobQueue
+	printerName = cfg.Printer
+	if len(config.Printers) > 0 {
+		if printerName == "" {
+			names := lo.Keys(config.Printers)
+			sort.Strings(names)
+			printerName = names[0]
+		} else if _, exists := config.Printers[printerName]; !exists {
+			return fmt.Errorf("job queue printer %q is not configured", printerName)
+		}
+	}
+
+	queue, state = make([]QueuedJob, 0), Ready
+	if !cfg.LoadOnStartup {
+		state = Paused
+	}
+
+	item, err := database.GetItem("marlinraker", "job_queue", true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return nil
+		}
+		return fmt.Errorf("failed to load job queue: %w", err)
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes, &queue); err != nil {
+		return fmt.Errorf("failed to load job queue: %w", err)
+	}
+
+	for _, job := range queue {
+		if id, err := strconv.ParseInt(job.JobId, 16, 64); err == nil {
+			nextJobId = max(nextJobId, id)
+		}
+	}
+	return nil
+}
+
+func PrinterName() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return printerName
+}
+
+func Status() (State, []QueuedJob) {
+	mu.Lock()
+	defer mu.Unlock()
+	return state, queuedJobs()
+}
+
+func PostJobs(printer shared.Printer, fileNames []string, reset bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if reset {
+		queue = make([]QueuedJob, 0)
+	}
+
+	now := now()
+	for _, fileName := range fileNames {
+		nextJobId++
+		queue = append(queue, QueuedJob{
+			FileName:  fileName,
+			JobId:     fmt.Sprintf("%016X", nextJobId),
+			TimeAdded: now,
+		})
+	}
+
+	if err := save(); err != nil {
+		return err
+	}
+	publishChanged("jobs_added")
+
+	if state == Ready && canLoad(printer) {
+		loadNext(printer, false)
+	}
+	return nil
+}
+
+func DeleteJobs(jobIds []string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	queue = lo.Filter(queue, func(job QueuedJob, _ int) bool {
+		return !lo.Contains(jobIds, job.JobId)
+	})
+	if err := save(); err != nil {
+		return err
+	}
+	publishChanged("jobs_removed")
+	return nil
+}
+
+func DeleteAllJobs() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	queue = make([]QueuedJob, 0)
+	if err := save(); err != nil {
+		return err
+	}
+	publishChanged("jobs_removed")
+	return nil
+}
+
+func Pause() {
+	mu.Lock()
+	defer mu.Unlock()
+	setState(Paused)
+}
+
+func Start(printer shared.Printer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if state == Loading || state == Starting {
+		return
+	}
+	setState(Ready)
+	if canLoad(printer) {
+		loadNext(printer, false)
+	}
+}
+
+func Jump(jobId string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	job, idx, found := lo.FindIndexOf(queue, func(job QueuedJob) bool {
+		return job.JobId == jobId
+	})
+	if !found {
+		return util.NewErrorf(404, "job %q not found", jobId)
+	}
+
+	queue = append(queue[:idx], queue[idx+1:]...)
+	queue = append([]QueuedJob{job}, queue...)
+	if err := save(); err != nil {
+		return err
+	}
+	publishChanged("jobs_added")
+	return nil
+}
+
+func JobFinished(printer shared.Printer, printState string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if printer.Name() != printerName || state != Ready || len(queue) == 0 {
+		return
+	}
+	if printState != "complete" || !cfg.AutomaticTransition {
+		setState(Paused)
+		return
+	}
+	loadNext(printer, true)
+}
+
+func canLoad(printer shared.Printer) bool {
+	return printer != nil && printer.Name() == printerName && len(queue) > 0 && !printer.GetPrintManager().IsPrinting()
+}
+
+func loadNext(printer shared.Printer, transition bool) {
+	setState(Loading)
+	go func() {
+		if transition {
+			if err := waitForTransition(printer); err != nil {
+				log.Errorf("Job queue transition aborted: %v", err)
+				mu.Lock()
+				setState(Paused)
+				mu.Unlock()
+				return
+			}
+		}
+
+		mu.Lock()
+		if state != Loading || len(queue) == 0 {
+			mu.Unlock()
+			return
+		}
+		job := queue[0]
+		queue = queue[1:]
+		if err := save(); err != nil {
+			log.Errorf("Failed to save job queue: %v", err)
+		}
+		setState(Starting)
+		publishChanged("job_loaded")
+		mu.Unlock()
+
+		log.Printf("Starting queued job %q", job.FileName)
+		var err error
+		if !printer.GetPrintManager().CanPrint(job.FileName) {
+			err = fmt.Errorf("cannot print file %q", job.FileName)
+		} else {
+			<-printer.MainExecutorContext().QueueGcode("SDCARD_PRINT_FILE FILENAME="+strconv.Quote(job.FileName), true)
+			if !printer.GetPrintManager().IsPrinting() {
+				err = fmt.Errorf("failed to start print of %q", job.FileName)
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			log.Errorf("Failed to load queued job: %v", err)
+			queue = append([]QueuedJob{job}, queue...)
+			if err := save(); err != nil {
+				log.Errorf("Failed to save job queue: %v", err)
+			}
+			publishChanged("jobs_added")
+			setState(Paused)
+			return
+		}
+		if state == Starting {
+			setState(Ready)
+		}
+	}()
+}
+
+func waitForTransition(printer shared.Printer) error {
+	if cfg.TransitionDelay > 0 {
+		log.Printf("Waiting %.1fs before starting next queued job", cfg.TransitionDelay)
+		time.Sleep(time.Duration(cfg.TransitionDelay * float64(time.Second)))
+	}
+
+	if cfg.WaitForCooldown {
+		log.Printf("Waiting for bed to cool down to %.1f°C before starting next queued job", cfg.CooldownTemp)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			mu.Lock()
+			loading := state == Loading
+			mu.Unlock()
+			if !loading {
+				return errors.New("job queue is no longer loading")
+			}
+
+			bed, err := printer.GetObjects().Query("heater_bed")
+			if err != nil {
+				return err
+			}
+			temperature, exists := bed["temperature"].(float64)
+			if !exists {
+				log.Println("Printer has no heated bed, skipping cooldown")
+				break
+			}
+			if temperature <= cfg.CooldownTemp {
+				break
+			}
+			<-ticker.C
+		}
+	}
+	return nil
+}
+
+func setState(newState State) {
+	if state == newState {
+		return
+	}
+	state = newState
+	publishChanged("state_changed")
+}
+
+func queuedJobs() []QueuedJob {
+	now := now()
+	return lo.Map(queue, func(job QueuedJob, _ int) QueuedJob {
+		job.TimeInQueue = now - job.TimeAdded
+		return job
+	})
+}
+
+func save() error {
+	if _, err := database.PostItem("marlinraker", "job_queue", queue, true); err != nil {
+		return fmt.Errorf("failed to save job queue: %w", err)
+	}
+	return nil
+}
+
+func publishChanged(action string) {
+	var updatedQueue []QueuedJob
+	if action != "state_changed" {
+		updatedQueue = queuedJobs()
+	}
+	err := notification.Publish(notification.New("notify_job_queue_changed", []any{map[string]any{
+		"action":        action,
+		"updated_queue": updatedQueue,
+		"queue_state":   state,
+	}}))
+	if err != nil {
+		log.Errorf("Failed to publish notification: %v", err)
+	}
+}
+
+func now() float64 {
+	return float64(time.Now().UnixMilli()) / 1000.0
+}
@@ -0,0 +1,169 @@
+package job_queue
+
+import (
+	"github.com/samber/lo"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakePrinter struct {
+	shared.Printer
+	shared.PrintManager
+	shared.ExecutorContext
+	name     string
+	objects  *printer_objects.Registry
+	canPrint bool
+	printing atomic.Bool
+	started  atomic.Value
+}
+
+func (printer *fakePrinter) Name() string                                { return printer.name }
+func (printer *fakePrinter) GetObjects() *printer_objects.Registry       { return printer.objects }
+func (printer *fakePrinter) GetPrintManager() shared.PrintManager        { return printer }
+func (printer *fakePrinter) MainExecutorContext() shared.ExecutorContext { return printer }
+func (printer *fakePrinter) CanPrint(string) bool                        { return printer.canPrint }
+func (printer *fakePrinter) IsPrinting() bool                            { return printer.printing.Load() }
+
+func (printer *fakePrinter) QueueGcode(gcode string, _ bool) chan string {
+	printer.started.Store(strings.TrimPrefix(gcode, "SDCARD_PRINT_FILE FILENAME="))
+	printer.printing.Store(true)
+	ch := make(chan string)
+	close(ch)
+	return ch
+}
+
+func newFakePrinter(name string, canPrint bool) *fakePrinter {
+	return &fakePrinter{name: name, objects: printer_objects.NewRegistry(name), canPrint: canPrint}
+}
+
+func fileNames(jobs []QueuedJob) []string {
+	return lo.Map(jobs, func(job QueuedJob, _ int) string { return job.FileName })
+}
+
+func waitForState(t *testing.T, expected State) {
+	deadline := time.Now().Add(5 * time.Second)
+	for state, _ := Status(); state != expected; state, _ = Status() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for state %s, state is %s", expected, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+
+	cfg := config.DefaultConfig()
+	assert.NilError(t, Init(cfg))
+
+	state, jobs := Status()
+	assert.Equal(t, state, Paused)
+	assert.Equal(t, len(jobs), 0)
+
+	assert.NilError(t, PostJobs(nil, []string{"a.gcode", "b.gcode", "c.gcode"}, false))
+	_, jobs = Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"a.gcode", "b.gcode", "c.gcode"})
+
+	assert.NilError(t, Jump(jobs[2].JobId))
+	_, jobs = Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"c.gcode", "a.gcode", "b.gcode"})
+
+	assert.Error(t, Jump("foo"), `job "foo" not found`)
+
+	assert.NilError(t, DeleteJobs([]string{jobs[1].JobId}))
+	_, jobs = Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"c.gcode", "b.gcode"})
+
+	Start(nil)
+	state, _ = Status()
+	assert.Equal(t, state, Ready)
+
+	JobFinished(newFakePrinter("other", true), "cancelled")
+	state, _ = Status()
+	assert.Equal(t, state, Ready)
+
+	JobFinished(newFakePrinter("", true), "cancelled")
+	state, _ = Status()
+	assert.Equal(t, state, Paused)
+
+	// reload from database
+	assert.NilError(t, Init(cfg))
+	_, reloaded := Status()
+	assert.DeepEqual(t, fileNames(reloaded), []string{"c.gcode", "b.gcode"})
+
+	assert.NilError(t, PostJobs(nil, []string{"d.gcode"}, true))
+	_, jobs = Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"d.gcode"})
+	assert.Assert(t, jobs[0].JobId != reloaded[0].JobId && jobs[0].JobId != reloaded[1].JobId)
+
+	assert.NilError(t, DeleteAllJobs())
+	_, jobs = Status()
+	assert.Equal(t, len(jobs), 0)
+}
+
+func TestJobQueueBinding(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.Printers = map[string]config.Serial{"right": {}, "left": {}}
+	assert.NilError(t, Init(cfg))
+	assert.Equal(t, PrinterName(), "left")
+
+	cfg.JobQueue.Printer = "right"
+	assert.NilError(t, Init(cfg))
+	assert.Equal(t, PrinterName(), "right")
+
+	cfg.JobQueue.Printer = "middle"
+	assert.Error(t, Init(cfg), `job queue printer "middle" is not configured`)
+}
+
+func TestJobQueueRequeue(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+	assert.NilError(t, Init(config.DefaultConfig()))
+
+	assert.NilError(t, PostJobs(nil, []string{"a.gcode", "b.gcode"}, false))
+	Start(newFakePrinter("", false))
+	waitForState(t, Paused)
+	_, jobs := Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"a.gcode", "b.gcode"})
+
+	assert.NilError(t, Init(config.DefaultConfig()))
+	_, jobs = Status()
+	assert.DeepEqual(t, fileNames(jobs), []string{"a.gcode", "b.gcode"})
+}
+
+func TestJobQueueTransitionWithoutBed(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.JobQueue.LoadOnStartup = true
+	cfg.JobQueue.AutomaticTransition = true
+	cfg.JobQueue.WaitForCooldown = true
+	assert.NilError(t, Init(cfg))
+	assert.NilError(t, PostJobs(nil, []string{"next.gcode"}, false))
+
+	printer := newFakePrinter("", true)
+	JobFinished(printer, "complete")
+	waitForState(t, Ready)
+	assert.Equal(t, printer.started.Load(), `"next.gcode"`)
+	_, jobs := Status()
+	assert.Equal(t, len(jobs), 0)
+}
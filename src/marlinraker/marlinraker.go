@@ -1,15 +1,22 @@
 package marlinraker
 
 import (
+	"fmt"
+	"github.com/samber/lo"
 	log "github.com/sirupsen/logrus"
 	"marlinraker/src/api/notification"
 	"marlinraker/src/config"
 	"marlinraker/src/constants"
 	"marlinraker/src/marlinraker/temp_store"
 	"marlinraker/src/printer"
+	"marlinraker/src/printer/virtual"
 	"marlinraker/src/printer_objects"
 	"marlinraker/src/scanner"
 	"marlinraker/src/system_info"
+	"marlinraker/src/util"
+	"sort"
+	"sync"
+	"time"
 )
 
 type KlippyState string
@@ -21,13 +28,26 @@ const (
 	Startup  KlippyState = "startup"
 )
 
+type Instance struct {
+	Name         string
+	Serial       config.Serial
+	Objects      *printer_objects.Registry
+	TempStore    *temp_store.Store
+	mu           *sync.RWMutex
+	state        KlippyState
+	stateMessage string
+	printer      *printer.Printer
+	port         string
+	baudRate     int
+	wakeCh       chan struct{}
+	halted       bool
+}
+
 var (
-	State           = Shutdown
-	StateMessage    string
 	Config          *config.Config
 	KlipperSettings map[string]any
 	KlipperConfig   map[string]any
-	Printer         *printer.Printer
+	Instances       []*Instance
 )
 
 func Init(cfg *config.Config) {
@@ -40,76 +60,270 @@ func Init(cfg *config.Config) {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	printer_objects.RegisterObject("webhooks", webhooksObject{})
-	printer_objects.RegisterObject("configfile", configFileObject{})
+	Instances = make([]*Instance, 0)
+	if len(cfg.Printers) == 0 {
+		Instances = append(Instances, NewInstance("", cfg.Serial))
+	} else {
+		names := lo.Keys(cfg.Printers)
+		sort.Strings(names)
+		for _, name := range names {
+			Instances = append(Instances, NewInstance(name, cfg.Printers[name]))
+		}
+	}
 
 	go system_info.Run()
-	go temp_store.Run()
-	go Connect()
+	for _, instance := range Instances {
+		go instance.TempStore.Run()
+		go instance.Run()
+	}
+
+	if cfg.Reconnect.Enabled && len(cfg.Reconnect.WatchPaths) > 0 {
+		go watchDevices(cfg.Reconnect.WatchPaths, time.Duration(cfg.Reconnect.WatchInterval*float64(time.Second)))
+	}
+}
+
+func NewInstance(name string, serial config.Serial) *Instance {
+	instance := &Instance{
+		Name:      name,
+		Serial:    serial,
+		Objects:   printer_objects.NewRegistry(name),
+		TempStore: temp_store.New(),
+		mu:        &sync.RWMutex{},
+		state:     Shutdown,
+		wakeCh:    make(chan struct{}, 1),
+	}
+	instance.Objects.RegisterObject("webhooks", webhooksObject{instance})
+	instance.Objects.RegisterObject("configfile", configFileObject{instance})
+	return instance
+}
+
+func GetInstance(name string) (*Instance, error) {
+	if len(Instances) == 0 {
+		return nil, util.NewError(503, "no printers configured")
+	}
+	if name == "" {
+		return Instances[0], nil
+	}
+	for _, instance := range Instances {
+		if instance.Name == name {
+			return instance, nil
+		}
+	}
+	return nil, util.NewErrorf(404, "printer %q not found", name)
+}
+
+func IsPrinting() bool {
+	return lo.SomeBy(Instances, func(instance *Instance) bool {
+		printer := instance.Printer()
+		return printer != nil && printer.PrintManager.IsPrinting()
+	})
+}
+
+func (instance *Instance) Printer() *printer.Printer {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.printer
+}
+
+func (instance *Instance) State() KlippyState {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.state
+}
+
+func (instance *Instance) StateMessage() string {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.stateMessage
+}
+
+func (instance *Instance) Port() string {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.port
 }
 
-func SetState(state KlippyState, message string) {
-	State = state
-	StateMessage = message
+func (instance *Instance) BaudRate() int {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.baudRate
+}
+
+func (instance *Instance) isHalted() bool {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	return instance.halted
+}
+
+func (instance *Instance) setHalted(halted bool) {
+	instance.mu.Lock()
+	defer instance.mu.Unlock()
+	instance.halted = halted
+}
+
+func (instance *Instance) SetState(state KlippyState, message string) {
+	instance.mu.Lock()
+	instance.state, instance.stateMessage = state, message
+	instance.mu.Unlock()
 
 	switch state {
 	case Error:
-		log.Errorln(message)
+		instance.logger().Errorln(message)
 	default:
-		log.Println(message)
+		instance.logger().Println(message)
 	}
 
 	if state == Ready || state == Shutdown {
-		notify := notification.New("notify_klippy_"+string(state), []any{})
-		if err := notification.Publish(notify); err != nil {
-			log.Errorf("Failed to public notification: %v", err)
-		}
+		instance.notify("notify_klippy_" + string(state))
 	}
 
-	if err := printer_objects.EmitObject("webhooks"); err != nil {
+	if err := instance.Objects.EmitObject("webhooks"); err != nil {
 		log.Errorf("Failed to emit object webhooks: %v", err)
 	}
 }
 
-func Connect() {
+func (instance *Instance) Run() {
+	reconnect := Config.Reconnect
+	delay := reconnect.InitialDelay
+	for {
+		if instance.Connect() {
+			delay = reconnect.InitialDelay
+		}
+		if !reconnect.Enabled {
+			return
+		}
+
+		if instance.isHalted() {
+			instance.logger().Println("Printer was halted, waiting for firmware restart")
+			<-instance.wakeCh
+			continue
+		}
+
+		instance.logger().Printf("Reconnecting in %.1fs...", delay)
+		select {
+		case <-time.After(time.Duration(delay * float64(time.Second))):
+			delay = nextDelay(delay, reconnect)
+		case <-instance.wakeCh:
+		}
+	}
+}
+
+func nextDelay(delay float64, reconnect config.Reconnect) float64 {
+	return min(delay*reconnect.BackoffFactor, reconnect.MaxDelay)
+}
+
+func (instance *Instance) Wake() {
+	select {
+	case instance.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (instance *Instance) Restart() error {
+	instance.setHalted(false)
+	if printer := instance.Printer(); printer != nil {
+		if err := printer.Disconnect(); err != nil {
+			return err
+		}
+	}
+	if Config.Reconnect.Enabled {
+		instance.Wake()
+	} else {
+		go instance.Connect()
+	}
+	return nil
+}
+
+func (instance *Instance) Connect() bool {
 
-	if State != Error && State != Shutdown {
-		return
+	if state := instance.State(); state != Error && state != Shutdown {
+		return false
 	}
 
-	SetState(Startup, "Connecting to printer...")
+	instance.SetState(Startup, "Connecting to printer...")
 
 	var baudRateInt int
-	port, baudRate := Config.Serial.Port, Config.Serial.BaudRate
+	port, baudRate := instance.Serial.Port, instance.Serial.BaudRate
 	if baudRate, isInt := baudRate.(int); isInt {
 		baudRateInt = baudRate
 	}
-	if port == "" || port == "auto" || baudRateInt <= 0 {
-		port, baudRateInt = scanner.FindSerialPort(Config)
+	isVirtual := port == virtual.PortName
+	if !isVirtual && (port == "" || port == "auto" || baudRateInt <= 0) {
+		port, baudRateInt = scanner.FindSerialPort(instance.Serial)
 	}
 
-	if port == "" || baudRateInt == 0 {
-		SetState(Error, "Could not find serial port to connect to")
-		return
+	if port == "" || (baudRateInt == 0 && !isVirtual) {
+		instance.SetState(Error, "Could not find serial port to connect to")
+		return false
 	}
 
-	log.Printf("Using port %s @ %d", port, baudRateInt)
+	instance.logger().Printf("Using port %s @ %d", port, baudRateInt)
+
+	cfg := *Config
+	cfg.Serial = instance.Serial
 
-	var err error
-	Printer, err = printer.New(Config, port, baudRateInt)
+	connected, err := printer.New(instance.Name, &cfg, instance.Objects, instance.TempStore, port, baudRateInt)
 	if err != nil {
-		SetState(Error, "Error: "+err.Error())
-		Printer = nil
-		return
+		instance.SetState(Error, "Error: "+err.Error())
+		return false
 	}
 
-	SetState(Ready, "Printer is ready")
-	<-Printer.CloseCh
-	temp_store.Reset()
-	if Printer.Error != nil {
-		SetState(Error, Printer.Error.Error())
+	instance.mu.Lock()
+	instance.printer, instance.port, instance.baudRate = connected, resolveDevice(port), baudRateInt
+	instance.mu.Unlock()
+	instance.SetState(Ready, "Printer is ready")
+	connected.MacroManager.StartDelayedGcodes()
+
+	if checkpoint, err := printer.LoadCheckpoint(instance.Name); err != nil {
+		log.Errorf("Failed to load print checkpoint: %v", err)
+	} else if checkpoint != nil {
+		message := fmt.Sprintf("// Print of %q was interrupted, use RECOVER_PRINT to resume it", checkpoint.FileName)
+		if err := connected.Respond(message); err != nil {
+			log.Errorf("Failed to send response: %v", err)
+		}
+	}
+
+	<-connected.CloseCh
+	instance.TempStore.Reset()
+	instance.notify("notify_klippy_disconnected")
+	instance.mu.Lock()
+	instance.printer, instance.port, instance.baudRate = nil, "", 0
+	if connected.Halted() {
+		instance.halted = true
+	}
+	instance.mu.Unlock()
+	if err := connected.GetError(); err != nil {
+		instance.SetState(Error, err.Error())
 	} else {
-		SetState(Shutdown, "Disconnected from printer")
+		instance.SetState(Shutdown, "Disconnected from printer")
+	}
+	return true
+}
+
+func Close() {
+	for _, instance := range Instances {
+		instance.setHalted(true)
+		if printer := instance.Printer(); printer != nil {
+			if err := printer.Disconnect(); err != nil {
+				log.Errorf("Failed to disconnect from printer: %v", err)
+			}
+		}
+	}
+}
+
+func (instance *Instance) notify(method string) {
+	params := []any{}
+	if instance.Name != "" {
+		params = append(params, instance.Name)
+	}
+	if err := notification.Publish(notification.New(method, params)); err != nil {
+		log.Errorf("Failed to public notification: %v", err)
+	}
+}
+
+func (instance *Instance) logger() *log.Entry {
+	if instance.Name == "" {
+		return log.NewEntry(log.StandardLogger())
 	}
-	Printer = nil
+	return log.WithField("printer", instance.Name)
 }
@@ -0,0 +1,100 @@
+package marlinraker
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/marlinraker/job_queue"
+	"marlinraker/src/printer/virtual"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextDelay(t *testing.T) {
+	reconnect := config.Reconnect{InitialDelay: 1, MaxDelay: 10, BackoffFactor: 2}
+	delays := make([]float64, 0)
+	for delay := reconnect.InitialDelay; len(delays) < 6; delay = nextDelay(delay, reconnect) {
+		delays = append(delays, delay)
+	}
+	assert.DeepEqual(t, delays, []float64{1, 2, 4, 8, 10, 10})
+}
+
+func TestCheckDevices(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "ttyUSB0")
+	assert.NilError(t, os.WriteFile(device, nil, 0644))
+	link := filepath.Join(dir, "by-id")
+	assert.NilError(t, os.Mkdir(link, 0755))
+	assert.NilError(t, os.Symlink(device, filepath.Join(link, "usb-Marlin")))
+
+	known := listDevices([]string{link})
+	assert.DeepEqual(t, known, map[string]string{filepath.Join(link, "usb-Marlin"): device})
+
+	idle, halted := NewInstance("idle", config.Serial{}), NewInstance("halted", config.Serial{})
+	halted.setHalted(true)
+	Instances = []*Instance{idle, halted}
+	t.Cleanup(func() { Instances = nil })
+
+	assert.NilError(t, os.Symlink(device, filepath.Join(link, "usb-Prusa")))
+	known = checkDevices([]string{link}, known)
+	assert.Equal(t, len(known), 2)
+	assert.Equal(t, len(idle.wakeCh), 1)
+	assert.Equal(t, len(halted.wakeCh), 0)
+
+	<-idle.wakeCh
+	assert.NilError(t, os.Remove(filepath.Join(link, "usb-Prusa")))
+	known = checkDevices([]string{link}, known)
+	assert.Equal(t, len(known), 1)
+	assert.Equal(t, len(idle.wakeCh), 0)
+}
+
+func TestInstanceReconnect(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+	assert.NilError(t, database.Init())
+	assert.NilError(t, history.Init())
+
+	Config = config.DefaultConfig()
+	Config.VirtualPrinter.TimeScale = 0
+	Config.Reconnect = config.Reconnect{Enabled: true, InitialDelay: 0.05, MaxDelay: 0.1, BackoffFactor: 2}
+	assert.NilError(t, job_queue.Init(Config))
+
+	serial := Config.Serial
+	serial.Port = virtual.PortName
+	instance := NewInstance("", serial)
+	Instances = []*Instance{instance}
+	t.Cleanup(func() {
+		Close()
+		Instances = nil
+	})
+	go instance.Run()
+
+	waitForState(t, instance, Ready)
+	assert.NilError(t, instance.Printer().Disconnect())
+	waitForState(t, instance, Ready)
+
+	instance.Printer().EmergencyStop()
+	waitForState(t, instance, Error)
+	assert.Equal(t, instance.StateMessage(), "emergency stop")
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, instance.State(), Error)
+
+	assert.NilError(t, instance.Restart())
+	waitForState(t, instance, Ready)
+}
+
+func waitForState(t *testing.T, instance *Instance, state KlippyState) {
+	deadline := time.Now().Add(10 * time.Second)
+	for instance.State() != state || (state == Ready && instance.Printer() == nil) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for state %s, state is %s", state, instance.State())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
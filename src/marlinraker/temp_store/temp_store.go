@@ -14,51 +14,59 @@ type tempRecords struct {
 
 type TempStore map[string]tempRecords
 
-var (
-	store             = make(TempStore)
-	storeMutex        = &sync.RWMutex{}
+type Store struct {
+	store             TempStore
+	storeMutex        *sync.RWMutex
 	lastMeasured      map[string]any
-	lastMeasuredMutex = &sync.RWMutex{}
-)
+	lastMeasuredMutex *sync.RWMutex
+}
+
+func New() *Store {
+	return &Store{
+		store:             make(TempStore),
+		storeMutex:        &sync.RWMutex{},
+		lastMeasuredMutex: &sync.RWMutex{},
+	}
+}
 
-func Run() {
+func (store *Store) Run() {
 	ticker := time.NewTicker(time.Second)
 	for {
 		<-ticker.C
-		storeTemps()
+		store.storeTemps()
 	}
 }
 
-func Reset() {
-	storeMutex.Lock()
-	store = make(map[string]tempRecords)
-	storeMutex.Unlock()
+func (store *Store) Reset() {
+	store.storeMutex.Lock()
+	store.store = make(TempStore)
+	store.storeMutex.Unlock()
 }
 
-func GetStore() TempStore {
-	storeMutex.RLock()
-	defer storeMutex.RUnlock()
-	return store
+func (store *Store) GetStore() TempStore {
+	store.storeMutex.RLock()
+	defer store.storeMutex.RUnlock()
+	return store.store
 }
 
-func SetLastMeasured(_lastMeasured map[string]any) {
-	lastMeasuredMutex.Lock()
-	defer lastMeasuredMutex.Unlock()
-	lastMeasured = _lastMeasured
+func (store *Store) SetLastMeasured(lastMeasured map[string]any) {
+	store.lastMeasuredMutex.Lock()
+	defer store.lastMeasuredMutex.Unlock()
+	store.lastMeasured = lastMeasured
 }
 
-func storeTemps() {
-	storeMutex.Lock()
-	lastMeasuredMutex.RLock()
-	defer storeMutex.Unlock()
-	defer lastMeasuredMutex.RUnlock()
+func (store *Store) storeTemps() {
+	store.storeMutex.Lock()
+	store.lastMeasuredMutex.RLock()
+	defer store.storeMutex.Unlock()
+	defer store.lastMeasuredMutex.RUnlock()
 
-	for name, temp := range lastMeasured {
+	for name, temp := range store.lastMeasured {
 
-		records, exist := store[name]
+		records, exist := store.store[name]
 		if !exist {
 			records = tempRecords{}
-			store[name] = records
+			store.store[name] = records
 		}
 
 		switch temp := temp.(type) {
@@ -72,7 +80,7 @@ func storeTemps() {
 			appendToRecord(&records.Powers, float32(temp.Power))
 		}
 
-		store[name] = records
+		store.store[name] = records
 	}
 }
 
@@ -2,11 +2,13 @@ package marlinraker
 
 import "marlinraker/src/printer_objects"
 
-type webhooksObject struct{}
+type webhooksObject struct {
+	instance *Instance
+}
 
-func (webhooksObject) Query() (printer_objects.QueryResult, error) {
+func (webhooksObject webhooksObject) Query() (printer_objects.QueryResult, error) {
 	return printer_objects.QueryResult{
-		"state":         State,
-		"state_message": StateMessage,
+		"state":         webhooksObject.instance.State(),
+		"state_message": webhooksObject.instance.StateMessage(),
 	}, nil
 }
@@ -0,0 +1,271 @@
+package printer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/database"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type bedMeshProfile struct {
+	Points  [][]float64 `json:"points"`
+	MeshMin [2]float64  `json:"mesh_min"`
+	MeshMax [2]float64  `json:"mesh_max"`
+	Slot    int         `json:"slot"`
+}
+
+type bedMesh struct {
+	printer     *Printer
+	mu          *sync.RWMutex
+	kind        string
+	profileName string
+	matrix      [][]float64
+	meshMin     [2]float64
+	meshMax     [2]float64
+	profiles    map[string]bedMeshProfile
+}
+
+func newBedMesh(printer *Printer) *bedMesh {
+	mesh := &bedMesh{
+		printer:  printer,
+		mu:       &sync.RWMutex{},
+		profiles: make(map[string]bedMeshProfile),
+	}
+	if err := mesh.loadProfiles(); err != nil {
+		log.Errorf("Failed to load bed mesh profiles: %v", err)
+	}
+	printer.Objects.RegisterObject("bed_mesh", bedMeshObject{mesh})
+
+	if report, err := parser.ParseM420(<-printer.context.Load().QueueGcode("M420 V", true)); err == nil {
+		mesh.mu.Lock()
+		mesh.update(report)
+		mesh.profileName = "default"
+		for name, profile := range mesh.profiles {
+			if reflect.DeepEqual(profile.Points, mesh.matrix) {
+				mesh.profileName = name
+			}
+		}
+		mesh.mu.Unlock()
+	}
+	return mesh
+}
+
+func (mesh *bedMesh) cleanup() {
+	mesh.printer.Objects.UnregisterObject("bed_mesh")
+}
+
+func (mesh *bedMesh) Calibrate(context shared.ExecutorContext, profile string) error {
+	if profile == "" {
+		profile = "default"
+	}
+	<-context.QueueGcode("G29", true)
+	return mesh.report(context, profile)
+}
+
+func (mesh *bedMesh) LoadProfile(context shared.ExecutorContext, name string) error {
+	mesh.mu.RLock()
+	profile, exists := mesh.profiles[name]
+	kind := mesh.kind
+	mesh.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("bed mesh profile %q not found", name)
+	}
+
+	if kind == "ubl" {
+		<-context.QueueGcode(fmt.Sprintf("G29 L%d", profile.Slot), true)
+	} else {
+		var builder strings.Builder
+		for j, row := range profile.Points {
+			for i, z := range row {
+				builder.WriteString(fmt.Sprintf("M421 I%d J%d Z%s\n", i, j, strconv.FormatFloat(z, 'f', 3, 64)))
+			}
+		}
+		<-context.QueueGcode(builder.String(), true)
+	}
+	return mesh.report(context, name)
+}
+
+func (mesh *bedMesh) SaveProfile(context shared.ExecutorContext, name string) error {
+	mesh.mu.Lock()
+	if mesh.matrix == nil {
+		mesh.mu.Unlock()
+		return errors.New("no mesh loaded to save")
+	}
+	slot := mesh.profileSlot(name)
+	mesh.profiles[name] = bedMeshProfile{
+		Points:  mesh.matrix,
+		MeshMin: mesh.meshMin,
+		MeshMax: mesh.meshMax,
+		Slot:    slot,
+	}
+	mesh.profileName = name
+	kind := mesh.kind
+	mesh.mu.Unlock()
+
+	if kind == "ubl" {
+		<-context.QueueGcode(fmt.Sprintf("G29 S%d", slot), true)
+	}
+	<-context.QueueGcode("M500", true)
+
+	if err := mesh.saveProfiles(); err != nil {
+		return err
+	}
+	mesh.emit()
+	return mesh.printer.Respond(fmt.Sprintf("// Bed mesh profile %q saved to EEPROM slot %d", name, slot))
+}
+
+func (mesh *bedMesh) profileSlot(name string) int {
+	if profile, exists := mesh.profiles[name]; exists {
+		return profile.Slot
+	}
+	slot := 0
+	for lo.ContainsBy(lo.Values(mesh.profiles), func(profile bedMeshProfile) bool { return profile.Slot == slot }) {
+		slot++
+	}
+	return slot
+}
+
+func (mesh *bedMesh) RemoveProfile(name string) error {
+	mesh.mu.Lock()
+	if _, exists := mesh.profiles[name]; !exists {
+		mesh.mu.Unlock()
+		return fmt.Errorf("bed mesh profile %q not found", name)
+	}
+	delete(mesh.profiles, name)
+	mesh.mu.Unlock()
+
+	if err := mesh.saveProfiles(); err != nil {
+		return err
+	}
+	mesh.emit()
+	return mesh.printer.Respond(fmt.Sprintf("// Bed mesh profile %q removed", name))
+}
+
+func (mesh *bedMesh) Clear(context shared.ExecutorContext) error {
+	<-context.QueueGcode("M420 S0", true)
+	mesh.mu.Lock()
+	mesh.matrix, mesh.profileName = nil, ""
+	mesh.mu.Unlock()
+	mesh.emit()
+	return nil
+}
+
+func (mesh *bedMesh) report(context shared.ExecutorContext, profile string) error {
+	report, err := parser.ParseM420(<-context.QueueGcode("M420 S1 V", true))
+	if err != nil {
+		return fmt.Errorf("failed to read bed mesh: %w", err)
+	}
+	mesh.mu.Lock()
+	mesh.update(report)
+	mesh.profileName = profile
+	mesh.mu.Unlock()
+	mesh.emit()
+	return nil
+}
+
+func (mesh *bedMesh) update(report parser.BedMeshReport) {
+	mesh.kind, mesh.matrix = report.Kind, report.Matrix
+	if report.HasBounds {
+		mesh.meshMin, mesh.meshMax = report.MeshMin, report.MeshMax
+		return
+	}
+	axisMin, axisMax := mesh.printer.config.Printer.AxisMinimum, mesh.printer.config.Printer.AxisMaximum
+	mesh.meshMin = [2]float64{float64(axisMin[0]), float64(axisMin[1])}
+	mesh.meshMax = [2]float64{float64(axisMax[0]), float64(axisMax[1])}
+}
+
+func (mesh *bedMesh) emit() {
+	if err := mesh.printer.Objects.EmitObject("bed_mesh"); err != nil {
+		log.Errorf("Failed to emit bed_mesh: %v", err)
+	}
+}
+
+func (mesh *bedMesh) loadProfiles() error {
+	item, err := database.GetItem("marlinraker", bedMeshKey(mesh.printer.name), true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return nil
+		}
+		return err
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, &mesh.profiles)
+}
+
+func (mesh *bedMesh) saveProfiles() error {
+	mesh.mu.RLock()
+	defer mesh.mu.RUnlock()
+	if _, err := database.PostItem("marlinraker", bedMeshKey(mesh.printer.name), mesh.profiles, true); err != nil {
+		return fmt.Errorf("failed to save bed mesh profiles: %w", err)
+	}
+	return nil
+}
+
+func bedMeshKey(printerName string) string {
+	if printerName == "" {
+		return "bed_mesh_profiles"
+	}
+	return "bed_mesh_profiles_" + printerName
+}
+
+type bedMeshObject struct {
+	mesh *bedMesh
+}
+
+func (object bedMeshObject) Query() (printer_objects.QueryResult, error) {
+	mesh := object.mesh
+	mesh.mu.RLock()
+	defer mesh.mu.RUnlock()
+
+	matrix := mesh.matrix
+	if matrix == nil {
+		matrix = [][]float64{{}}
+	}
+
+	profiles := make(map[string]any, len(mesh.profiles))
+	for name, profile := range mesh.profiles {
+		xCount := 0
+		if len(profile.Points) > 0 {
+			xCount = len(profile.Points[0])
+		}
+		profiles[name] = map[string]any{
+			"points": profile.Points,
+			"mesh_params": map[string]any{
+				"min_x":      profile.MeshMin[0],
+				"max_x":      profile.MeshMax[0],
+				"min_y":      profile.MeshMin[1],
+				"max_y":      profile.MeshMax[1],
+				"x_count":    xCount,
+				"y_count":    len(profile.Points),
+				"mesh_x_pps": 0,
+				"mesh_y_pps": 0,
+				"algo":       "direct",
+				"tension":    0.2,
+			},
+		}
+	}
+
+	return printer_objects.QueryResult{
+		"profile_name":  mesh.profileName,
+		"mesh_min":      mesh.meshMin,
+		"mesh_max":      mesh.meshMax,
+		"probed_matrix": matrix,
+		"mesh_matrix":   matrix,
+		"profiles":      profiles,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package printer
+
+import (
+	"gotest.tools/assert"
+	"sync"
+	"testing"
+)
+
+func TestBedMeshProfileSlots(t *testing.T) {
+	mesh := &bedMesh{mu: &sync.RWMutex{}, profiles: make(map[string]bedMeshProfile)}
+	assert.Equal(t, mesh.profileSlot("default"), 0)
+
+	mesh.profiles["default"] = bedMeshProfile{Slot: 0}
+	mesh.profiles["pei"] = bedMeshProfile{Slot: 2}
+	assert.Equal(t, mesh.profileSlot("default"), 0)
+	assert.Equal(t, mesh.profileSlot("pei"), 2)
+	assert.Equal(t, mesh.profileSlot("glass"), 1)
+
+	mesh.profiles["glass"] = bedMeshProfile{Slot: 1}
+	assert.Equal(t, mesh.profileSlot("textured"), 3)
+
+	delete(mesh.profiles, "default")
+	assert.Equal(t, mesh.profileSlot("textured"), 0)
+}
+
+func TestBedMeshObject(t *testing.T) {
+	mesh := &bedMesh{
+		mu:          &sync.RWMutex{},
+		profileName: "pei",
+		meshMin:     [2]float64{10, 10},
+		meshMax:     [2]float64{200, 200},
+		profiles: map[string]bedMeshProfile{
+			"pei": {Points: [][]float64{{0, 0.1, 0.2}, {0.1, 0.2, 0.3}}, MeshMin: [2]float64{10, 10}, MeshMax: [2]float64{200, 200}, Slot: 1},
+		},
+	}
+
+	result, err := bedMeshObject{mesh}.Query()
+	assert.NilError(t, err)
+	assert.Equal(t, result["profile_name"], "pei")
+	assert.DeepEqual(t, result["probed_matrix"], [][]float64{{}})
+	params := result["profiles"].(map[string]any)["pei"].(map[string]any)["mesh_params"].(map[string]any)
+	assert.Equal(t, params["x_count"], 3)
+	assert.Equal(t, params["y_count"], 2)
+	assert.Equal(t, params["max_x"], 200.)
+}
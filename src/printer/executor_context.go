@@ -14,6 +14,7 @@ import (
 
 type command struct {
 	gcode string
+	run   func(shared.ExecutorContext) error
 	ch    chan string
 }
 
@@ -27,6 +28,7 @@ type executorContext struct {
 	responseBuilder strings.Builder
 	pending         *sync.WaitGroup
 	mu              *sync.Mutex
+	pipelining      bool
 }
 
 func newExecutorContext(printer *Printer, name string) *executorContext {
@@ -38,6 +40,7 @@ func newExecutorContext(printer *Printer, name string) *executorContext {
 		responseCh: make(chan string),
 		pending:    &sync.WaitGroup{},
 		mu:         &sync.Mutex{},
+		pipelining: printer.Capabilities["ADVANCED_OK"],
 	}
 	go context.work()
 	return context
@@ -77,28 +80,66 @@ func (context *executorContext) ReleaseSubContext() {
 
 func (context *executorContext) work() {
 	log.WithField("context", context.name).Debugln("begin")
+
+	inFlight := make([]command, 0)
+	bufferFree := 1
+
 	for {
+		commandCh, responseCh := context.commandCh, context.responseCh
+		if len(inFlight) >= max(1, bufferFree) || (len(inFlight) > 0 && context.isBarrier(inFlight[len(inFlight)-1])) {
+			commandCh = nil
+		}
+		if len(inFlight) == 0 {
+			responseCh = nil
+		}
+
 		select {
 		case <-context.closeCh:
 			log.WithField("context", context.name).Debugln("end")
 			return
 
-		case cmd := <-context.commandCh:
+		case cmd := <-commandCh:
+			if len(inFlight) > 0 && context.isBarrier(cmd) {
+				for _, inFlightCmd := range inFlight {
+					context.complete(inFlightCmd, <-context.responseCh)
+				}
+				inFlight = inFlight[:0]
+			}
 			context.flush(cmd)
-
-			response := <-context.responseCh
-			context.responseBuilder = strings.Builder{}
-			select {
-			case cmd.ch <- response:
-			default:
+			inFlight = append(inFlight, cmd)
+
+		case response := <-responseCh:
+			cmd := inFlight[0]
+			inFlight = inFlight[1:]
+			if context.pipelining {
+				lines := strings.Split(response, "\n")
+				if ok, isAdvanced := parser.ParseAdvancedOk(lines[len(lines)-1]); isAdvanced {
+					bufferFree = ok.BufferFree
+				}
 			}
-			close(cmd.ch)
-			context.printer.handleRequestLine(cmd.gcode)
-			context.pending.Done()
+			context.complete(cmd, response)
 		}
 	}
 }
 
+func (context *executorContext) isBarrier(cmd command) bool {
+	if !context.pipelining || cmd.run != nil {
+		return true
+	}
+	_, _, isMacro := context.printer.MacroManager.GetMacro(cmd.gcode)
+	return isMacro
+}
+
+func (context *executorContext) complete(cmd command, response string) {
+	select {
+	case cmd.ch <- response:
+	default:
+	}
+	close(cmd.ch)
+	context.printer.handleRequestLine(cmd.gcode)
+	context.pending.Done()
+}
+
 func (context *executorContext) QueueGcode(gcode string, silent bool) chan string {
 
 	context.mu.Lock()
@@ -152,6 +193,20 @@ func (context *executorContext) QueueGcode(gcode string, silent bool) chan strin
 	return ch
 }
 
+func (context *executorContext) QueueMacro(name string, run func(shared.ExecutorContext) error) chan string {
+
+	context.mu.Lock()
+	defer context.mu.Unlock()
+
+	log.WithField("context", context.name).Debugf("queued %s", name)
+
+	ch := make(chan string)
+	cmd := command{gcode: name, run: run, ch: ch}
+	context.pending.Add(1)
+	context.commandCh <- cmd
+	return ch
+}
+
 func (context *executorContext) readLine(line string) {
 
 	if subContext := context.subContext.Load(); subContext != nil {
@@ -167,36 +222,29 @@ func (context *executorContext) readLine(line string) {
 	context.responseBuilder.WriteString(line)
 
 	if strings.HasPrefix(line, "ok") {
-		context.responseCh <- context.responseBuilder.String()
+		response := context.responseBuilder.String()
+		context.responseBuilder = strings.Builder{}
+		context.responseCh <- response
 	}
 }
 
 func (context *executorContext) flush(cmd command) {
 
+	if cmd.run != nil {
+		context.runInSubContext(cmd.gcode, cmd.run)
+		return
+	}
+
 	if macro, name, exists := context.printer.MacroManager.GetMacro(cmd.gcode); exists {
 		log.WithField("context", context.name).Debugf("macro: %s", cmd.gcode)
 
-		subContext, err := context.MakeSubContext(fmt.Sprintf("%s/%s", context.name, name))
-		if err != nil {
-			log.Errorf("Could not create subcontext: %v", err)
-			return
-		}
-
-		ch, err := context.printer.MacroManager.ExecuteMacro(macro, subContext, cmd.gcode)
-		if err != nil {
-			err = <-ch
-		}
-		if err != nil {
-			message := fmt.Sprintf("!! Error: %s", err)
-			if err = context.printer.Respond(message); err != nil {
-				log.Errorf("Failed to send response: %v", err)
+		context.runInSubContext(name, func(subContext shared.ExecutorContext) error {
+			ch, err := context.printer.MacroManager.ExecuteMacro(macro, subContext, cmd.gcode)
+			if err == nil {
+				err = <-ch
 			}
-		}
-		context.ReleaseSubContext()
-
-		go func() {
-			context.responseCh <- "ok"
-		}()
+			return err
+		})
 		return
 	}
 
@@ -204,8 +252,32 @@ func (context *executorContext) flush(cmd command) {
 		WithField("port", context.printer.path).
 		Debugf("write: %s\n", cmd.gcode)
 
-	_, err := context.printer.port.Write([]byte(fmt.Sprintln(cmd.gcode)))
-	if err != nil {
+	context.printer.prepareRequestLine(cmd.gcode)
+	if err := context.printer.protocol.writeLine(cmd.gcode); err != nil {
 		log.Errorf("Failed writing to printer port: %v", err)
+		go func() {
+			context.responseCh <- "ok"
+		}()
+	}
+}
+
+func (context *executorContext) runInSubContext(name string, run func(shared.ExecutorContext) error) {
+
+	subContext, err := context.MakeSubContext(fmt.Sprintf("%s/%s", context.name, name))
+	if err != nil {
+		log.Errorf("Could not create subcontext: %v", err)
+		return
 	}
+
+	if err = run(subContext); err != nil {
+		message := fmt.Sprintf("!! Error: %s", err)
+		if err = context.printer.Respond(message); err != nil {
+			log.Errorf("Failed to send response: %v", err)
+		}
+	}
+	context.ReleaseSubContext()
+
+	go func() {
+		context.responseCh <- "ok"
+	}()
 }
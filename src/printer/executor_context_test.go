@@ -0,0 +1,97 @@
+package printer
+
+import (
+	"errors"
+	"github.com/samber/lo"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/marlinraker/gcode_store"
+	"marlinraker/src/printer/macros"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type acknowledgingWriter struct {
+	printer *Printer
+	mu      sync.Mutex
+	lines   []string
+}
+
+func (writer *acknowledgingWriter) Write(bytes []byte) (int, error) {
+	writer.mu.Lock()
+	writer.lines = append(writer.lines, strings.TrimSpace(string(bytes)))
+	writer.mu.Unlock()
+	go writer.printer.context.Load().readLine("ok")
+	return len(bytes), nil
+}
+
+func (writer *acknowledgingWriter) Lines() []string {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return append([]string{}, writer.lines...)
+}
+
+func TestExecutorContextMacros(t *testing.T) {
+	setupDatabase(t)
+	cfg := config.DefaultConfig()
+	cfg.Macros = map[string]config.Macro{
+		"heat": {Gcode: "M104 S100\nM140 S50"},
+		"fail": {TemplateEngine: "jinja", Gcode: "{action_raise_error('too cold')}"},
+	}
+	objects := printer_objects.NewRegistry("")
+	printer := &Printer{
+		config:     cfg,
+		Objects:    objects,
+		watchers:   util.NewThreadSafe(make([]watcher, 0)),
+		GcodeState: &GcodeState{objects: objects, mu: &sync.RWMutex{}},
+		context:    util.NewThreadSafe[*executorContext](nil),
+		err:        util.NewThreadSafe[error](nil),
+	}
+	writer := &acknowledgingWriter{printer: printer}
+	printer.protocol = newSerialProtocol(writer, "test", false)
+	printer.MacroManager = macros.NewMacroManager(printer, cfg)
+	context := newExecutorContext(printer, "main")
+	printer.context.Store(context)
+	t.Cleanup(func() {
+		printer.MacroManager.Cleanup()
+		context.close()
+	})
+
+	await := func(ch chan string) string {
+		select {
+		case response := <-ch:
+			return response
+		case <-time.After(5 * time.Second):
+			t.Fatal("command never completed")
+			return ""
+		}
+	}
+	countErrors := func(message string) int {
+		return lo.CountBy(gcode_store.GcodeStore, func(log gcode_store.GcodeLog) bool {
+			return log.Message == message
+		})
+	}
+
+	assert.Equal(t, await(context.QueueGcode("HEAT", true)), "ok")
+	assert.DeepEqual(t, writer.Lines(), []string{"M104 S100", "M140 S50"})
+
+	assert.Equal(t, await(context.QueueGcode(`HEAT A="\q"`, true)), "ok")
+	assert.Equal(t, len(writer.Lines()), 2)
+
+	failures := countErrors("!! Error: too cold")
+	assert.Equal(t, await(context.QueueGcode("FAIL", true)), "ok")
+	assert.Equal(t, countErrors("!! Error: too cold"), failures+1)
+
+	failures = countErrors("!! Error: macro failed")
+	assert.Equal(t, await(context.QueueMacro("custom", func(subContext shared.ExecutorContext) error {
+		<-subContext.QueueGcode("G28", true)
+		return errors.New("macro failed")
+	})), "ok")
+	assert.Equal(t, countErrors("!! Error: macro failed"), failures+1)
+	assert.DeepEqual(t, writer.Lines(), []string{"M104 S100", "M140 S50", "G28"})
+}
@@ -0,0 +1,132 @@
+package printer
+
+import (
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer_objects"
+	"strconv"
+	"sync"
+)
+
+const heaterFanTemp = 50
+
+type fanObject struct {
+	watcher *fanWatcher
+	index   int
+}
+
+func (object fanObject) Query() (printer_objects.QueryResult, error) {
+	return printer_objects.QueryResult{
+		"speed": object.watcher.printer.GcodeState.Snapshot().FanSpeeds[object.index],
+		"rpm":   object.watcher.getRpm("P" + strconv.Itoa(object.index)),
+	}, nil
+}
+
+type heaterFanObject struct {
+	watcher  *fanWatcher
+	extruder int
+}
+
+func (object heaterFanObject) Query() (printer_objects.QueryResult, error) {
+	heater := "extruder"
+	if object.extruder > 0 {
+		heater += strconv.Itoa(object.extruder)
+	}
+	result, err := object.watcher.printer.Objects.Query(heater)
+	if err != nil {
+		return nil, err
+	}
+
+	speed := 0.
+	if temperature, isFloat := result["temperature"].(float64); isFloat && temperature >= heaterFanTemp {
+		speed = 1
+	}
+	return printer_objects.QueryResult{
+		"speed": speed,
+		"rpm":   object.watcher.getRpm("E" + strconv.Itoa(object.extruder)),
+	}, nil
+}
+
+type fanWatcher struct {
+	printer    *Printer
+	objects    []string
+	rpms       map[string]int
+	rpmsMutex  *sync.RWMutex
+	autoReport bool
+}
+
+func newFanWatcher(printer *Printer) *fanWatcher {
+	fans := printer.config.Printer.Fans
+	watcher := &fanWatcher{
+		printer:    printer,
+		objects:    make([]string, 0),
+		rpms:       make(map[string]int),
+		rpmsMutex:  &sync.RWMutex{},
+		autoReport: fans.ReportRpm,
+	}
+
+	for index, names := range fanObjectNames(fans) {
+		for _, name := range names {
+			watcher.register(name, fanObject{watcher, index})
+		}
+	}
+	for name, extruder := range fans.HeaterFans {
+		watcher.register("heater_fan "+name, heaterFanObject{watcher, extruder})
+	}
+
+	if watcher.autoReport {
+		_ = printer.context.Load().QueueGcode("M123 S1", true)
+	}
+	return watcher
+}
+
+func fanObjectNames(fans config.Fans) map[int][]string {
+	names := make(map[int][]string)
+	if fans.PartFan >= 0 && fans.PartFan < maxFans {
+		names[fans.PartFan] = []string{"fan"}
+	}
+	for name, index := range fans.Generic {
+		if index < 0 || index >= maxFans {
+			continue
+		}
+		names[index] = append(names[index], "fan_generic "+name)
+	}
+	return names
+}
+
+func (watcher *fanWatcher) register(name string, object printer_objects.PrinterObject) {
+	watcher.printer.Objects.RegisterObject(name, object)
+	watcher.objects = append(watcher.objects, name)
+}
+
+func (watcher *fanWatcher) getRpm(tachometer string) any {
+	watcher.rpmsMutex.RLock()
+	defer watcher.rpmsMutex.RUnlock()
+	if rpm, exists := watcher.rpms[tachometer]; exists {
+		return rpm
+	}
+	return nil
+}
+
+func (watcher *fanWatcher) handle(line string) {
+	if !parser.IsM123Report(line) {
+		return
+	}
+
+	watcher.rpmsMutex.Lock()
+	for tachometer, rpm := range parser.ParseM123(line) {
+		watcher.rpms[tachometer] = rpm
+	}
+	watcher.rpmsMutex.Unlock()
+
+	if err := watcher.printer.Objects.EmitObject(watcher.objects...); err != nil {
+		log.Errorf("Failed to emit fan objects: %v", err)
+	}
+}
+
+func (watcher *fanWatcher) stop() {
+	for _, name := range watcher.objects {
+		watcher.printer.Objects.UnregisterObject(name)
+	}
+}
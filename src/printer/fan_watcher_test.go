@@ -0,0 +1,51 @@
+package printer
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestFanObjectNames(t *testing.T) {
+	names := fanObjectNames(config.Fans{
+		PartFan: 0,
+		Generic: map[string]int{"Aux": 1, "Chamber": 1, "Exhaust": 2, "Invalid": maxFans, "Negative": -1},
+	})
+	sort.Strings(names[1])
+	assert.DeepEqual(t, names, map[int][]string{
+		0: {"fan"},
+		1: {"fan_generic Aux", "fan_generic Chamber"},
+		2: {"fan_generic Exhaust"},
+	})
+
+	assert.DeepEqual(t, fanObjectNames(config.Fans{PartFan: -1}), map[int][]string{})
+}
+
+func TestGcodeStateFanSpeeds(t *testing.T) {
+	state := &GcodeState{
+		fanObjects: fanObjectNames(config.Fans{PartFan: 0, Generic: map[string]int{"Aux": 1}}),
+		mu:         &sync.RWMutex{},
+	}
+
+	objects, err := state.apply("M106 S127.5")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, objects, []string{"fan"})
+
+	objects, err = state.apply("M106 P1 S51")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, objects, []string{"fan_generic Aux"})
+
+	objects, err = state.apply("M106 P3 S255")
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects), 0)
+	assert.DeepEqual(t, state.FanSpeeds, [maxFans]float64{0.5, 0.2, 0, 1})
+
+	_, err = state.apply("M107 P1")
+	assert.NilError(t, err)
+	assert.Equal(t, state.FanSpeeds[1], 0.)
+
+	_, err = state.apply("M106 P8 S255")
+	assert.Error(t, err, "fan index 8 out of range")
+}
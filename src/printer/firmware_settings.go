@@ -0,0 +1,319 @@
+package printer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/database"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	firmwareSettingsAbsTolerance = 0.006
+	firmwareSettingsRelTolerance = 0.001
+	firmwareSettingsMaxRecords   = 50
+)
+
+type FirmwareSettingsChange struct {
+	Setting string  `json:"setting"`
+	Before  float64 `json:"before"`
+	After   float64 `json:"after"`
+}
+
+type FirmwareSettingsRecord struct {
+	Action  string                   `json:"action"`
+	Time    float64                  `json:"time"`
+	Saved   bool                     `json:"saved"`
+	Changes []FirmwareSettingsChange `json:"changes"`
+}
+
+type firmwareSetting struct {
+	command string
+	param   string
+	value   func(settings parser.FirmwareSettings) (float64, bool)
+}
+
+var firmwareSettings = buildFirmwareSettings()
+
+func buildFirmwareSettings() map[string]firmwareSetting {
+	settings := make(map[string]firmwareSetting)
+
+	axes := func(section string, command string, names string, values func(parser.FirmwareSettings) []float64) {
+		for i, axis := range names {
+			settings[section+"."+strings.ToLower(string(axis))] = firmwareSetting{command, string(axis),
+				func(firmwareSettings parser.FirmwareSettings) (float64, bool) {
+					if values := values(firmwareSettings); values != nil {
+						return values[i], true
+					}
+					return 0, false
+				}}
+		}
+	}
+	axes("steps_per_unit", "M92", "XYZE", func(settings parser.FirmwareSettings) []float64 { return settings.StepsPerUnit[:] })
+	axes("max_feedrate", "M203", "XYZE", func(settings parser.FirmwareSettings) []float64 { return settings.MaxFeedrate[:] })
+	axes("max_accel", "M201", "XYZE", func(settings parser.FirmwareSettings) []float64 { return settings.MaxAccel[:] })
+	axes("probe_offset", "M851", "XYZ", func(settings parser.FirmwareSettings) []float64 {
+		if settings.ProbeOffset == nil {
+			return nil
+		}
+		return settings.ProbeOffset[:]
+	})
+
+	settings["acceleration.print"] = firmwareSetting{"M204", "P", func(settings parser.FirmwareSettings) (float64, bool) {
+		return settings.Acceleration.Print, true
+	}}
+	settings["acceleration.retract"] = firmwareSetting{"M204", "R", func(settings parser.FirmwareSettings) (float64, bool) {
+		return settings.Acceleration.Retract, true
+	}}
+	settings["acceleration.travel"] = firmwareSetting{"M204", "T", func(settings parser.FirmwareSettings) (float64, bool) {
+		return settings.Acceleration.Travel, true
+	}}
+
+	pid := func(section string, command string, pid func(parser.FirmwareSettings) *parser.PidSettings) {
+		for _, param := range "PID" {
+			settings[section+"."+strings.ToLower(string(param))] = firmwareSetting{command, string(param),
+				func(firmwareSettings parser.FirmwareSettings) (float64, bool) {
+					values := pid(firmwareSettings)
+					if values == nil {
+						return 0, false
+					}
+					return map[rune]float64{'P': values.P, 'I': values.I, 'D': values.D}[param], true
+				}}
+		}
+	}
+	pid("hotend_pid", "M301", func(settings parser.FirmwareSettings) *parser.PidSettings { return settings.HotendPid })
+	pid("bed_pid", "M304", func(settings parser.FirmwareSettings) *parser.PidSettings { return settings.BedPid })
+
+	settings["linear_advance"] = firmwareSetting{"M900", "K", func(settings parser.FirmwareSettings) (float64, bool) {
+		if settings.LinearAdvance == nil {
+			return 0, false
+		}
+		return *settings.LinearAdvance, true
+	}}
+	return settings
+}
+
+func firmwareSettingValue(settings parser.FirmwareSettings, name string) (float64, bool) {
+	return firmwareSettings[name].value(settings)
+}
+
+func (printer *Printer) UpdateFirmwareSettings(context shared.ExecutorContext, changes map[string]float64, save bool) (FirmwareSettingsRecord, error) {
+	return printer.updateFirmwareSettings(context, "update", changes, save)
+}
+
+func (printer *Printer) updateFirmwareSettings(context shared.ExecutorContext, action string, changes map[string]float64, save bool) (FirmwareSettingsRecord, error) {
+	if len(changes) == 0 {
+		return FirmwareSettingsRecord{}, util.NewError(400, "no settings to update")
+	}
+	for name := range changes {
+		if _, exists := firmwareSettings[name]; !exists {
+			return FirmwareSettingsRecord{}, util.NewErrorf(400, "unknown firmware setting %q", name)
+		}
+	}
+	if printer.PrintManager.IsPrinting() {
+		return FirmwareSettingsRecord{}, util.NewError(409, "cannot change firmware settings while printing")
+	}
+
+	before := printer.FirmwareSettings()
+	<-context.QueueGcode(firmwareSettingsGcode(changes), true)
+	after, err := printer.reloadFirmwareSettings(context)
+	if err != nil {
+		return FirmwareSettingsRecord{}, err
+	}
+
+	var errs []error
+	for _, name := range sortedKeys(changes) {
+		value, exists := firmwareSettingValue(after, name)
+		if !exists || !firmwareSettingMatches(value, changes[name]) {
+			errs = append(errs, fmt.Errorf("firmware did not apply %s=%v", name, changes[name]))
+		}
+	}
+	if len(errs) > 0 {
+		reverted := make(map[string]float64)
+		for name := range changes {
+			if value, exists := firmwareSettingValue(before, name); exists {
+				reverted[name] = value
+			}
+		}
+		<-context.QueueGcode(firmwareSettingsGcode(reverted), true)
+		if after, err = printer.reloadFirmwareSettings(context); err != nil {
+			errs = append(errs, err)
+		}
+		errs = append(errs, errors.New("changes have been reverted"))
+		printer.recordFirmwareSettings(action, false, before, after, lo.Keys(changes))
+		return FirmwareSettingsRecord{}, errors.Join(errs...)
+	}
+
+	if save {
+		<-context.QueueGcode("M500", true)
+	}
+	return printer.recordFirmwareSettings(action, save, before, after, lo.Keys(changes)), nil
+}
+
+func firmwareSettingMatches(value float64, expected float64) bool {
+	return math.Abs(value-expected) <= firmwareSettingsAbsTolerance+firmwareSettingsRelTolerance*math.Abs(expected)
+}
+
+func firmwareSettingsGcode(changes map[string]float64) string {
+	commands := make(map[string]map[string]float64)
+	for name, value := range changes {
+		setting := firmwareSettings[name]
+		if _, exists := commands[setting.command]; !exists {
+			commands[setting.command] = make(map[string]float64)
+		}
+		commands[setting.command][setting.param] = value
+	}
+
+	var builder strings.Builder
+	for _, command := range sortedKeys(commands) {
+		builder.WriteString(command)
+		params := commands[command]
+		for _, param := range sortedKeys(params) {
+			builder.WriteString(" " + param + strconv.FormatFloat(params[param], 'f', -1, 64))
+		}
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+func (printer *Printer) ApplyFirmwareSettings(context shared.ExecutorContext, changes map[string]float64, save bool) error {
+	_, err := printer.UpdateFirmwareSettings(context, changes, save)
+	return err
+}
+
+func (printer *Printer) SaveFirmwareSettings(context shared.ExecutorContext) (FirmwareSettingsRecord, error) {
+	<-context.QueueGcode("M500", true)
+	settings := printer.FirmwareSettings()
+	return printer.recordFirmwareSettings("save", true, settings, settings, nil), nil
+}
+
+func (printer *Printer) ResetFirmwareSettings(context shared.ExecutorContext) (FirmwareSettingsRecord, error) {
+	return printer.replaceFirmwareSettings(context, "reset", "M502")
+}
+
+func (printer *Printer) LoadFirmwareSettings(context shared.ExecutorContext) (FirmwareSettingsRecord, error) {
+	return printer.replaceFirmwareSettings(context, "load", "M501")
+}
+
+func (printer *Printer) RollbackFirmwareSettings(context shared.ExecutorContext, save bool) (FirmwareSettingsRecord, error) {
+	records, err := LoadFirmwareSettingsRecords(printer.name)
+	if err != nil {
+		return FirmwareSettingsRecord{}, err
+	}
+	last, _, found := lo.FindLastIndexOf(records, func(record FirmwareSettingsRecord) bool {
+		return record.Action != "rollback" && len(record.Changes) > 0
+	})
+	if !found {
+		return FirmwareSettingsRecord{}, util.NewError(404, "there are no firmware setting changes to roll back")
+	}
+
+	changes := make(map[string]float64, len(last.Changes))
+	for _, change := range last.Changes {
+		changes[change.Setting] = change.Before
+	}
+	return printer.updateFirmwareSettings(context, "rollback", changes, save)
+}
+
+func (printer *Printer) replaceFirmwareSettings(context shared.ExecutorContext, action string, gcode string) (FirmwareSettingsRecord, error) {
+	if printer.PrintManager.IsPrinting() {
+		return FirmwareSettingsRecord{}, util.NewError(409, "cannot change firmware settings while printing")
+	}
+	before := printer.FirmwareSettings()
+	<-context.QueueGcode(gcode, true)
+	after, err := printer.reloadFirmwareSettings(context)
+	if err != nil {
+		return FirmwareSettingsRecord{}, err
+	}
+	return printer.recordFirmwareSettings(action, action == "load", before, after, lo.Keys(firmwareSettings)), nil
+}
+
+func (printer *Printer) reloadFirmwareSettings(context shared.ExecutorContext) (parser.FirmwareSettings, error) {
+	settings, err := parser.ParseM503(<-context.QueueGcode("M503", true))
+	if err != nil {
+		return settings, fmt.Errorf("failed to read firmware settings: %w", err)
+	}
+	printer.firmwareSettings.Store(settings)
+	if err := printer.Objects.EmitObject("toolhead", "configfile"); err != nil {
+		log.Errorf("Failed to emit firmware settings: %v", err)
+	}
+	return settings, nil
+}
+
+func (printer *Printer) recordFirmwareSettings(action string, saved bool, before parser.FirmwareSettings, after parser.FirmwareSettings, names []string) FirmwareSettingsRecord {
+	record := FirmwareSettingsRecord{
+		Action:  action,
+		Time:    float64(time.Now().UnixMilli()) / 1000.0,
+		Saved:   saved,
+		Changes: firmwareSettingsChanges(before, after, names),
+	}
+
+	records, err := LoadFirmwareSettingsRecords(printer.name)
+	if err != nil {
+		log.Errorf("Failed to load firmware settings history: %v", err)
+		records = make([]FirmwareSettingsRecord, 0)
+	}
+	records = append(records, record)
+	if len(records) > firmwareSettingsMaxRecords {
+		records = records[len(records)-firmwareSettingsMaxRecords:]
+	}
+	if _, err := database.PostItem("marlinraker", firmwareSettingsKey(printer.name), records, true); err != nil {
+		log.Errorf("Failed to save firmware settings history: %v", err)
+	}
+	return record
+}
+
+func firmwareSettingsChanges(before parser.FirmwareSettings, after parser.FirmwareSettings, names []string) []FirmwareSettingsChange {
+	changes := make([]FirmwareSettingsChange, 0)
+	sort.Strings(names)
+	for _, name := range names {
+		beforeValue, beforeExists := firmwareSettingValue(before, name)
+		afterValue, afterExists := firmwareSettingValue(after, name)
+		if beforeExists && afterExists && beforeValue != afterValue {
+			changes = append(changes, FirmwareSettingsChange{Setting: name, Before: beforeValue, After: afterValue})
+		}
+	}
+	return changes
+}
+
+func LoadFirmwareSettingsRecords(printerName string) ([]FirmwareSettingsRecord, error) {
+	records := make([]FirmwareSettingsRecord, 0)
+	item, err := database.GetItem("marlinraker", firmwareSettingsKey(printerName), true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return records, nil
+		}
+		return nil, err
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bytes, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func firmwareSettingsKey(printerName string) string {
+	if printerName == "" {
+		return "firmware_settings_history"
+	}
+	return "firmware_settings_history_" + printerName
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := lo.Keys(values)
+	sort.Strings(keys)
+	return keys
+}
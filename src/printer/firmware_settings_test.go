@@ -0,0 +1,167 @@
+package printer
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer/print_manager"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"testing"
+)
+
+type firmwareContext struct {
+	shared.ExecutorContext
+	gcodes    []string
+	responses []string
+}
+
+func (context *firmwareContext) QueueGcode(gcode string, _ bool) chan string {
+	context.gcodes = append(context.gcodes, gcode)
+	ch := make(chan string, 1)
+	if gcode == "M503" && len(context.responses) > 0 {
+		ch <- context.responses[0]
+		context.responses = context.responses[1:]
+	} else {
+		ch <- "ok"
+	}
+	return ch
+}
+
+func m503Response(stepsE float64, printAccel float64, hotendP float64) string {
+	return fmt.Sprintf("echo:  M92 X80.00 Y80.00 Z400.00 E%.2f\n"+
+		"echo:  M201 X500.00 Y500.00 Z100.00 E5000.00\n"+
+		"echo:  M203 X500.00 Y500.00 Z5.00 E25.00\n"+
+		"echo:  M204 P%.2f R3000.00 T3000.00\n"+
+		"echo:  M301 P%.2f I1.08 D114.00\nok", stepsE, printAccel, hotendP)
+}
+
+func TestFirmwareSettingsGcode(t *testing.T) {
+	assert.Equal(t, firmwareSettingsGcode(map[string]float64{
+		"steps_per_unit.e":   415,
+		"steps_per_unit.x":   80.5,
+		"acceleration.print": 1000,
+		"hotend_pid.p":       22.2,
+		"linear_advance":     0.05,
+	}), "M204 P1000\nM301 P22.2\nM900 K0.05\nM92 E415 X80.5\n")
+	assert.Equal(t, firmwareSettingsGcode(map[string]float64{}), "")
+}
+
+func TestFirmwareSettingValue(t *testing.T) {
+	settings := parser.FirmwareSettings{
+		StepsPerUnit: [4]float64{80, 80, 400, 93},
+		HotendPid:    &parser.PidSettings{P: 22.2, I: 1.08, D: 114},
+	}
+
+	value, exists := firmwareSettingValue(settings, "steps_per_unit.e")
+	assert.Assert(t, exists)
+	assert.Equal(t, value, 93.)
+	value, exists = firmwareSettingValue(settings, "hotend_pid.d")
+	assert.Assert(t, exists)
+	assert.Equal(t, value, 114.)
+
+	for _, name := range []string{"bed_pid.p", "linear_advance", "probe_offset.z"} {
+		_, exists = firmwareSettingValue(settings, name)
+		assert.Assert(t, !exists, name)
+	}
+}
+
+func TestFirmwareSettingsChanges(t *testing.T) {
+	linearAdvance := 0.05
+	before := parser.FirmwareSettings{
+		StepsPerUnit: [4]float64{80, 80, 400, 93},
+		Acceleration: parser.AccelerationSettings{Print: 1000, Retract: 1000, Travel: 1500},
+	}
+	after := before
+	after.StepsPerUnit[3] = 415
+	after.Acceleration.Travel = 2000
+	after.LinearAdvance = &linearAdvance
+
+	assert.DeepEqual(t, firmwareSettingsChanges(before, after, []string{"steps_per_unit.x", "steps_per_unit.e", "acceleration.travel", "linear_advance"}),
+		[]FirmwareSettingsChange{
+			{Setting: "acceleration.travel", Before: 1500, After: 2000},
+			{Setting: "steps_per_unit.e", Before: 93, After: 415},
+		})
+	assert.DeepEqual(t, firmwareSettingsChanges(before, before, []string{"steps_per_unit.e"}), []FirmwareSettingsChange{})
+}
+
+func TestFirmwareSettingMatches(t *testing.T) {
+	settings, err := parser.ParseM503("echo:  M201 X500.00 Y500.00 Z100.00 E5000.00\necho:  M203 X500.00 Y500.00 Z5.00 E25.00\n" +
+		"echo:  M900 K0.04\necho:  M301 P22.20 I1.08 D1234.57\nok")
+	assert.NilError(t, err)
+
+	linearAdvance, exists := firmwareSettingValue(settings, "linear_advance")
+	assert.Assert(t, exists)
+	assert.Assert(t, firmwareSettingMatches(linearAdvance, 0.045))
+	assert.Assert(t, firmwareSettingMatches(0.05, 0.045))
+	assert.Assert(t, !firmwareSettingMatches(linearAdvance, 0.06))
+	assert.Assert(t, !firmwareSettingMatches(0, 0.045))
+
+	derivative, exists := firmwareSettingValue(settings, "hotend_pid.d")
+	assert.Assert(t, exists)
+	assert.Assert(t, firmwareSettingMatches(derivative, 1234.567))
+	assert.Assert(t, firmwareSettingMatches(1234.9, 1234.567))
+	assert.Assert(t, !firmwareSettingMatches(114, 1234.567))
+}
+
+func TestUpdateFirmwareSettings(t *testing.T) {
+	setupDatabase(t)
+	settings, err := parser.ParseM503(m503Response(93, 3000, 22.2))
+	assert.NilError(t, err)
+	printer := &Printer{
+		config:           config.DefaultConfig(),
+		Objects:          printer_objects.NewRegistry(""),
+		firmwareSettings: util.NewThreadSafe(settings),
+	}
+	printer.PrintManager = print_manager.NewPrintManager(printer, printer.config)
+	t.Cleanup(func() {
+		printer.PrintManager.Cleanup(nil)
+	})
+	context := &firmwareContext{}
+
+	_, err = printer.UpdateFirmwareSettings(context, map[string]float64{}, false)
+	assert.Error(t, err, "no settings to update")
+	_, err = printer.UpdateFirmwareSettings(context, map[string]float64{"steps_per_unit.w": 1}, false)
+	assert.Error(t, err, `unknown firmware setting "steps_per_unit.w"`)
+	assert.Equal(t, len(context.gcodes), 0)
+
+	context.responses = []string{m503Response(95.5, 1500, 22.2)}
+	record, err := printer.UpdateFirmwareSettings(context, map[string]float64{"steps_per_unit.e": 95.5, "acceleration.print": 1500}, false)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, record.Changes, []FirmwareSettingsChange{
+		{Setting: "acceleration.print", Before: 3000, After: 1500},
+		{Setting: "steps_per_unit.e", Before: 93, After: 95.5},
+	})
+	assert.DeepEqual(t, context.gcodes, []string{"M204 P1500\nM92 E95.5\n", "M503"})
+	assert.Equal(t, printer.FirmwareSettings().StepsPerUnit[3], 95.5)
+
+	context.gcodes = nil
+	context.responses = []string{m503Response(95.5, 1500, 22.2), m503Response(95.5, 1500, 22.2)}
+	_, err = printer.UpdateFirmwareSettings(context, map[string]float64{"hotend_pid.p": 25}, true)
+	assert.Error(t, err, "firmware did not apply hotend_pid.p=25\nchanges have been reverted")
+	assert.DeepEqual(t, context.gcodes, []string{"M301 P25\n", "M503", "M301 P22.2\n", "M503"})
+
+	context.gcodes = nil
+	context.responses = []string{m503Response(93, 3000, 22.2)}
+	record, err = printer.RollbackFirmwareSettings(context, true)
+	assert.NilError(t, err)
+	assert.Equal(t, record.Action, "rollback")
+	assert.Equal(t, record.Saved, true)
+	assert.DeepEqual(t, context.gcodes, []string{"M204 P3000\nM92 E93\n", "M503", "M500"})
+	assert.Equal(t, printer.FirmwareSettings().StepsPerUnit[3], 93.)
+
+	context.gcodes = nil
+	context.responses = []string{"ok"}
+	_, err = printer.LoadFirmwareSettings(context)
+	assert.ErrorContains(t, err, "failed to read firmware settings: ")
+	assert.DeepEqual(t, context.gcodes, []string{"M501", "M503"})
+
+	records, err := LoadFirmwareSettingsRecords("")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, lo.Map(records, func(record FirmwareSettingsRecord, _ int) string { return record.Action }),
+		[]string{"update", "update", "rollback"})
+	assert.Equal(t, len(records[1].Changes), 0)
+}
@@ -8,10 +8,14 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 )
 
+const maxFans = 8
+
 type GcodeState struct {
 	Position             [4]float64
+	GcodePosition        [4]float64
 	IsAbsoluteCoordinate bool
 	IsAbsoluteExtrude    bool
 	SpeedFactor          int
@@ -21,23 +25,77 @@ type GcodeState struct {
 	EOffset              float64
 	Velocity             float64
 	EVelocity            float64
+	FanSpeeds            [maxFans]float64
+	isSkipping           bool
+	skipPosition         [4]float64
+	skipFeedrate         float64
+	fanObjects           map[int][]string
+	objects              *printer_objects.Registry
+	mu                   *sync.RWMutex
+}
+
+func (state *GcodeState) Snapshot() GcodeState {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	snapshot := *state
+	snapshot.mu = &sync.RWMutex{}
+	return snapshot
 }
 
 func (state *GcodeState) ExtrudedFilament() float64 {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.EOffset + state.Position[3]
 }
 
+func (state *GcodeState) GetGcodePosition() [4]float64 {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.GcodePosition
+}
+
+func (state *GcodeState) setEOffset(offset float64) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.EOffset = offset
+}
+
 func (state *GcodeState) update(line string) error {
+	emit, err := state.apply(line)
+	if err != nil || len(emit) == 0 {
+		return err
+	}
+	return state.objects.EmitObject(emit...)
+}
+
+func (state *GcodeState) apply(line string) ([]string, error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
 
 	switch {
+	case parser.G0_G1.MatchString(line):
+		values, err := parser.ParseG0G1G92(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse move: %w", err)
+		}
+		state.applyMove(values, &state.GcodePosition, &state.Feedrate)
+
 	case parser.G92.MatchString(line):
 		values, err := parser.ParseG0G1G92(line)
 		if err != nil {
-			return fmt.Errorf("failed to parse extruder offset: %w", err)
+			return nil, fmt.Errorf("failed to parse extruder offset: %w", err)
 		}
 		if value, exists := values["E"]; exists {
 			state.EOffset += state.Position[3] - value
 		}
+		for i, axis := range []string{"X", "Y", "Z", "E"} {
+			if value, exists := values[axis]; exists {
+				state.GcodePosition[i] = value
+				if state.isSkipping {
+					state.skipPosition[i] = value
+				}
+			}
+		}
 
 	case parser.G28.MatchString(line):
 		homedAxes := parser.ParseG28(line)
@@ -46,64 +104,134 @@ func (state *GcodeState) update(line string) error {
 				state.HomedAxes[i] = true
 			}
 		}
-		if err := printer_objects.EmitObject("toolhead"); err != nil {
-			return err
-		}
+		return []string{"toolhead"}, nil
 
 	case parser.M18_M84_M410.MatchString(line):
 		for i := 0; i < 3; i++ {
 			state.HomedAxes[i] = false
 		}
-		if err := printer_objects.EmitObject("toolhead"); err != nil {
-			return err
-		}
+		return []string{"toolhead"}, nil
 
 	case parser.G90.MatchString(line):
 		state.IsAbsoluteCoordinate = true
 		state.IsAbsoluteExtrude = true
-		if err := printer_objects.EmitObject("gcode_move"); err != nil {
-			return err
-		}
+		return []string{"gcode_move"}, nil
 
 	case parser.G91.MatchString(line):
 		state.IsAbsoluteCoordinate = false
 		state.IsAbsoluteExtrude = false
-		if err := printer_objects.EmitObject("gcode_move"); err != nil {
-			return err
-		}
+		return []string{"gcode_move"}, nil
 
 	case parser.M82.MatchString(line):
 		state.IsAbsoluteExtrude = true
-		if err := printer_objects.EmitObject("gcode_move"); err != nil {
-			return err
-		}
+		return []string{"gcode_move"}, nil
 
 	case parser.M83.MatchString(line):
 		state.IsAbsoluteExtrude = false
-		if err := printer_objects.EmitObject("gcode_move"); err != nil {
-			return err
+		return []string{"gcode_move"}, nil
+
+	case parser.M106.MatchString(line), parser.M107.MatchString(line):
+		index, speed, err := parser.ParseM106M107(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fan speed: %w", err)
+		}
+		if index >= maxFans {
+			return nil, fmt.Errorf("fan index %d out of range", index)
 		}
+		state.FanSpeeds[index] = speed
+		return state.fanObjects[index], nil
 
 	case parser.M220_M221.MatchString(line):
 		factor, err := parser.ParseM220M221(line)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if parser.M220.MatchString(line) {
 			state.SpeedFactor = factor
 		} else {
 			state.ExtrudeFactor = factor
 		}
-		if err := printer_objects.EmitObject("gcode_move"); err != nil {
-			return err
+		return []string{"gcode_move"}, nil
+	}
+
+	return nil, nil
+}
+
+func (state *GcodeState) applyMove(values map[string]float64, position *[4]float64, feedrate *float64) {
+	for i, axis := range []string{"X", "Y", "Z", "E"} {
+		value, exists := values[axis]
+		if !exists {
+			continue
+		}
+		isAbsolute := state.IsAbsoluteCoordinate
+		if i == 3 {
+			isAbsolute = state.IsAbsoluteExtrude
+		}
+		if isAbsolute {
+			position[i] = value
+		} else {
+			position[i] += value
 		}
 	}
+	if value, exists := values["F"]; exists {
+		*feedrate = value
+	}
+}
 
+func (state *GcodeState) SkipMove(line string) error {
+	values, err := parser.ParseG0G1G92(line)
+	if err != nil {
+		return fmt.Errorf("failed to parse move: %w", err)
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.isSkipping {
+		state.isSkipping = true
+		state.skipPosition, state.skipFeedrate = state.GcodePosition, state.Feedrate
+	}
+	state.applyMove(values, &state.skipPosition, &state.skipFeedrate)
 	return nil
 }
 
-func (state *GcodeState) restore(context shared.ExecutorContext, restoreTo GcodeState) {
+func (state *GcodeState) ResumeGcode() string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.isSkipping {
+		return ""
+	}
+	state.isSkipping = false
 
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 3, 64)
+	}
+
+	coords := make([]string, 0)
+	for i := 0; i < 3; i++ {
+		from, to := state.GcodePosition[i], state.skipPosition[i]
+		if math.Abs(from-to) > 1e-6 {
+			value := to
+			if !state.IsAbsoluteCoordinate {
+				value = to - from
+			}
+			coords = append(coords, string("XYZ"[i])+formatFloat(value))
+		}
+	}
+	if state.skipFeedrate != state.Feedrate {
+		coords = append(coords, fmt.Sprintf("F%d", int(state.skipFeedrate)))
+	}
+
+	var builder strings.Builder
+	if len(coords) > 0 {
+		builder.WriteString(fmt.Sprintf("G0 %s\n", strings.Join(coords, " ")))
+	}
+	if state.IsAbsoluteExtrude && math.Abs(state.GcodePosition[3]-state.skipPosition[3]) > 1e-6 {
+		builder.WriteString("G92 E" + formatFloat(state.skipPosition[3]) + "\n")
+	}
+	return builder.String()
+}
+
+func (state *GcodeState) restore(context shared.ExecutorContext, restoreTo GcodeState) {
+	state.mu.Lock()
 	var builder strings.Builder
 	if restoreTo.IsAbsoluteCoordinate {
 		builder.WriteString("G90\n")
@@ -154,6 +282,7 @@ func (state *GcodeState) restore(context shared.ExecutorContext, restoreTo Gcode
 		builder.WriteString(fmt.Sprintf("G1 %s\n", strings.Join(coords, " ")))
 	}
 	state.Position = restoreTo.Position
+	state.mu.Unlock()
 
 	<-context.QueueGcode(builder.String(), true)
 }
@@ -0,0 +1,25 @@
+package printer
+
+import "github.com/samber/lo"
+
+type lineWatcher struct {
+	handler func(line string)
+}
+
+func (watcher *lineWatcher) handle(line string) {
+	watcher.handler(line)
+}
+
+func (*lineWatcher) stop() {}
+
+func (printer *Printer) WatchLines(handler func(line string)) func() {
+	listener := &lineWatcher{handler}
+	printer.watchers.Do(func(watchers []watcher) []watcher {
+		return append(watchers, listener)
+	})
+	return func() {
+		printer.watchers.Do(func(watchers []watcher) []watcher {
+			return lo.Without[watcher](watchers, listener)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package macros
+
+import (
+	"errors"
+	"marlinraker/src/shared"
+)
+
+type bedMeshCalibrateMacro struct{}
+
+func (bedMeshCalibrateMacro) Description() string {
+	return "Perform Mesh Bed Leveling"
+}
+
+func (bedMeshCalibrateMacro) Execute(manager *MacroManager, context shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+	mesh, err := getBedMesh(manager)
+	if err != nil {
+		return err
+	}
+	return mesh.Calibrate(context, params["profile"])
+}
+
+func getBedMesh(manager *MacroManager) (shared.BedMesh, error) {
+	mesh := manager.printer.GetBedMesh()
+	if mesh == nil {
+		return nil, errors.New("bed mesh is not enabled")
+	}
+	return mesh, nil
+}
@@ -0,0 +1,19 @@
+package macros
+
+import (
+	"marlinraker/src/shared"
+)
+
+type bedMeshClearMacro struct{}
+
+func (bedMeshClearMacro) Description() string {
+	return "Clear the Mesh so no z-adjustment is made"
+}
+
+func (bedMeshClearMacro) Execute(manager *MacroManager, context shared.ExecutorContext, _ []string, _ Objects, _ Params) error {
+	mesh, err := getBedMesh(manager)
+	if err != nil {
+		return err
+	}
+	return mesh.Clear(context)
+}
@@ -0,0 +1,30 @@
+package macros
+
+import (
+	"errors"
+	"marlinraker/src/shared"
+)
+
+type bedMeshProfileMacro struct{}
+
+func (bedMeshProfileMacro) Description() string {
+	return "Bed Mesh Persistent Storage management"
+}
+
+func (bedMeshProfileMacro) Execute(manager *MacroManager, context shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+	mesh, err := getBedMesh(manager)
+	if err != nil {
+		return err
+	}
+
+	if name, exists := params["load"]; exists {
+		return mesh.LoadProfile(context, name)
+	}
+	if name, exists := params["save"]; exists {
+		return mesh.SaveProfile(context, name)
+	}
+	if name, exists := params["remove"]; exists {
+		return mesh.RemoveProfile(name)
+	}
+	return errors.New("invalid syntax, use BED_MESH_PROFILE LOAD|SAVE|REMOVE=<name>")
+}
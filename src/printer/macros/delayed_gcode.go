@@ -0,0 +1,86 @@
+package macros
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/shared"
+	"strings"
+	"sync"
+	"time"
+)
+
+type delayedGcode struct {
+	id              string
+	initialDuration time.Duration
+	macro           Macro
+	timer           *time.Timer
+	mu              *sync.Mutex
+}
+
+func (manager *MacroManager) StartDelayedGcodes() {
+	for _, delayed := range manager.delayedGcodes {
+		if delayed.initialDuration > 0 {
+			manager.scheduleDelayedGcode(delayed, delayed.initialDuration)
+		}
+	}
+}
+
+func (manager *MacroManager) scheduleDelayedGcode(delayed *delayedGcode, duration time.Duration) {
+	delayed.mu.Lock()
+	defer delayed.mu.Unlock()
+
+	if delayed.timer != nil {
+		delayed.timer.Stop()
+		delayed.timer = nil
+	}
+	if duration <= 0 {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		delayed.mu.Lock()
+		if delayed.timer == timer {
+			delayed.timer = nil
+		}
+		delayed.mu.Unlock()
+
+		log.Debugf("Running delayed_gcode %s", delayed.id)
+		manager.printer.MainExecutorContext().QueueMacro("delayed_gcode "+delayed.id, func(context shared.ExecutorContext) error {
+			ch, err := manager.ExecuteMacro(delayed.macro, context, delayed.id)
+			if err == nil {
+				err = <-ch
+			}
+			return err
+		})
+	})
+	delayed.timer = timer
+}
+
+func (manager *MacroManager) stopDelayedGcodes() {
+	for _, delayed := range manager.delayedGcodes {
+		manager.scheduleDelayedGcode(delayed, 0)
+	}
+}
+
+type updateDelayedGcodeMacro struct{}
+
+func (updateDelayedGcodeMacro) Description() string {
+	return "Update the duration of a delayed_gcode"
+}
+
+func (updateDelayedGcodeMacro) Execute(manager *MacroManager, _ shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+	id, err := params.RequireString("id")
+	if err != nil {
+		return err
+	}
+	delayed, exists := manager.delayedGcodes[strings.ToLower(id)]
+	if !exists {
+		return fmt.Errorf("unknown delayed_gcode %q", id)
+	}
+	duration, err := params.RequireFloat64("duration")
+	if err != nil {
+		return err
+	}
+	manager.scheduleDelayedGcode(delayed, time.Duration(duration*float64(time.Second)))
+	return nil
+}
@@ -0,0 +1,112 @@
+package macros
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"sync"
+	"testing"
+	"time"
+)
+
+type queueingContext struct {
+	shared.ExecutorContext
+	mu     *sync.Mutex
+	gcodes []string
+	macros chan string
+}
+
+func (context *queueingContext) QueueGcode(gcode string, _ bool) chan string {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	context.gcodes = append(context.gcodes, gcode)
+	ch := make(chan string, 1)
+	ch <- "ok"
+	return ch
+}
+
+func (context *queueingContext) QueueMacro(name string, run func(shared.ExecutorContext) error) chan string {
+	ch := make(chan string, 1)
+	go func() {
+		if err := run(context); err != nil {
+			ch <- "!! Error: " + err.Error()
+		} else {
+			ch <- "ok"
+		}
+		context.macros <- name
+	}()
+	return ch
+}
+
+func (context *queueingContext) Gcodes() []string {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	return append([]string{}, context.gcodes...)
+}
+
+type delayedGcodePrinter struct {
+	testPrinter
+	context *queueingContext
+}
+
+func (printer delayedGcodePrinter) Name() string {
+	return ""
+}
+
+func (printer delayedGcodePrinter) MainExecutorContext() shared.ExecutorContext {
+	return printer.context
+}
+
+func TestDelayedGcode(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	assert.NilError(t, database.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.DelayedGcodes = map[string]config.DelayedGcode{
+		"Preheat": {InitialDuration: 0.01, Gcode: "M104 S150"},
+		"bed_off": {TemplateEngine: "jinja", Gcode: "M140 S{0}"},
+		"broken":  {TemplateEngine: "mako", Gcode: "M140 S0"},
+	}
+	context := &queueingContext{mu: &sync.Mutex{}, macros: make(chan string, 1)}
+	printer := delayedGcodePrinter{testPrinter{objects: printer_objects.NewRegistry("")}, context}
+	manager := NewMacroManager(printer, cfg)
+	t.Cleanup(manager.Cleanup)
+
+	waitForMacro := func(name string) {
+		select {
+		case macro := <-context.macros:
+			assert.Equal(t, macro, name)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s did not run", name)
+		}
+	}
+	update := func(params Params) error {
+		return updateDelayedGcodeMacro{}.Execute(manager, nil, nil, nil, params)
+	}
+
+	_, _, exists := manager.GetMacro("_DELAYED_GCODE preheat")
+	assert.Assert(t, !exists)
+	time.Sleep(50 * time.Millisecond)
+	assert.DeepEqual(t, context.Gcodes(), []string{})
+
+	manager.StartDelayedGcodes()
+	waitForMacro("delayed_gcode preheat")
+	assert.DeepEqual(t, context.Gcodes(), []string{"M104 S150"})
+
+	assert.NilError(t, update(Params{"id": "BED_OFF", "duration": "0.01"}))
+	waitForMacro("delayed_gcode bed_off")
+	assert.DeepEqual(t, context.Gcodes(), []string{"M104 S150", "M140 S0"})
+
+	assert.NilError(t, update(Params{"id": "preheat", "duration": "0.05"}))
+	assert.NilError(t, update(Params{"id": "preheat", "duration": "0"}))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, len(context.macros), 0)
+
+	assert.Error(t, update(Params{"id": "broken", "duration": "1"}), `unknown delayed_gcode "broken"`)
+	assert.Error(t, update(Params{"id": "preheat"}), "missing argument DURATION")
+	assert.Error(t, update(Params{"duration": "1"}), "missing argument ID")
+}
@@ -0,0 +1,35 @@
+package macros
+
+import (
+	"marlinraker/src/files"
+	"marlinraker/src/shared"
+	"strings"
+)
+
+type excludeObjectMacro struct{}
+
+func (excludeObjectMacro) Description() string {
+	return "Cancel moves for an object in the current print"
+}
+
+func (excludeObjectMacro) Execute(manager *MacroManager, _ shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+	printManager := manager.printer.GetPrintManager()
+	name := files.NormalizeObjectName(params["name"])
+
+	switch {
+	case params["reset"] == "1":
+		return printManager.ResetExcludedObjects(name)
+
+	case params["current"] == "1":
+		return printManager.ExcludeObject("")
+
+	case name != "":
+		return printManager.ExcludeObject(name)
+	}
+
+	excluded := printManager.ExcludedObjects()
+	if len(excluded) == 0 {
+		return manager.printer.Respond("// No objects excluded")
+	}
+	return manager.printer.Respond("// Excluded objects: " + strings.Join(excluded, " "))
+}
@@ -1,11 +1,39 @@
 package macros
 
-import "marlinraker/src/printer_objects"
+import (
+	"fmt"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/util"
+	"strings"
+)
 
 type gcodeMacroObject struct {
-	variables map[string]any
+	variables util.ThreadSafe[map[string]any]
 }
 
-func (object gcodeMacroObject) Query() (printer_objects.QueryResult, error) {
-	return object.variables, nil
+func newGcodeMacroObject(variables map[string]any) *gcodeMacroObject {
+	return &gcodeMacroObject{util.NewThreadSafe(variables)}
+}
+
+func (object *gcodeMacroObject) Query() (printer_objects.QueryResult, error) {
+	object.variables.RLock()
+	defer object.variables.RUnlock()
+	result := make(printer_objects.QueryResult, len(object.variables.Get()))
+	for name, value := range object.variables.Get() {
+		result[name] = value
+	}
+	return result, nil
+}
+
+func (object *gcodeMacroObject) setVariable(name string, value any) error {
+	object.variables.Lock()
+	defer object.variables.Unlock()
+	variables := object.variables.Get()
+	for existing := range variables {
+		if strings.EqualFold(existing, name) {
+			variables[existing] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown gcode_macro variable %q", name)
 }
@@ -0,0 +1,199 @@
+package jinja
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type filter func(value any, args []any, kwargs map[string]any) (any, error)
+
+type test func(value any, args []any) (bool, error)
+
+var filters = map[string]filter{
+	"abs":     absFilter,
+	"count":   lengthFilter,
+	"d":       defaultFilter,
+	"default": defaultFilter,
+	"float":   floatFilter,
+	"int":     intFilter,
+	"join":    joinFilter,
+	"length":  lengthFilter,
+	"lower":   lowerFilter,
+	"round":   roundFilter,
+	"string":  stringFilter,
+	"trim":    trimFilter,
+	"upper":   upperFilter,
+}
+
+var tests = map[string]test{
+	"defined": func(value any, _ []any) (bool, error) {
+		_, isUndefined := value.(undefined)
+		return !isUndefined, nil
+	},
+	"undefined": func(value any, _ []any) (bool, error) {
+		_, isUndefined := value.(undefined)
+		return isUndefined, nil
+	},
+	"none": func(value any, _ []any) (bool, error) {
+		return value == nil, nil
+	},
+	"number": func(value any, _ []any) (bool, error) {
+		switch normalize(value).(type) {
+		case int, float64:
+			return true, nil
+		}
+		return false, nil
+	},
+	"string": func(value any, _ []any) (bool, error) {
+		_, isString := normalize(value).(string)
+		return isString, nil
+	},
+	"even": func(value any, _ []any) (bool, error) {
+		number, isInt := normalize(value).(int)
+		return isInt && number%2 == 0, nil
+	},
+	"odd": func(value any, _ []any) (bool, error) {
+		number, isInt := normalize(value).(int)
+		return isInt && number%2 != 0, nil
+	},
+}
+
+func defaultFilter(value any, args []any, kwargs map[string]any) (any, error) {
+	fallback := argument(args, kwargs, 0, "default_value", "")
+	boolean := isTruthy(argument(args, kwargs, 1, "boolean", false))
+	if _, isUndefined := value.(undefined); isUndefined || (boolean && !isTruthy(value)) {
+		return fallback, nil
+	}
+	return value, nil
+}
+
+func floatFilter(value any, args []any, kwargs map[string]any) (any, error) {
+	fallback := argument(args, kwargs, 0, "default", 0.)
+	switch value := normalize(value).(type) {
+	case int:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return 1., nil
+		}
+		return 0., nil
+	case string:
+		if float, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return float, nil
+		}
+	}
+	return fallback, nil
+}
+
+func intFilter(value any, args []any, kwargs map[string]any) (any, error) {
+	fallback := argument(args, kwargs, 0, "default", 0)
+	base, isInt := normalize(argument(args, kwargs, 1, "base", 10)).(int)
+	if !isInt {
+		return nil, errors.New("base must be an integer")
+	}
+	switch value := normalize(value).(type) {
+	case int:
+		return value, nil
+	case float64:
+		return int(value), nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		value = strings.TrimSpace(value)
+		if number, err := strconv.ParseInt(value, base, 64); err == nil {
+			return int(number), nil
+		}
+		if float, err := strconv.ParseFloat(value, 64); err == nil {
+			return int(float), nil
+		}
+	}
+	return fallback, nil
+}
+
+func roundFilter(value any, args []any, kwargs map[string]any) (any, error) {
+	precision, isInt := normalize(argument(args, kwargs, 0, "precision", 0)).(int)
+	if !isInt {
+		return nil, errors.New("precision must be an integer")
+	}
+	method := toString(argument(args, kwargs, 1, "method", "common"))
+	number, isNumber := toNumber(value)
+	if !isNumber {
+		return nil, fmt.Errorf("cannot round %s", repr(value))
+	}
+	float := toFloat(number)
+
+	switch method {
+	case "common":
+		rounded, err := strconv.ParseFloat(strconv.FormatFloat(float, 'f', max(precision, 0), 64), 64)
+		if err != nil {
+			return nil, err
+		}
+		return rounded, nil
+	case "ceil", "floor":
+		factor := math.Pow(10, float64(precision))
+		if method == "ceil" {
+			return math.Ceil(float*factor) / factor, nil
+		}
+		return math.Floor(float*factor) / factor, nil
+	}
+	return nil, errors.New("method must be common, ceil or floor")
+}
+
+func lowerFilter(value any, _ []any, _ map[string]any) (any, error) {
+	return strings.ToLower(toString(value)), nil
+}
+
+func upperFilter(value any, _ []any, _ map[string]any) (any, error) {
+	return strings.ToUpper(toString(value)), nil
+}
+
+func trimFilter(value any, _ []any, _ map[string]any) (any, error) {
+	return strings.TrimSpace(toString(value)), nil
+}
+
+func stringFilter(value any, _ []any, _ map[string]any) (any, error) {
+	return toString(value), nil
+}
+
+func absFilter(value any, _ []any, _ map[string]any) (any, error) {
+	switch value := normalize(value).(type) {
+	case int:
+		return max(value, -value), nil
+	case float64:
+		return math.Abs(value), nil
+	}
+	return nil, fmt.Errorf("bad operand type for abs(): %s", repr(value))
+}
+
+func lengthFilter(value any, _ []any, _ map[string]any) (any, error) {
+	switch value := normalize(value).(type) {
+	case string:
+		return utf8.RuneCountInString(value), nil
+	case []any:
+		return len(value), nil
+	case map[string]any:
+		return len(value), nil
+	}
+	return nil, fmt.Errorf("object of type %s has no len()", repr(value))
+}
+
+func joinFilter(value any, args []any, kwargs map[string]any) (any, error) {
+	items, err := iterate(value)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = toString(item)
+	}
+	return strings.Join(parts, toString(argument(args, kwargs, 0, "d", ""))), nil
+}
@@ -0,0 +1,200 @@
+package jinja
+
+import (
+	"errors"
+	"gotest.tools/assert"
+	"testing"
+)
+
+type testObjects map[string]map[string]any
+
+func (objects testObjects) Get(name string) (any, bool, error) {
+	object, exists := objects[name]
+	return object, exists, nil
+}
+
+func render(t *testing.T, source string, context map[string]any) string {
+	tmpl, err := Parse("test", source)
+	assert.NilError(t, err)
+	result, err := tmpl.Render(context)
+	assert.NilError(t, err)
+	return result
+}
+
+func TestExpressions(t *testing.T) {
+	context := map[string]any{
+		"printer": testObjects{
+			"extruder":             {"temperature": 215.5, "target": float32(220)},
+			"gcode_macro START":    {"bed_temp": 60},
+			"toolhead":             {"position": [4]float64{10, 20, 5, 0}, "homed_axes": "xyz"},
+			"configfile":           {"settings": map[string]any{"stepper_x": map[string]any{"position_max": 250}}},
+			"print_stats":          {"state": "printing"},
+			"display_status":       {"progress": 0.5},
+			"exclude_object":       {"objects": []map[string]any{{"name": "a"}, {"name": "b"}}},
+			"heater_bed":           {"temperature": 60.},
+			"temperature_sensor x": {"temperature": 30},
+		},
+		"params":    map[string]any{"TEMP": "210", "NAME": "Cube"},
+		"rawparams": "TEMP=210 NAME=Cube",
+	}
+
+	for _, test := range []struct {
+		source   string
+		expected string
+	}{
+		{"{1 + 2 * 3}", "7"},
+		{"{(1 + 2) * 3}", "9"},
+		{"{7 / 2} {7 // 2} {-7 // 2} {-7 % 3} {2 ** 10}", "3.5 3 -4 2 1024"},
+		{"{1.5 + 1} {10 / 5}", "2.5 2.0"},
+		{"{'a' ~ 1 ~ 2.0 ~ True}", "a12.0True"},
+		{"{'ab' * 2 + 'c'}", "ababc"},
+		{"{[1, 'a', 2.5]} {(1, 2)}", "[1, 'a', 2.5] [1, 2]"},
+		{"{ {'a': 1}['a'] }", "1"},
+		{"{1 < 2 < 3} {3 > 2 > 2} {1 == 1.0} {'a' != 'b'}", "True False True True"},
+		{"{'x' in 'xyz'} {2 not in [1, 2]} {'a' in {'a': 1}}", "True False True"},
+		{"{not 0 and 1 or 2} {0 or ''}", "1 "},
+		{"{'yes' if 1 > 0 else 'no'} {'yes' if false}", "yes "},
+		{"{printer.extruder.temperature} {printer.extruder.target}", "215.5 220.0"},
+		{"{printer['gcode_macro START'].bed_temp}", "60"},
+		{"{printer.toolhead.position[1]} {printer.toolhead.position.2} {printer.toolhead.position[-1]}", "20.0 5.0 0.0"},
+		{"{printer.configfile.settings.stepper_x.position_max}", "250"},
+		{"{'z' in printer.toolhead.homed_axes}", "True"},
+		{"{'extruder' in printer} {'extruder1' in printer}", "True False"},
+		{"{printer.exclude_object.objects[1].name}", "b"},
+		{"{params.TEMP|float + 5} {params.TEMP|int // 2}", "215.0 105"},
+		{"{params.MISSING|default(200)|float} {params.MISSING is defined}", "200.0 False"},
+		{"{params.NAME|lower} {params.NAME|upper} {'  x '|trim}", "cube CUBE x"},
+		{"{3.14159|round(2)} {2.5|round} {2.11|round(1, 'ceil')} {(-3)|abs} {-3|abs}", "3.14 2.0 2.2 3 -3"},
+		{"{'abc'|length} {[1, 2]|count} {[1, 2, 3]|join(',')}", "3 2 1,2,3"},
+		{"{''|default('x', true)} {None|d('y')} {none is none}", "x None True"},
+		{"{'12.7'|int} {'x'|float(1.5)} {'ff'|int(base=16)}", "12 1.5 255"},
+		{"{rawparams.split()[1]} {'a,b'.split(',')|join} {'Hi'.lower().startswith('h')}", "NAME=Cube ab True"},
+		{"{1e-5} {1e16} {0.1 + 0.2}", "1e-05 1e+16 0.30000000000000004"},
+		{"{range(3)} {range(1, 7, 2)}", "[0, 1, 2] [1, 3, 5]"},
+		{"{range(5, 0, -2)} {range(3, 1)} {range(100000)|length}", "[5, 3, 1] [] 100000"},
+		{`{"%.2f" % printer.extruder.temperature} {"%d%%" % 42.7} {"%s=%03d" % ("X", 7)}`, "215.50 42% X=007"},
+		{`{"%-4s|%5.1f|%x|%g" % ("ab", 2.25, 255, 0.1)} {"%r" % "a"}`, "ab  |  2.2|ff|0.1 'a'"},
+		{"{printer.unknown}{params.missing}", ""},
+	} {
+		assert.Equal(t, render(t, test.source, context), test.expected, test.source)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	context := map[string]any{
+		"printer": testObjects{"extruder": {"temperature": 210.}},
+		"params":  map[string]any{"TEMP": "230", "COUNT": "3"},
+		"offsets": map[string]any{"y": 2, "x": 1},
+	}
+
+	for _, test := range []struct {
+		source   string
+		expected string
+	}{
+		{"{% if printer.extruder.temperature > 200 %}hot{% else %}cold{% endif %}", "hot"},
+		{"{% if 0 %}a{% elif params.TEMP|int > 220 %}b{% else %}c{% endif %}", "b"},
+		{"{% set temp = params.TEMP|default(200)|float %}M104 S{temp}", "M104 S230.0"},
+		{"{% set a, b = 1, 2 %}{a + b}", "3"},
+		{`{% set label = "T%d" % 1 %}{label}`, "T1"},
+		{"{% for i in range(params.COUNT|int) %}{i}{% if not loop.last %},{% endif %}{% endfor %}", "0,1,2"},
+		{"{% for i in [1, 2, 3] if i != 2 %}{loop.index}:{i} {% endfor %}", "1:1 2:3 "},
+		{"{% for axis, offset in offsets.items() %}{axis}{offset}{% endfor %}", "x1y2"},
+		{"{% for key in offsets %}{key}{% endfor %}", "xy"},
+		{"{% for i in [] %}x{% else %}empty{% endfor %}", "empty"},
+		{"{% for i in range(2) %}{% set inner = i %}{% endfor %}{inner is defined}", "False"},
+		{"{# comment #}G28\n{%- if true %}\nG1 Z5{% endif -%}\n  M400", "G28\nG1 Z5M400"},
+		{"{% if params.TEMP|int >= 230 and\n   printer.extruder.temperature < 220 %}ok{% endif %}", "ok"},
+	} {
+		assert.Equal(t, render(t, test.source, context), test.expected, test.source)
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	var messages []string
+	context := map[string]any{
+		"action_respond_info": Function(func(args []any, _ map[string]any) (any, error) {
+			messages = append(messages, toString(args[0]))
+			return "", nil
+		}),
+		"action_raise_error": Function(func(args []any, _ map[string]any) (any, error) {
+			return nil, errors.New(toString(args[0]))
+		}),
+	}
+
+	assert.Equal(t, render(t, "{action_respond_info('temp: %s' ~ 200)}G28", context), "G28")
+	assert.DeepEqual(t, messages, []string{"temp: %s200"})
+
+	tmpl, err := Parse("test", "G28\n{% if true %}{action_raise_error('not homed')}{% endif %}")
+	assert.NilError(t, err)
+	_, err = tmpl.Render(context)
+	assert.Error(t, err, "not homed")
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range []struct {
+		source   string
+		expected string
+	}{
+		{"{% if true %}", "line 1: unexpected end of template, expected \"endif\""},
+		{"{% endfor %}", "line 1: unknown tag \"endfor\""},
+		{"G28\n{% while true %}", "line 2: unknown tag \"while\""},
+		{"{1 +}", "line 1: unexpected \"}\""},
+		{"{'abc}", "line 1: unterminated string"},
+		{"{# abc", "line 1: unclosed comment"},
+		{"{1 ? 2}", "line 1: unexpected character '?'"},
+	} {
+		_, err := Parse("test", test.source)
+		assert.Error(t, err, test.expected, test.source)
+	}
+
+	for _, test := range []struct {
+		source   string
+		expected string
+	}{
+		{"{missing.attr}", "'missing' is undefined"},
+		{"{missing + 1}", "'missing' is undefined"},
+		{"{1 / 0}", "division by zero"},
+		{"{'a' - 1}", "unsupported operand types for -: 'a' and 1"},
+		{"{1|unknown}", "no filter named \"unknown\""},
+		{"{1 < 'a'}", "'<' not supported between 1 and 'a'"},
+		{"{% for i in 5 %}{% endfor %}", "5 is not iterable"},
+		{"{undefined_function()}", "'undefined_function' is undefined"},
+		{`{"%d" % "x"}`, "%d format: a number is required, not 'x'"},
+		{`{"%s %s" % 1}`, "not enough arguments for format string"},
+		{`{"%s" % (1, 2)}`, "not all arguments converted during string formatting"},
+		{"{range(100001)}", "range() is too big, ranges larger than 100000 are not allowed"},
+		{"{range(0, -200002, -2)}", "range() is too big, ranges larger than 100000 are not allowed"},
+	} {
+		tmpl, err := Parse("test", test.source)
+		assert.NilError(t, err, test.source)
+		_, err = tmpl.Render(map[string]any{})
+		assert.Error(t, err, test.expected, test.source)
+	}
+}
+
+func TestParseLiteral(t *testing.T) {
+	for _, test := range []struct {
+		source   string
+		expected any
+	}{
+		{"5", 5},
+		{"-2.5", -2.5},
+		{"'abc'", "abc"},
+		{`"x y"`, "x y"},
+		{"True", true},
+		{"None", nil},
+		{"[1, 'a', [2]]", []any{1, "a", []any{2}}},
+		{"{'a': 1, 'b': {'c': False}}", map[string]any{"a": 1, "b": map[string]any{"c": false}}},
+		{"(1, 2)", []any{1, 2}},
+	} {
+		value, err := ParseLiteral(test.source)
+		assert.NilError(t, err, test.source)
+		assert.DeepEqual(t, value, test.expected)
+	}
+
+	for _, source := range []string{"abc", "1 + 2", "[x]", "'a' 'b' c", "{1: 2"} {
+		_, err := ParseLiteral(source)
+		assert.Assert(t, err != nil, source)
+	}
+	assert.Equal(t, Repr(map[string]any{"b": []any{1, "x"}, "a": 2.}), "{'a': 2.0, 'b': [1, 'x']}")
+}
@@ -0,0 +1,299 @@
+package jinja
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenText tokenKind = iota
+	tokenExprStart
+	tokenExprEnd
+	tokenStmtStart
+	tokenStmtEnd
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenOperator
+	tokenEOF
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+var operators = []string{
+	"**", "//", "==", "!=", "<=", ">=",
+	"+", "-", "*", "/", "%", "~", "<", ">", "=", "(", ")", "[", "]", "{", "}", ".", ",", ":", "|",
+}
+
+type lexer struct {
+	source    string
+	pos       int
+	line      int
+	tokens    []token
+	trimLeft  bool
+	exprDepth int
+}
+
+func lex(source string) ([]token, error) {
+	lexer := &lexer{source: source, line: 1}
+	if err := lexer.run(); err != nil {
+		return nil, err
+	}
+	return lexer.tokens, nil
+}
+
+func (lexer *lexer) run() error {
+	for lexer.pos < len(lexer.source) {
+		idx := strings.IndexByte(lexer.source[lexer.pos:], '{')
+		if idx == -1 {
+			lexer.emitText(lexer.source[lexer.pos:])
+			lexer.pos = len(lexer.source)
+			break
+		}
+		lexer.emitText(lexer.source[lexer.pos : lexer.pos+idx])
+		lexer.pos += idx
+
+		switch {
+		case strings.HasPrefix(lexer.source[lexer.pos:], "{#"):
+			if err := lexer.lexComment(); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(lexer.source[lexer.pos:], "{%"):
+			lexer.pos += 2
+			if lexer.peekByte() == '-' {
+				lexer.pos++
+				lexer.trimPreviousText()
+			}
+			lexer.emit(tokenStmtStart, "{%")
+			if err := lexer.lexExpression(tokenStmtEnd); err != nil {
+				return err
+			}
+
+		default:
+			lexer.pos++
+			lexer.emit(tokenExprStart, "{")
+			if err := lexer.lexExpression(tokenExprEnd); err != nil {
+				return err
+			}
+		}
+	}
+	lexer.emit(tokenEOF, "")
+	return nil
+}
+
+func (lexer *lexer) lexComment() error {
+	startLine := lexer.line
+	lexer.pos += 2
+	if lexer.peekByte() == '-' {
+		lexer.trimPreviousText()
+	}
+	idx := strings.Index(lexer.source[lexer.pos:], "#}")
+	if idx == -1 {
+		return fmt.Errorf("line %d: unclosed comment", startLine)
+	}
+	comment := lexer.source[lexer.pos : lexer.pos+idx]
+	lexer.line += strings.Count(comment, "\n")
+	lexer.trimLeft = strings.HasSuffix(comment, "-")
+	lexer.pos += idx + 2
+	return nil
+}
+
+func (lexer *lexer) lexExpression(end tokenKind) error {
+	lexer.exprDepth = 0
+	for {
+		lexer.skipWhitespace()
+		if lexer.pos >= len(lexer.source) {
+			if end == tokenEOF {
+				lexer.emit(tokenEOF, "")
+				return nil
+			}
+			return fmt.Errorf("line %d: unexpected end of template", lexer.line)
+		}
+		rest := lexer.source[lexer.pos:]
+
+		if end == tokenStmtEnd {
+			if strings.HasPrefix(rest, "%}") {
+				lexer.pos += 2
+				lexer.emit(tokenStmtEnd, "%}")
+				return nil
+			}
+			if strings.HasPrefix(rest, "-%}") {
+				lexer.pos += 3
+				lexer.emit(tokenStmtEnd, "%}")
+				lexer.trimLeft = true
+				return nil
+			}
+		} else if end == tokenExprEnd && rest[0] == '}' && lexer.exprDepth == 0 {
+			lexer.pos++
+			lexer.emit(tokenExprEnd, "}")
+			return nil
+		}
+
+		c := rest[0]
+		switch {
+		case c == '_' || isLetter(c):
+			start := lexer.pos
+			for lexer.pos < len(lexer.source) && (lexer.source[lexer.pos] == '_' ||
+				isLetter(lexer.source[lexer.pos]) || isDigit(lexer.source[lexer.pos])) {
+				lexer.pos++
+			}
+			lexer.emit(tokenName, lexer.source[start:lexer.pos])
+
+		case isDigit(c):
+			lexer.lexNumber()
+
+		case c == '"' || c == '\'':
+			if err := lexer.lexString(c); err != nil {
+				return err
+			}
+
+		default:
+			matched := false
+			for _, operator := range operators {
+				if strings.HasPrefix(rest, operator) {
+					switch operator {
+					case "{":
+						lexer.exprDepth++
+					case "}":
+						lexer.exprDepth--
+					}
+					lexer.pos += len(operator)
+					lexer.emit(tokenOperator, operator)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("line %d: unexpected character %q", lexer.line, c)
+			}
+		}
+	}
+}
+
+func (lexer *lexer) lexNumber() {
+	start, kind := lexer.pos, tokenInt
+	lexer.skipDigits()
+	if lexer.peekByte() == '.' && lexer.pos+1 < len(lexer.source) && isDigit(lexer.source[lexer.pos+1]) {
+		kind = tokenFloat
+		lexer.pos++
+		lexer.skipDigits()
+	}
+	if c := lexer.peekByte(); c == 'e' || c == 'E' {
+		next := lexer.pos + 1
+		if next < len(lexer.source) && (lexer.source[next] == '+' || lexer.source[next] == '-') {
+			next++
+		}
+		if next < len(lexer.source) && isDigit(lexer.source[next]) {
+			kind = tokenFloat
+			lexer.pos = next
+			lexer.skipDigits()
+		}
+	}
+	lexer.emit(kind, strings.ReplaceAll(lexer.source[start:lexer.pos], "_", ""))
+}
+
+func (lexer *lexer) lexString(quote byte) error {
+	startLine := lexer.line
+	lexer.pos++
+	var builder strings.Builder
+	for lexer.pos < len(lexer.source) {
+		c := lexer.source[lexer.pos]
+		lexer.pos++
+		switch c {
+		case quote:
+			lexer.emit(tokenString, builder.String())
+			return nil
+		case '\n':
+			lexer.line++
+		case '\\':
+			if lexer.pos >= len(lexer.source) {
+				break
+			}
+			escaped := lexer.source[lexer.pos]
+			lexer.pos++
+			switch escaped {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			case '\\', '\'', '"':
+				c = escaped
+			default:
+				builder.WriteByte('\\')
+				c = escaped
+			}
+		}
+		builder.WriteByte(c)
+	}
+	return fmt.Errorf("line %d: unterminated string", startLine)
+}
+
+func (lexer *lexer) emit(kind tokenKind, value string) {
+	lexer.tokens = append(lexer.tokens, token{kind: kind, value: value, line: lexer.line})
+}
+
+func (lexer *lexer) emitText(text string) {
+	if lexer.trimLeft {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		lexer.trimLeft = false
+	}
+	if text != "" {
+		lexer.emit(tokenText, text)
+		lexer.line += strings.Count(text, "\n")
+	}
+}
+
+func (lexer *lexer) trimPreviousText() {
+	if len(lexer.tokens) == 0 {
+		return
+	}
+	last := &lexer.tokens[len(lexer.tokens)-1]
+	if last.kind == tokenText {
+		last.value = strings.TrimRightFunc(last.value, unicode.IsSpace)
+	}
+}
+
+func (lexer *lexer) skipWhitespace() {
+	for lexer.pos < len(lexer.source) {
+		switch lexer.source[lexer.pos] {
+		case '\n':
+			lexer.line++
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		lexer.pos++
+	}
+}
+
+func (lexer *lexer) skipDigits() {
+	for lexer.pos < len(lexer.source) && (isDigit(lexer.source[lexer.pos]) || lexer.source[lexer.pos] == '_') {
+		lexer.pos++
+	}
+}
+
+func (lexer *lexer) peekByte() byte {
+	if lexer.pos < len(lexer.source) {
+		return lexer.source[lexer.pos]
+	}
+	return 0
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,55 @@
+package jinja
+
+import (
+	"errors"
+	"fmt"
+)
+
+func ParseLiteral(source string) (any, error) {
+	lexer := &lexer{source: source, line: 1}
+	if err := lexer.lexExpression(tokenEOF); err != nil {
+		return nil, err
+	}
+
+	parser := &parser{tokens: lexer.tokens}
+	expr, err := parser.parseTuple()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("invalid literal %q", source)
+	}
+	if !isLiteral(expr) {
+		return nil, errors.New("value is not a literal")
+	}
+	return expr.eval(&state{})
+}
+
+func Repr(value any) string {
+	return repr(value)
+}
+
+func isLiteral(expr expression) bool {
+	switch expr := expr.(type) {
+	case literalExpr:
+		return true
+	case unaryExpr:
+		_, isLiteral := expr.operand.(literalExpr)
+		return isLiteral
+	case listExpr:
+		for _, item := range expr.items {
+			if !isLiteral(item) {
+				return false
+			}
+		}
+		return true
+	case dictExpr:
+		for i := range expr.keys {
+			if !isLiteral(expr.keys[i]) || !isLiteral(expr.values[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
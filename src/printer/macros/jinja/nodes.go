@@ -0,0 +1,483 @@
+package jinja
+
+import (
+	"fmt"
+	"strings"
+)
+
+type node interface {
+	render(*state, *strings.Builder) error
+}
+
+type expression interface {
+	eval(*state) (any, error)
+}
+
+type state struct {
+	scopes []map[string]any
+}
+
+func (state *state) lookup(name string) any {
+	for i := len(state.scopes) - 1; i >= 0; i-- {
+		if value, exists := state.scopes[i][name]; exists {
+			return value
+		}
+	}
+	return undefined{name}
+}
+
+func (state *state) assign(name string, value any) {
+	state.scopes[len(state.scopes)-1][name] = value
+}
+
+func renderNodes(state *state, nodes []node, builder *strings.Builder) error {
+	for _, node := range nodes {
+		if err := node.render(state, builder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type textNode struct {
+	text string
+}
+
+func (node textNode) render(_ *state, builder *strings.Builder) error {
+	builder.WriteString(node.text)
+	return nil
+}
+
+type outputNode struct {
+	value expression
+}
+
+func (node outputNode) render(state *state, builder *strings.Builder) error {
+	value, err := node.value.eval(state)
+	if err != nil {
+		return err
+	}
+	builder.WriteString(toString(value))
+	return nil
+}
+
+type ifBranch struct {
+	condition expression
+	body      []node
+}
+
+type ifNode struct {
+	branches  []ifBranch
+	otherwise []node
+}
+
+func (node ifNode) render(state *state, builder *strings.Builder) error {
+	for _, branch := range node.branches {
+		condition, err := branch.condition.eval(state)
+		if err != nil {
+			return err
+		}
+		if isTruthy(condition) {
+			return renderNodes(state, branch.body, builder)
+		}
+	}
+	return renderNodes(state, node.otherwise, builder)
+}
+
+type forNode struct {
+	targets   []string
+	iterable  expression
+	condition expression
+	body      []node
+	otherwise []node
+}
+
+func (node forNode) render(state *state, builder *strings.Builder) error {
+	iterable, err := node.iterable.eval(state)
+	if err != nil {
+		return err
+	}
+	items, err := iterate(iterable)
+	if err != nil {
+		return err
+	}
+
+	state.scopes = append(state.scopes, make(map[string]any))
+	defer func() {
+		state.scopes = state.scopes[:len(state.scopes)-1]
+	}()
+
+	if node.condition != nil {
+		filtered := make([]any, 0, len(items))
+		for _, item := range items {
+			if err := node.bind(state, item); err != nil {
+				return err
+			}
+			condition, err := node.condition.eval(state)
+			if err != nil {
+				return err
+			}
+			if isTruthy(condition) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	if len(items) == 0 {
+		return renderNodes(state, node.otherwise, builder)
+	}
+
+	for i, item := range items {
+		if err := node.bind(state, item); err != nil {
+			return err
+		}
+		state.assign("loop", map[string]any{
+			"index":     i + 1,
+			"index0":    i,
+			"revindex":  len(items) - i,
+			"revindex0": len(items) - i - 1,
+			"first":     i == 0,
+			"last":      i == len(items)-1,
+			"length":    len(items),
+		})
+		if err := renderNodes(state, node.body, builder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (node forNode) bind(state *state, item any) error {
+	return assignTargets(state, node.targets, item)
+}
+
+type setNode struct {
+	targets []string
+	value   expression
+}
+
+func (node setNode) render(state *state, _ *strings.Builder) error {
+	value, err := node.value.eval(state)
+	if err != nil {
+		return err
+	}
+	return assignTargets(state, node.targets, value)
+}
+
+func assignTargets(state *state, targets []string, value any) error {
+	if len(targets) == 1 {
+		state.assign(targets[0], value)
+		return nil
+	}
+	items, err := iterate(value)
+	if err != nil {
+		return err
+	}
+	if len(items) != len(targets) {
+		return fmt.Errorf("cannot unpack %d values into %d names", len(items), len(targets))
+	}
+	for i, target := range targets {
+		state.assign(target, items[i])
+	}
+	return nil
+}
+
+type literalExpr struct {
+	value any
+}
+
+func (expr literalExpr) eval(*state) (any, error) {
+	return expr.value, nil
+}
+
+type nameExpr struct {
+	name string
+}
+
+func (expr nameExpr) eval(state *state) (any, error) {
+	return normalize(state.lookup(expr.name)), nil
+}
+
+type listExpr struct {
+	items []expression
+}
+
+func (expr listExpr) eval(state *state) (any, error) {
+	list := make([]any, len(expr.items))
+	for i, item := range expr.items {
+		value, err := item.eval(state)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+	return list, nil
+}
+
+type dictExpr struct {
+	keys   []expression
+	values []expression
+}
+
+func (expr dictExpr) eval(state *state) (any, error) {
+	dict := make(map[string]any, len(expr.keys))
+	for i := range expr.keys {
+		key, err := expr.keys[i].eval(state)
+		if err != nil {
+			return nil, err
+		}
+		value, err := expr.values[i].eval(state)
+		if err != nil {
+			return nil, err
+		}
+		dict[toString(key)] = value
+	}
+	return dict, nil
+}
+
+type conditionalExpr struct {
+	condition expression
+	value     expression
+	otherwise expression
+}
+
+func (expr conditionalExpr) eval(state *state) (any, error) {
+	condition, err := expr.condition.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(condition) {
+		return expr.value.eval(state)
+	}
+	return expr.otherwise.eval(state)
+}
+
+type logicalExpr struct {
+	operator string
+	left     expression
+	right    expression
+}
+
+func (expr logicalExpr) eval(state *state) (any, error) {
+	left, err := expr.left.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(left) == (expr.operator == "or") {
+		return left, nil
+	}
+	return expr.right.eval(state)
+}
+
+type notExpr struct {
+	operand expression
+}
+
+func (expr notExpr) eval(state *state) (any, error) {
+	operand, err := expr.operand.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	return !isTruthy(operand), nil
+}
+
+type compareExpr struct {
+	first     expression
+	operators []string
+	operands  []expression
+}
+
+func (expr compareExpr) eval(state *state) (any, error) {
+	left, err := expr.first.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	for i, operator := range expr.operators {
+		right, err := expr.operands[i].eval(state)
+		if err != nil {
+			return nil, err
+		}
+		result, err := compare(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if !result {
+			return false, nil
+		}
+		left = right
+	}
+	return true, nil
+}
+
+type binaryExpr struct {
+	operator string
+	left     expression
+	right    expression
+}
+
+func (expr binaryExpr) eval(state *state) (any, error) {
+	left, err := expr.left.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	right, err := expr.right.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	return arithmetic(expr.operator, left, right)
+}
+
+type unaryExpr struct {
+	operator string
+	operand  expression
+}
+
+func (expr unaryExpr) eval(state *state) (any, error) {
+	operand, err := expr.operand.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	if expr.operator == "-" {
+		return arithmetic("-", 0, operand)
+	}
+	return arithmetic("+", 0, operand)
+}
+
+type attributeExpr struct {
+	value expression
+	name  string
+}
+
+func (expr attributeExpr) eval(state *state) (any, error) {
+	value, err := expr.value.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	return getAttribute(value, expr.name)
+}
+
+type indexExpr struct {
+	value expression
+	index expression
+}
+
+func (expr indexExpr) eval(state *state) (any, error) {
+	value, err := expr.value.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	index, err := expr.index.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	return getItem(value, index)
+}
+
+type keywordArg struct {
+	name  string
+	value expression
+}
+
+func evalArguments(state *state, args []expression, kwargs []keywordArg) ([]any, map[string]any, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		value, err := arg.eval(state)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = value
+	}
+	named := make(map[string]any, len(kwargs))
+	for _, kwarg := range kwargs {
+		value, err := kwarg.value.eval(state)
+		if err != nil {
+			return nil, nil, err
+		}
+		named[kwarg.name] = value
+	}
+	return values, named, nil
+}
+
+type callExpr struct {
+	callee expression
+	args   []expression
+	kwargs []keywordArg
+}
+
+func (expr callExpr) eval(state *state) (any, error) {
+	callee, err := expr.callee.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	function, isFunction := callee.(Function)
+	if !isFunction {
+		if undefined, isUndefined := callee.(undefined); isUndefined {
+			return nil, undefined.error()
+		}
+		return nil, fmt.Errorf("%s is not callable", repr(callee))
+	}
+	args, kwargs, err := evalArguments(state, expr.args, expr.kwargs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := function(args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(result), nil
+}
+
+type filterExpr struct {
+	name   string
+	value  expression
+	args   []expression
+	kwargs []keywordArg
+}
+
+func (expr filterExpr) eval(state *state) (any, error) {
+	filter, exists := filters[expr.name]
+	if !exists {
+		return nil, fmt.Errorf("no filter named %q", expr.name)
+	}
+	value, err := expr.value.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	args, kwargs, err := evalArguments(state, expr.args, expr.kwargs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := filter(value, args, kwargs)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", expr.name, err)
+	}
+	return result, nil
+}
+
+type testExpr struct {
+	name    string
+	value   expression
+	args    []expression
+	negated bool
+}
+
+func (expr testExpr) eval(state *state) (any, error) {
+	test, exists := tests[expr.name]
+	if !exists {
+		return nil, fmt.Errorf("no test named %q", expr.name)
+	}
+	value, err := expr.value.eval(state)
+	if err != nil {
+		return nil, err
+	}
+	args, _, err := evalArguments(state, expr.args, nil)
+	if err != nil {
+		return nil, err
+	}
+	result, err := test(value, args)
+	if err != nil {
+		return nil, err
+	}
+	return result != expr.negated, nil
+}
@@ -0,0 +1,656 @@
+package jinja
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(tokens []token) ([]node, error) {
+	parser := &parser{tokens: tokens}
+	nodes, end, err := parser.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, parser.errorf("unexpected tag %q", end)
+	}
+	return nodes, nil
+}
+
+func (parser *parser) parseBody(endTags ...string) ([]node, string, error) {
+	nodes := make([]node, 0)
+	for {
+		tok := parser.next()
+		switch tok.kind {
+		case tokenEOF:
+			if len(endTags) > 0 {
+				return nil, "", parser.errorf("unexpected end of template, expected %q", endTags[len(endTags)-1])
+			}
+			return nodes, "", nil
+
+		case tokenText:
+			nodes = append(nodes, textNode{tok.value})
+
+		case tokenExprStart:
+			value, err := parser.parseExpression()
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := parser.expect(tokenExprEnd, "}"); err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, outputNode{value})
+
+		case tokenStmtStart:
+			name, err := parser.expect(tokenName, "")
+			if err != nil {
+				return nil, "", err
+			}
+			if slices.Contains(endTags, name.value) {
+				return nodes, name.value, nil
+			}
+			var stmt node
+			switch name.value {
+			case "if":
+				stmt, err = parser.parseIf()
+			case "for":
+				stmt, err = parser.parseFor()
+			case "set":
+				stmt, err = parser.parseSet()
+			default:
+				return nil, "", parser.errorf("unknown tag %q", name.value)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, stmt)
+
+		default:
+			return nil, "", parser.errorf("unexpected %q", tok.value)
+		}
+	}
+}
+
+func (parser *parser) parseIf() (node, error) {
+	stmt := ifNode{}
+	for {
+		condition, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+			return nil, err
+		}
+		body, end, err := parser.parseBody("elif", "else", "endif")
+		if err != nil {
+			return nil, err
+		}
+		stmt.branches = append(stmt.branches, ifBranch{condition, body})
+
+		switch end {
+		case "elif":
+			continue
+		case "else":
+			if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+				return nil, err
+			}
+			stmt.otherwise, _, err = parser.parseBody("endif")
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	}
+}
+
+func (parser *parser) parseFor() (node, error) {
+	targets, err := parser.parseTargets()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.expect(tokenName, "in"); err != nil {
+		return nil, err
+	}
+	iterable, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	var condition expression
+	if parser.accept(tokenName, "if") {
+		if condition, err = parser.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+		return nil, err
+	}
+	body, end, err := parser.parseBody("else", "endfor")
+	if err != nil {
+		return nil, err
+	}
+	stmt := forNode{targets: targets, iterable: iterable, condition: condition, body: body}
+	if end == "else" {
+		if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+			return nil, err
+		}
+		if stmt.otherwise, _, err = parser.parseBody("endfor"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (parser *parser) parseSet() (node, error) {
+	targets, err := parser.parseTargets()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.expect(tokenOperator, "="); err != nil {
+		return nil, err
+	}
+	value, err := parser.parseTuple()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.expect(tokenStmtEnd, ""); err != nil {
+		return nil, err
+	}
+	return setNode{targets, value}, nil
+}
+
+func (parser *parser) parseTargets() ([]string, error) {
+	var targets []string
+	for {
+		name, err := parser.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, name.value)
+		if !parser.accept(tokenOperator, ",") {
+			return targets, nil
+		}
+	}
+}
+
+func (parser *parser) parseTuple() (expression, error) {
+	first, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.peekIs(tokenOperator, ",") {
+		return first, nil
+	}
+	items := []expression{first}
+	for parser.accept(tokenOperator, ",") {
+		item, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return listExpr{items}, nil
+}
+
+func (parser *parser) parseExpression() (expression, error) {
+	value, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.accept(tokenName, "if") {
+		return value, nil
+	}
+	condition, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	var otherwise expression = literalExpr{undefined{"else branch"}}
+	if parser.accept(tokenName, "else") {
+		if otherwise, err = parser.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	return conditionalExpr{condition, value, otherwise}, nil
+}
+
+func (parser *parser) parseOr() (expression, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(tokenName, "or") {
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{"or", left, right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseAnd() (expression, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(tokenName, "and") {
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{"and", left, right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseNot() (expression, error) {
+	if parser.accept(tokenName, "not") {
+		operand, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return parser.parseCompare()
+}
+
+func (parser *parser) parseCompare() (expression, error) {
+	first, err := parser.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	compare := compareExpr{first: first}
+	for {
+		var operator string
+		tok := parser.peek()
+		switch {
+		case tok.kind == tokenOperator && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, tok.value):
+			operator = tok.value
+			parser.pos++
+		case tok.kind == tokenName && tok.value == "in":
+			operator = "in"
+			parser.pos++
+		case tok.kind == tokenName && tok.value == "not" && parser.peekAt(1).kind == tokenName && parser.peekAt(1).value == "in":
+			operator = "not in"
+			parser.pos += 2
+		}
+		if operator == "" {
+			break
+		}
+		operand, err := parser.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		compare.operators = append(compare.operators, operator)
+		compare.operands = append(compare.operands, operand)
+	}
+	if len(compare.operators) == 0 {
+		return first, nil
+	}
+	return compare, nil
+}
+
+func (parser *parser) parseConcat() (expression, error) {
+	left, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(tokenOperator, "~") {
+		right, err := parser.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{"~", left, right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseAdditive() (expression, error) {
+	left, err := parser.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := parser.peek()
+		if tok.kind != tokenOperator || (tok.value != "+" && tok.value != "-") {
+			return left, nil
+		}
+		parser.pos++
+		right, err := parser.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{tok.value, left, right}
+	}
+}
+
+func (parser *parser) parseMultiplicative() (expression, error) {
+	left, err := parser.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := parser.peek()
+		if tok.kind != tokenOperator || !slices.Contains([]string{"*", "/", "//", "%"}, tok.value) {
+			return left, nil
+		}
+		parser.pos++
+		right, err := parser.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{tok.value, left, right}
+	}
+}
+
+func (parser *parser) parsePower() (expression, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.accept(tokenOperator, "**") {
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{"**", left, right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseUnary() (expression, error) {
+	tok := parser.peek()
+	if tok.kind == tokenOperator && (tok.value == "-" || tok.value == "+") {
+		parser.pos++
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{tok.value, operand}, nil
+	}
+	return parser.parseFilters()
+}
+
+func (parser *parser) parseFilters() (expression, error) {
+	value, err := parser.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case parser.accept(tokenOperator, "|"):
+			name, err := parser.expect(tokenName, "")
+			if err != nil {
+				return nil, err
+			}
+			filter := filterExpr{name: name.value, value: value}
+			if parser.accept(tokenOperator, "(") {
+				if filter.args, filter.kwargs, err = parser.parseArguments(); err != nil {
+					return nil, err
+				}
+			}
+			value = filter
+
+		case parser.accept(tokenName, "is"):
+			negated := parser.accept(tokenName, "not")
+			name, err := parser.expect(tokenName, "")
+			if err != nil {
+				return nil, err
+			}
+			test := testExpr{name: name.value, value: value, negated: negated}
+			if parser.accept(tokenOperator, "(") {
+				if test.args, _, err = parser.parseArguments(); err != nil {
+					return nil, err
+				}
+			}
+			value = test
+
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (parser *parser) parsePostfix() (expression, error) {
+	value, err := parser.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case parser.accept(tokenOperator, "."):
+			tok := parser.next()
+			if tok.kind != tokenName && tok.kind != tokenInt {
+				return nil, parser.errorf("expected attribute name, got %q", tok.value)
+			}
+			value = attributeExpr{value, tok.value}
+
+		case parser.accept(tokenOperator, "["):
+			index, err := parser.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := parser.expect(tokenOperator, "]"); err != nil {
+				return nil, err
+			}
+			value = indexExpr{value, index}
+
+		case parser.accept(tokenOperator, "("):
+			call := callExpr{callee: value}
+			if call.args, call.kwargs, err = parser.parseArguments(); err != nil {
+				return nil, err
+			}
+			value = call
+
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (parser *parser) parsePrimary() (expression, error) {
+	tok := parser.next()
+	switch tok.kind {
+	case tokenName:
+		switch tok.value {
+		case "true", "True":
+			return literalExpr{true}, nil
+		case "false", "False":
+			return literalExpr{false}, nil
+		case "none", "None":
+			return literalExpr{nil}, nil
+		}
+		return nameExpr{tok.value}, nil
+
+	case tokenInt:
+		value, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, parser.errorf("invalid number %q", tok.value)
+		}
+		return literalExpr{value}, nil
+
+	case tokenFloat:
+		value, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, parser.errorf("invalid number %q", tok.value)
+		}
+		return literalExpr{value}, nil
+
+	case tokenString:
+		value := tok.value
+		for parser.peek().kind == tokenString {
+			value += parser.next().value
+		}
+		return literalExpr{value}, nil
+
+	case tokenOperator:
+		switch tok.value {
+		case "(":
+			if parser.accept(tokenOperator, ")") {
+				return listExpr{}, nil
+			}
+			value, err := parser.parseTuple()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := parser.expect(tokenOperator, ")"); err != nil {
+				return nil, err
+			}
+			return value, nil
+
+		case "[":
+			items, err := parser.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return listExpr{items}, nil
+
+		case "{":
+			return parser.parseDict()
+		}
+	}
+	return nil, parser.errorf("unexpected %q", tok.value)
+}
+
+func (parser *parser) parseList(end string) ([]expression, error) {
+	items := make([]expression, 0)
+	for !parser.accept(tokenOperator, end) {
+		if len(items) > 0 {
+			if _, err := parser.expect(tokenOperator, ","); err != nil {
+				return nil, err
+			}
+			if parser.accept(tokenOperator, end) {
+				break
+			}
+		}
+		item, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (parser *parser) parseDict() (expression, error) {
+	dict := dictExpr{}
+	for !parser.accept(tokenOperator, "}") {
+		if len(dict.keys) > 0 {
+			if _, err := parser.expect(tokenOperator, ","); err != nil {
+				return nil, err
+			}
+			if parser.accept(tokenOperator, "}") {
+				break
+			}
+		}
+		key, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parser.expect(tokenOperator, ":"); err != nil {
+			return nil, err
+		}
+		value, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		dict.keys = append(dict.keys, key)
+		dict.values = append(dict.values, value)
+	}
+	return dict, nil
+}
+
+func (parser *parser) parseArguments() ([]expression, []keywordArg, error) {
+	var (
+		args   []expression
+		kwargs []keywordArg
+	)
+	for !parser.accept(tokenOperator, ")") {
+		if len(args)+len(kwargs) > 0 {
+			if _, err := parser.expect(tokenOperator, ","); err != nil {
+				return nil, nil, err
+			}
+			if parser.accept(tokenOperator, ")") {
+				break
+			}
+		}
+		if parser.peek().kind == tokenName && parser.peekAt(1).kind == tokenOperator && parser.peekAt(1).value == "=" {
+			name := parser.next().value
+			parser.pos++
+			value, err := parser.parseExpression()
+			if err != nil {
+				return nil, nil, err
+			}
+			kwargs = append(kwargs, keywordArg{name, value})
+			continue
+		}
+		if len(kwargs) > 0 {
+			return nil, nil, parser.errorf("positional argument follows keyword argument")
+		}
+		value, err := parser.parseExpression()
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, value)
+	}
+	return args, kwargs, nil
+}
+
+func (parser *parser) peek() token {
+	return parser.peekAt(0)
+}
+
+func (parser *parser) peekAt(offset int) token {
+	if idx := parser.pos + offset; idx >= 0 && idx < len(parser.tokens) {
+		return parser.tokens[idx]
+	}
+	return parser.tokens[len(parser.tokens)-1]
+}
+
+func (parser *parser) peekIs(kind tokenKind, value string) bool {
+	tok := parser.peek()
+	return tok.kind == kind && tok.value == value
+}
+
+func (parser *parser) next() token {
+	tok := parser.peek()
+	if parser.pos < len(parser.tokens) {
+		parser.pos++
+	}
+	return tok
+}
+
+func (parser *parser) accept(kind tokenKind, value string) bool {
+	if parser.peekIs(kind, value) {
+		parser.pos++
+		return true
+	}
+	return false
+}
+
+func (parser *parser) expect(kind tokenKind, value string) (token, error) {
+	tok := parser.peek()
+	if tok.kind != kind || (value != "" && tok.value != value) {
+		if tok.kind == tokenEOF {
+			return tok, parser.errorf("unexpected end of template")
+		}
+		return tok, parser.errorf("unexpected %q", tok.value)
+	}
+	parser.pos++
+	return tok, nil
+}
+
+func (parser *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", parser.peekAt(-1).line, fmt.Sprintf(format, args...))
+}
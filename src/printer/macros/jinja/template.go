@@ -0,0 +1,89 @@
+package jinja
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const maxRange = 100000
+
+type Template struct {
+	name  string
+	nodes []node
+}
+
+var globals = map[string]any{
+	"range": Function(rangeFunction),
+}
+
+func Parse(name string, source string) (*Template, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := parse(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{name: name, nodes: nodes}, nil
+}
+
+func (template *Template) Name() string {
+	return template.name
+}
+
+func (template *Template) Render(context map[string]any) (string, error) {
+	state := &state{scopes: []map[string]any{globals, context, make(map[string]any)}}
+	builder := strings.Builder{}
+	if err := renderNodes(state, template.nodes, &builder); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func rangeFunction(args []any, _ map[string]any) (any, error) {
+	bounds := make([]int, len(args))
+	for i, arg := range args {
+		bound, isInt := normalize(arg).(int)
+		if !isInt {
+			return nil, fmt.Errorf("range() arguments must be integers, not %s", repr(arg))
+		}
+		bounds[i] = bound
+	}
+
+	start, stop, step := 0, 0, 1
+	switch len(bounds) {
+	case 1:
+		stop = bounds[0]
+	case 2:
+		start, stop = bounds[0], bounds[1]
+	case 3:
+		start, stop, step = bounds[0], bounds[1], bounds[2]
+	default:
+		return nil, fmt.Errorf("range expected 1 to 3 arguments, got %d", len(bounds))
+	}
+	if step == 0 {
+		return nil, errors.New("range() step must not be zero")
+	}
+
+	length := 0
+	if step > 0 && stop > start {
+		length = (stop - start + step - 1) / step
+	} else if step < 0 && start > stop {
+		length = (start - stop - step - 1) / -step
+	}
+	if length > maxRange {
+		return nil, fmt.Errorf("range() is too big, ranges larger than %d are not allowed", maxRange)
+	}
+
+	items := make([]any, 0, length)
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+func ToString(value any) string {
+	return toString(value)
+}
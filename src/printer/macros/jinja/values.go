@@ -0,0 +1,649 @@
+package jinja
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var formatSpecRegex = regexp.MustCompile(`%([-+ 0#]*)([0-9]*)(?:\.([0-9]+))?([diouxXeEfFgGcrs%])`)
+
+type Function func(args []any, kwargs map[string]any) (any, error)
+
+type Getter interface {
+	Get(name string) (any, bool, error)
+}
+
+type undefined struct {
+	name string
+}
+
+func (value undefined) error() error {
+	return fmt.Errorf("'%s' is undefined", value.name)
+}
+
+func normalize(value any) any {
+	switch value := value.(type) {
+	case nil, bool, int, float64, string, []any, map[string]any, undefined, Function, Getter:
+		return value
+	case float32:
+		float, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+		return float
+	case func(args []any, kwargs map[string]any) (any, error):
+		return Function(value)
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(reflected.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(reflected.Uint())
+	case reflect.Float32, reflect.Float64:
+		return normalize(reflected.Convert(reflect.TypeOf(float64(0))).Interface())
+	case reflect.Bool:
+		return reflected.Bool()
+	case reflect.String:
+		return reflected.String()
+	case reflect.Slice, reflect.Array:
+		if reflected.Kind() == reflect.Slice && reflected.IsNil() {
+			return []any{}
+		}
+		list := make([]any, reflected.Len())
+		for i := range list {
+			list[i] = reflected.Index(i).Interface()
+		}
+		return list
+	case reflect.Map:
+		if reflected.Type().Key().Kind() != reflect.String {
+			break
+		}
+		dict := make(map[string]any, reflected.Len())
+		iter := reflected.MapRange()
+		for iter.Next() {
+			dict[iter.Key().String()] = iter.Value().Interface()
+		}
+		return dict
+	case reflect.Pointer, reflect.Interface:
+		if reflected.IsNil() {
+			return nil
+		}
+		return normalize(reflected.Elem().Interface())
+	}
+	return fmt.Sprint(value)
+}
+
+func isTruthy(value any) bool {
+	switch value := normalize(value).(type) {
+	case nil, undefined:
+		return false
+	case bool:
+		return value
+	case int:
+		return value != 0
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case []any:
+		return len(value) > 0
+	case map[string]any:
+		return len(value) > 0
+	}
+	return true
+}
+
+func toString(value any) string {
+	switch value := normalize(value).(type) {
+	case nil:
+		return "None"
+	case undefined:
+		return ""
+	case bool:
+		if value {
+			return "True"
+		}
+		return "False"
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return formatFloat(value)
+	case string:
+		return value
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = repr(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		keys := sortedKeys(value)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = repr(key) + ": " + repr(value[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case Function:
+		return "<function>"
+	case Getter:
+		return "<object>"
+	}
+	return fmt.Sprint(value)
+}
+
+func repr(value any) string {
+	if value, isString := normalize(value).(string); isString {
+		return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", `\'`) + "'"
+	}
+	return toString(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.IsNaN(value):
+		return "nan"
+	}
+	if value != 0 {
+		if exponent := math.Floor(math.Log10(math.Abs(value))); exponent < -4 || exponent >= 16 {
+			return strconv.FormatFloat(value, 'e', -1, 64)
+		}
+	}
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
+
+func sortedKeys(dict map[string]any) []string {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func iterate(value any) ([]any, error) {
+	switch value := normalize(value).(type) {
+	case undefined:
+		return []any{}, nil
+	case []any:
+		return value, nil
+	case map[string]any:
+		keys := sortedKeys(value)
+		items := make([]any, len(keys))
+		for i, key := range keys {
+			items[i] = key
+		}
+		return items, nil
+	case string:
+		items := make([]any, 0, len(value))
+		for _, char := range value {
+			items = append(items, string(char))
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("%s is not iterable", repr(value))
+}
+
+func toNumber(value any) (any, bool) {
+	switch value := normalize(value).(type) {
+	case int, float64:
+		return value, true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	}
+	return nil, false
+}
+
+func toFloat(value any) float64 {
+	switch value := value.(type) {
+	case int:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
+
+func arithmetic(operator string, left, right any) (any, error) {
+	left, right = normalize(left), normalize(right)
+
+	if operator == "~" {
+		return toString(left) + toString(right), nil
+	}
+	if undefined, isUndefined := left.(undefined); isUndefined {
+		return nil, undefined.error()
+	}
+	if undefined, isUndefined := right.(undefined); isUndefined {
+		return nil, undefined.error()
+	}
+
+	switch left := left.(type) {
+	case string:
+		if operator == "%" {
+			return formatString(left, right)
+		}
+		switch right := right.(type) {
+		case string:
+			if operator == "+" {
+				return left + right, nil
+			}
+		case int:
+			if operator == "*" {
+				return strings.Repeat(left, max(0, right)), nil
+			}
+		}
+	case []any:
+		if right, isList := right.([]any); isList && operator == "+" {
+			return append(append([]any{}, left...), right...), nil
+		}
+	}
+
+	leftNumber, isLeftNumber := toNumber(left)
+	rightNumber, isRightNumber := toNumber(right)
+	if !isLeftNumber || !isRightNumber {
+		return nil, fmt.Errorf("unsupported operand types for %s: %s and %s", operator, repr(left), repr(right))
+	}
+
+	leftInt, isLeftInt := leftNumber.(int)
+	rightInt, isRightInt := rightNumber.(int)
+	if isLeftInt && isRightInt {
+		switch operator {
+		case "+":
+			return leftInt + rightInt, nil
+		case "-":
+			return leftInt - rightInt, nil
+		case "*":
+			return leftInt * rightInt, nil
+		case "//", "%":
+			if rightInt == 0 {
+				return nil, errors.New("division by zero")
+			}
+			quotient, remainder := leftInt/rightInt, leftInt%rightInt
+			if remainder != 0 && (remainder < 0) != (rightInt < 0) {
+				quotient--
+				remainder += rightInt
+			}
+			if operator == "//" {
+				return quotient, nil
+			}
+			return remainder, nil
+		case "**":
+			if rightInt >= 0 {
+				result := 1
+				for i := 0; i < rightInt; i++ {
+					result *= leftInt
+				}
+				return result, nil
+			}
+		}
+	}
+
+	a, b := toFloat(leftNumber), toFloat(rightNumber)
+	switch operator {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/", "//", "%":
+		if b == 0 {
+			return nil, errors.New("division by zero")
+		}
+		switch operator {
+		case "/":
+			return a / b, nil
+		case "//":
+			return math.Floor(a / b), nil
+		}
+		remainder := math.Mod(a, b)
+		if remainder != 0 && (remainder < 0) != (b < 0) {
+			remainder += b
+		}
+		return remainder, nil
+	case "**":
+		return math.Pow(a, b), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", operator)
+}
+
+func formatString(format string, value any) (string, error) {
+	args, isTuple := value.([]any)
+	if !isTuple {
+		args = []any{value}
+	}
+
+	var builder strings.Builder
+	last, next := 0, 0
+	for _, match := range formatSpecRegex.FindAllStringSubmatchIndex(format, -1) {
+		builder.WriteString(format[last:match[0]])
+		last = match[1]
+
+		spec, verb := "%"+format[match[2]:match[3]]+format[match[4]:match[5]], format[match[8]:match[9]]
+		if match[6] != -1 {
+			spec += "." + format[match[6]:match[7]]
+		}
+		if verb == "%" {
+			builder.WriteByte('%')
+			continue
+		}
+		if next >= len(args) {
+			return "", errors.New("not enough arguments for format string")
+		}
+		arg := args[next]
+		next++
+
+		switch verb {
+		case "s":
+			builder.WriteString(fmt.Sprintf(spec+"s", toString(arg)))
+		case "r":
+			builder.WriteString(fmt.Sprintf(spec+"s", repr(arg)))
+		default:
+			number, isNumber := toNumber(arg)
+			if !isNumber {
+				return "", fmt.Errorf("%%%s format: a number is required, not %s", verb, repr(arg))
+			}
+			switch verb {
+			case "d", "i", "u":
+				builder.WriteString(fmt.Sprintf(spec+"d", int(toFloat(number))))
+			case "o", "x", "X":
+				builder.WriteString(fmt.Sprintf(spec+verb, int(toFloat(number))))
+			case "c":
+				builder.WriteString(fmt.Sprintf(spec+"c", rune(int(toFloat(number)))))
+			default:
+				if (verb == "g" || verb == "G") && match[6] == -1 {
+					spec += ".6"
+				}
+				builder.WriteString(fmt.Sprintf(spec+verb, toFloat(number)))
+			}
+		}
+	}
+	if next < len(args) {
+		return "", errors.New("not all arguments converted during string formatting")
+	}
+	builder.WriteString(format[last:])
+	return builder.String(), nil
+}
+
+func equals(left, right any) bool {
+	left, right = normalize(left), normalize(right)
+	leftNumber, isLeftNumber := toNumber(left)
+	rightNumber, isRightNumber := toNumber(right)
+	if isLeftNumber && isRightNumber {
+		return toFloat(leftNumber) == toFloat(rightNumber)
+	}
+
+	switch left := left.(type) {
+	case []any:
+		right, isList := right.([]any)
+		if !isList || len(left) != len(right) {
+			return false
+		}
+		for i := range left {
+			if !equals(left[i], right[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		right, isDict := right.(map[string]any)
+		if !isDict || len(left) != len(right) {
+			return false
+		}
+		for key, value := range left {
+			if other, exists := right[key]; !exists || !equals(value, other) {
+				return false
+			}
+		}
+		return true
+	case undefined:
+		_, isUndefined := right.(undefined)
+		return isUndefined
+	case Function, Getter:
+		return false
+	}
+	return left == right
+}
+
+func compare(operator string, left, right any) (bool, error) {
+	left, right = normalize(left), normalize(right)
+	switch operator {
+	case "==":
+		return equals(left, right), nil
+	case "!=":
+		return !equals(left, right), nil
+	case "in", "not in":
+		contained, err := contains(right, left)
+		if err != nil {
+			return false, err
+		}
+		return contained == (operator == "in"), nil
+	}
+
+	var order int
+	leftNumber, isLeftNumber := toNumber(left)
+	rightNumber, isRightNumber := toNumber(right)
+	leftString, isLeftString := left.(string)
+	rightString, isRightString := right.(string)
+	switch {
+	case isLeftNumber && isRightNumber:
+		a, b := toFloat(leftNumber), toFloat(rightNumber)
+		order = 0
+		if a < b {
+			order = -1
+		} else if a > b {
+			order = 1
+		}
+	case isLeftString && isRightString:
+		order = strings.Compare(leftString, rightString)
+	default:
+		return false, fmt.Errorf("'%s' not supported between %s and %s", operator, repr(left), repr(right))
+	}
+
+	switch operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	case ">=":
+		return order >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %s", operator)
+}
+
+func contains(container, item any) (bool, error) {
+	switch container := normalize(container).(type) {
+	case string:
+		item, isString := normalize(item).(string)
+		if !isString {
+			return false, fmt.Errorf("'in <string>' requires string as left operand, not %s", repr(item))
+		}
+		return strings.Contains(container, item), nil
+	case []any:
+		for _, element := range container {
+			if equals(element, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]any:
+		_, exists := container[toString(item)]
+		return exists, nil
+	case Getter:
+		_, exists, err := container.Get(toString(item))
+		return exists, err
+	case undefined:
+		return false, container.error()
+	}
+	return false, fmt.Errorf("argument of type %s is not iterable", repr(container))
+}
+
+func getAttribute(value any, name string) (any, error) {
+	switch value := normalize(value).(type) {
+	case undefined:
+		return nil, value.error()
+	case Getter:
+		result, exists, err := value.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return undefined{name}, nil
+		}
+		return normalize(result), nil
+	case map[string]any:
+		if result, exists := value[name]; exists {
+			return normalize(result), nil
+		}
+		if method := dictMethod(value, name); method != nil {
+			return method, nil
+		}
+	case string:
+		if method := stringMethod(value, name); method != nil {
+			return method, nil
+		}
+	case []any:
+		if idx, err := strconv.Atoi(name); err == nil {
+			return getItem(value, idx)
+		}
+	}
+	return undefined{name}, nil
+}
+
+func getItem(value any, index any) (any, error) {
+	switch value := normalize(value).(type) {
+	case undefined:
+		return nil, value.error()
+	case Getter, map[string]any:
+		return getAttribute(value, toString(index))
+	case []any, string:
+		var length int
+		if list, isList := value.([]any); isList {
+			length = len(list)
+		} else {
+			length = len(value.(string))
+		}
+		idx, isInt := normalize(index).(int)
+		if !isInt {
+			return nil, fmt.Errorf("indices must be integers, not %s", repr(index))
+		}
+		if idx < 0 {
+			idx += length
+		}
+		if idx < 0 || idx >= length {
+			return undefined{fmt.Sprintf("index %d", idx)}, nil
+		}
+		if list, isList := value.([]any); isList {
+			return normalize(list[idx]), nil
+		}
+		return value.(string)[idx : idx+1], nil
+	}
+	return undefined{toString(index)}, nil
+}
+
+func dictMethod(dict map[string]any, name string) Function {
+	switch name {
+	case "items":
+		return func([]any, map[string]any) (any, error) {
+			items := make([]any, 0, len(dict))
+			for _, key := range sortedKeys(dict) {
+				items = append(items, []any{key, dict[key]})
+			}
+			return items, nil
+		}
+	case "keys":
+		return func([]any, map[string]any) (any, error) {
+			return iterate(dict)
+		}
+	case "values":
+		return func([]any, map[string]any) (any, error) {
+			values := make([]any, 0, len(dict))
+			for _, key := range sortedKeys(dict) {
+				values = append(values, dict[key])
+			}
+			return values, nil
+		}
+	case "get":
+		return func(args []any, kwargs map[string]any) (any, error) {
+			if len(args) == 0 {
+				return nil, errors.New("get expected at least 1 argument")
+			}
+			if value, exists := dict[toString(args[0])]; exists {
+				return value, nil
+			}
+			return argument(args, kwargs, 1, "default", nil), nil
+		}
+	}
+	return nil
+}
+
+func stringMethod(value string, name string) Function {
+	transform := func(transform func(string) string) Function {
+		return func([]any, map[string]any) (any, error) {
+			return transform(value), nil
+		}
+	}
+	switch name {
+	case "lower":
+		return transform(strings.ToLower)
+	case "upper":
+		return transform(strings.ToUpper)
+	case "strip":
+		return transform(strings.TrimSpace)
+	case "split":
+		return func(args []any, kwargs map[string]any) (any, error) {
+			var parts []string
+			if separator := argument(args, kwargs, 0, "sep", nil); separator != nil {
+				parts = strings.Split(value, toString(separator))
+			} else {
+				parts = strings.Fields(value)
+			}
+			items := make([]any, len(parts))
+			for i, part := range parts {
+				items[i] = part
+			}
+			return items, nil
+		}
+	case "startswith", "endswith":
+		return func(args []any, kwargs map[string]any) (any, error) {
+			affix := toString(argument(args, kwargs, 0, "prefix", ""))
+			if name == "startswith" {
+				return strings.HasPrefix(value, affix), nil
+			}
+			return strings.HasSuffix(value, affix), nil
+		}
+	case "replace":
+		return func(args []any, kwargs map[string]any) (any, error) {
+			old, replacement := argument(args, kwargs, 0, "old", ""), argument(args, kwargs, 1, "new", "")
+			return strings.ReplaceAll(value, toString(old), toString(replacement)), nil
+		}
+	}
+	return nil
+}
+
+func argument(args []any, kwargs map[string]any, idx int, name string, fallback any) any {
+	if idx < len(args) {
+		return args[idx]
+	}
+	if value, exists := kwargs[name]; exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,105 @@
+package macros
+
+import (
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/printer/macros/jinja"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"strings"
+)
+
+type jinjaMacro struct {
+	description string
+	tmpl        *jinja.Template
+	object      *gcodeMacroObject
+}
+
+func newJinjaMacro(name string, description string, content string, object *gcodeMacroObject) (*jinjaMacro, error) {
+	tmpl, err := jinja.Parse(name, content)
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaMacro{description: description, tmpl: tmpl, object: object}, nil
+}
+
+func (macro *jinjaMacro) Description() string {
+	return macro.description
+}
+
+type printerObjects struct {
+	registry *printer_objects.Registry
+	objects  map[string]printer_objects.PrinterObject
+	cache    map[string]printer_objects.QueryResult
+}
+
+func (printer *printerObjects) Get(name string) (any, bool, error) {
+	if result, cached := printer.cache[name]; cached {
+		return map[string]any(result), true, nil
+	}
+	if printer.objects == nil {
+		printer.objects = printer.registry.GetObjects()
+	}
+	if _, exists := printer.objects[name]; !exists {
+		found := false
+		for objectName := range printer.objects {
+			if strings.EqualFold(objectName, name) {
+				name, found = objectName, true
+				break
+			}
+		}
+		if !found {
+			return nil, false, nil
+		}
+	}
+	result, err := printer.registry.Query(name)
+	if err != nil {
+		return nil, false, err
+	}
+	printer.cache[name] = result
+	return map[string]any(result), true, nil
+}
+
+func (macro *jinjaMacro) Execute(manager *MacroManager, context shared.ExecutorContext, rawParams []string, _ Objects, params Params) error {
+
+	upperParams := make(map[string]any, len(params))
+	for name, value := range params {
+		upperParams[strings.ToUpper(name)] = value
+	}
+
+	variables, err := macro.object.Query()
+	if err != nil {
+		return err
+	}
+	ctx := make(map[string]any, len(variables)+5)
+	for name, value := range variables {
+		ctx[name] = value
+	}
+	ctx["printer"] = &printerObjects{
+		registry: manager.printer.GetObjects(),
+		cache:    make(map[string]printer_objects.QueryResult),
+	}
+	ctx["params"] = upperParams
+	ctx["rawparams"] = strings.Join(rawParams, " ")
+	ctx["action_respond_info"] = jinja.Function(func(args []any, _ map[string]any) (any, error) {
+		for _, arg := range args {
+			if err := manager.printer.Respond("// " + jinja.ToString(arg)); err != nil {
+				log.Errorf("Failed to respond: %v", err)
+			}
+		}
+		return "", nil
+	})
+	ctx["action_raise_error"] = jinja.Function(func(args []any, _ map[string]any) (any, error) {
+		if len(args) == 0 {
+			return nil, errors.New("macro raised an error")
+		}
+		return nil, errors.New(jinja.ToString(args[0]))
+	})
+
+	gcode, err := macro.tmpl.Render(ctx)
+	if err != nil {
+		return err
+	}
+	<-context.QueueGcode(gcode, true)
+	return nil
+}
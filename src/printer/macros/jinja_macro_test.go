@@ -0,0 +1,35 @@
+package macros
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/printer_objects"
+	"testing"
+)
+
+func TestJinjaMacro(t *testing.T) {
+	registry := printer_objects.NewRegistry("")
+	registry.RegisterObject("extruder", newGcodeMacroObject(map[string]any{"target": 205.}))
+	manager := &MacroManager{printer: testPrinter{objects: registry}}
+
+	macro, err := newJinjaMacro("preheat", "Preheat the printer", `
+{%- set temp = params.TEMP|default(200)|float -%}
+{%- if printer.extruder.target < temp %}M104 S{temp}
+{% endif -%}
+M140 S{bed_temp} ; {rawparams}`, newGcodeMacroObject(map[string]any{"bed_temp": 60}))
+	assert.NilError(t, err)
+	assert.Equal(t, macro.Description(), "Preheat the printer")
+
+	context := &recordingContext{}
+	assert.NilError(t, macro.Execute(manager, context, []string{"TEMP=215"}, nil, Params{"temp": "215"}))
+	assert.NilError(t, macro.Execute(manager, context, nil, nil, Params{}))
+	assert.DeepEqual(t, context.gcodes, []string{"M104 S215.0\nM140 S60 ; TEMP=215", "M140 S60 ; "})
+
+	macro, err = newJinjaMacro("check", "", `
+{%- if printer.Extruder.target < params.MIN|int %}{action_raise_error('too cold')}{% endif -%}
+{%- if printer.chamber is not defined %}G28{% endif %}`, newGcodeMacroObject(nil))
+	assert.NilError(t, err)
+	context = &recordingContext{}
+	assert.Error(t, macro.Execute(manager, context, nil, nil, Params{"min": "210"}), "too cold")
+	assert.NilError(t, macro.Execute(manager, context, nil, nil, Params{"min": "200"}))
+	assert.DeepEqual(t, context.gcodes, []string{"G28"})
+}
@@ -9,12 +9,16 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type MacroManager struct {
-	Macros       map[string]Macro
-	macroObjects []string
-	printer      shared.Printer
+	Macros         map[string]Macro
+	macroObjects   map[string]*gcodeMacroObject
+	savedVariables *savedVariablesObject
+	delayedGcodes  map[string]*delayedGcode
+	printer        shared.Printer
 }
 
 type Params map[string]string
@@ -50,22 +54,38 @@ type Macro interface {
 func NewMacroManager(printer shared.Printer, config *config.Config) *MacroManager {
 
 	macros := map[string]Macro{
+		"BED_MESH_CALIBRATE":     bedMeshCalibrateMacro{},
+		"BED_MESH_CLEAR":         bedMeshClearMacro{},
+		"BED_MESH_PROFILE":       bedMeshProfileMacro{},
 		"CANCEL_PRINT":           cancelPrintMacro{},
+		"EXCLUDE_OBJECT":         excludeObjectMacro{},
 		"PAUSE":                  pauseMacro{},
+		"PID_CALIBRATE":          pidCalibrateMacro{},
+		"RECOVER_PRINT":          recoverPrintMacro{},
 		"RESTORE_GCODE_STATE":    restoreGcodeState{},
 		"RESUME":                 resumeMacro{},
 		"SAVE_GCODE_STATE":       saveGcodeState{},
 		"SDCARD_PRINT_FILE":      sdcardPrintFileMacro{},
+		"SAVE_VARIABLE":          saveVariableMacro{},
 		"SDCARD_RESET_FILE":      sdcardResetFileMacro{},
+		"SET_FAN_SPEED":          newSetFanSpeedMacro(config.Printer.Fans.Generic),
+		"SET_GCODE_VARIABLE":     setGcodeVariableMacro{},
 		"SET_HEATER_TEMPERATURE": setHeaterTemperatureMacro{},
+		"SET_PRINT_STATS_INFO":   setPrintStatsInfoMacro{},
 		"TURN_OFF_HEATERS":       turnOffHeatersMacro{},
+		"UPDATE_DELAYED_GCODE":   updateDelayedGcodeMacro{},
 	}
 
-	var macroObjects []string
+	macroObjects := make(map[string]*gcodeMacroObject)
 
 	for name, macroConfig := range config.Macros {
 		name = strings.ToUpper(name)
-		macro, err := newCustomMacro(name, "G-Code macro", macroConfig.Gcode)
+		if macroConfig.Variables == nil {
+			macroConfig.Variables = map[string]any{}
+		}
+		object := newGcodeMacroObject(macroConfig.Variables)
+
+		macro, err := newTemplateMacro(name, "G-Code macro", macroConfig.TemplateEngine, macroConfig.Gcode, object)
 		if err != nil {
 			log.Errorf("Error while loading macro %q: %v", name, err)
 			continue
@@ -90,21 +110,49 @@ func NewMacroManager(printer shared.Printer, config *config.Config) *MacroManage
 		}
 		macros[name] = macro
 
-		if macroConfig.Variables == nil {
-			macroConfig.Variables = map[string]any{}
+		printer.GetObjects().RegisterObject("gcode_macro "+name, object)
+		macroObjects[name] = object
+	}
+
+	delayedGcodes := make(map[string]*delayedGcode)
+	for id, delayedConfig := range config.DelayedGcodes {
+		id = strings.ToLower(id)
+		macro, err := newTemplateMacro(id, "Delayed G-Code", delayedConfig.TemplateEngine,
+			delayedConfig.Gcode, newGcodeMacroObject(map[string]any{}))
+		if err != nil {
+			log.Errorf("Error while loading delayed_gcode %q: %v", id, err)
+			continue
+		}
+		delayedGcodes[id] = &delayedGcode{
+			id:              id,
+			initialDuration: time.Duration(delayedConfig.InitialDuration * float64(time.Second)),
+			macro:           macro,
+			mu:              &sync.Mutex{},
 		}
-		object, objectName := gcodeMacroObject{macroConfig.Variables}, "gcode_macro "+name
-		printer_objects.RegisterObject(objectName, object)
-		macroObjects = append(macroObjects, objectName)
 	}
 
-	return &MacroManager{macros, macroObjects, printer}
+	savedVariables := loadSavedVariables(printer.Name())
+	printer.GetObjects().RegisterObject("save_variables", savedVariables)
+
+	return &MacroManager{macros, macroObjects, savedVariables, delayedGcodes, printer}
+}
+
+func newTemplateMacro(name string, description string, engine string, content string, object *gcodeMacroObject) (Macro, error) {
+	switch engine {
+	case "", "go":
+		return newCustomMacro(name, description, content)
+	case "jinja":
+		return newJinjaMacro(name, description, content, object)
+	}
+	return nil, fmt.Errorf("unknown template engine %q", engine)
 }
 
 func (manager *MacroManager) Cleanup() {
-	for _, objectName := range manager.macroObjects {
-		printer_objects.UnregisterObject(objectName)
+	manager.stopDelayedGcodes()
+	for name := range manager.macroObjects {
+		manager.printer.GetObjects().UnregisterObject("gcode_macro " + name)
 	}
+	manager.printer.GetObjects().UnregisterObject("save_variables")
 }
 
 func (manager *MacroManager) GetMacro(command string) (Macro, string, bool) {
@@ -121,7 +169,7 @@ func (manager *MacroManager) ExecuteMacro(macro Macro, context shared.ExecutorCo
 
 	var err error
 	objects, params := make(Objects), make(Params)
-	for name, object := range printer_objects.GetObjects() {
+	for name, object := range manager.printer.GetObjects().GetObjects() {
 		objects[name], err = object.Query()
 		if err != nil {
 			return nil, err
@@ -0,0 +1,111 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/shared"
+	"strconv"
+	"strings"
+)
+
+type pidCalibrateMacro struct{}
+
+func (pidCalibrateMacro) Description() string {
+	return "Run PID calibration test"
+}
+
+func (pidCalibrateMacro) Execute(manager *MacroManager, context shared.ExecutorContext, _ []string, objects Objects, params Params) error {
+
+	heater, err := params.RequireString("heater")
+	if err != nil {
+		return err
+	}
+
+	availableHeaters := objects["heaters"]["available_heaters"].([]string)
+	if !lo.Contains(availableHeaters, heater) {
+		return fmt.Errorf("cannot find heater %q", heater)
+	}
+
+	target, err := params.RequireFloat64("target")
+	if err != nil {
+		return err
+	}
+
+	cycles := 5
+	if value, exists := params["cycles"]; exists {
+		if cycles, err = strconv.Atoi(value); err != nil || cycles < 3 || cycles > 20 {
+			return errors.New("CYCLES must be between 3 and 20")
+		}
+	}
+
+	save := isTruthy(params["save"])
+	apply := save || isTruthy(params["apply"])
+
+	var index int
+	var section string
+	switch {
+	case strings.HasPrefix(heater, "extruder"):
+		if heater[8:] != "" {
+			if index, err = strconv.Atoi(heater[8:]); err != nil {
+				return fmt.Errorf("could not map heater name %q", heater)
+			}
+		}
+		section = "hotend_pid"
+	case heater == "heater_bed":
+		index, section = -1, "bed_pid"
+	default:
+		return fmt.Errorf("could not map heater name %q", heater)
+	}
+
+	if apply && index > 0 {
+		return fmt.Errorf("cannot apply PID parameters for %s", heater)
+	}
+	if manager.printer.GetPrintManager().IsPrinting() {
+		return errors.New("cannot calibrate PID while printing")
+	}
+
+	respond := func(message string) {
+		if err := manager.printer.Respond(message); err != nil {
+			log.Errorf("Failed to send response: %v", err)
+		}
+	}
+
+	respond(fmt.Sprintf("// PID autotune of %s at %.1f with %d cycles", heater, target, cycles))
+	cycle := 0
+	stop := manager.printer.WatchLines(func(line string) {
+		if result, ok := parser.ParseM303Cycle(line); ok {
+			cycle++
+			respond(fmt.Sprintf("// PID autotune cycle %d/%d: min=%.2f max=%.2f", min(cycle, cycles), cycles, result.Min, result.Max))
+		}
+	})
+	response := <-context.QueueGcode(fmt.Sprintf("M303 E%d S%.1f C%d", index, target, cycles), true)
+	stop()
+
+	pid, err := parser.ParseM303(response)
+	if err != nil {
+		return err
+	}
+	respond(fmt.Sprintf("// PID parameters: pid_Kp=%.3f pid_Ki=%.3f pid_Kd=%.3f", pid.P, pid.I, pid.D))
+
+	if !apply {
+		respond("// Use APPLY=1 to apply or SAVE=1 to apply and save these parameters to EEPROM")
+		return nil
+	}
+	changes := map[string]float64{section + ".p": pid.P, section + ".i": pid.I, section + ".d": pid.D}
+	if err := manager.printer.ApplyFirmwareSettings(context, changes, save); err != nil {
+		return err
+	}
+	if save {
+		respond("// PID parameters applied and saved to EEPROM")
+	} else {
+		respond("// PID parameters applied")
+	}
+	return nil
+}
+
+func isTruthy(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
@@ -0,0 +1,119 @@
+package macros
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"testing"
+)
+
+type testPrintManager struct {
+	shared.PrintManager
+	printing bool
+}
+
+func (manager testPrintManager) IsPrinting() bool {
+	return manager.printing
+}
+
+type pidCalibratePrinter struct {
+	testPrinter
+	printManager testPrintManager
+	responses    []string
+	watcher      func(line string)
+	changes      map[string]float64
+	saved        bool
+}
+
+func (printer *pidCalibratePrinter) GetPrintManager() shared.PrintManager {
+	return printer.printManager
+}
+
+func (printer *pidCalibratePrinter) Respond(message string) error {
+	printer.responses = append(printer.responses, message)
+	return nil
+}
+
+func (printer *pidCalibratePrinter) WatchLines(handler func(line string)) func() {
+	printer.watcher = handler
+	return func() {
+		printer.watcher = nil
+	}
+}
+
+func (printer *pidCalibratePrinter) ApplyFirmwareSettings(_ shared.ExecutorContext, changes map[string]float64, save bool) error {
+	printer.changes, printer.saved = changes, save
+	return nil
+}
+
+type autotuneContext struct {
+	shared.ExecutorContext
+	printer  *pidCalibratePrinter
+	lines    []string
+	response string
+	gcodes   []string
+}
+
+func (context *autotuneContext) QueueGcode(gcode string, _ bool) chan string {
+	context.gcodes = append(context.gcodes, gcode)
+	for _, line := range context.lines {
+		context.printer.watcher(line)
+	}
+	ch := make(chan string, 1)
+	ch <- context.response
+	return ch
+}
+
+func TestPidCalibrate(t *testing.T) {
+	printer := &pidCalibratePrinter{testPrinter: testPrinter{objects: printer_objects.NewRegistry("")}}
+	manager := &MacroManager{printer: printer}
+	objects := Objects{"heaters": {"available_heaters": []string{"extruder", "extruder1", "heater_bed"}}}
+	context := &autotuneContext{
+		printer: printer,
+		lines: []string{
+			" bias: 89 d: 89 min: 206.50 max: 213.70 Ku: 31.61 Tu: 20.40",
+			" bias: 90 d: 90 min: 206.60 max: 213.60 Ku: 31.50 Tu: 20.30",
+			" bias: 90 d: 90 min: 206.70 max: 213.50 Ku: 31.40 Tu: 20.20",
+		},
+		response: " Kp: 22.20 Ki: 2.17 Kd: 56.89\nok",
+	}
+	execute := func(params Params) error {
+		return pidCalibrateMacro{}.Execute(manager, context, nil, objects, params)
+	}
+
+	assert.NilError(t, execute(Params{"heater": "extruder", "target": "210", "cycles": "3"}))
+	assert.DeepEqual(t, context.gcodes, []string{"M303 E0 S210.0 C3"})
+	assert.DeepEqual(t, printer.responses, []string{
+		"// PID autotune of extruder at 210.0 with 3 cycles",
+		"// PID autotune cycle 1/3: min=206.50 max=213.70",
+		"// PID autotune cycle 2/3: min=206.60 max=213.60",
+		"// PID autotune cycle 3/3: min=206.70 max=213.50",
+		"// PID parameters: pid_Kp=22.200 pid_Ki=2.170 pid_Kd=56.890",
+		"// Use APPLY=1 to apply or SAVE=1 to apply and save these parameters to EEPROM",
+	})
+	assert.Assert(t, printer.watcher == nil)
+	assert.Assert(t, printer.changes == nil)
+
+	context.gcodes, context.lines = nil, nil
+	assert.NilError(t, execute(Params{"heater": "heater_bed", "target": "60", "save": "1"}))
+	assert.DeepEqual(t, context.gcodes, []string{"M303 E-1 S60.0 C5"})
+	assert.DeepEqual(t, printer.changes, map[string]float64{"bed_pid.p": 22.2, "bed_pid.i": 2.17, "bed_pid.d": 56.89})
+	assert.Equal(t, printer.saved, true)
+	assert.Equal(t, printer.responses[len(printer.responses)-1], "// PID parameters applied and saved to EEPROM")
+
+	assert.NilError(t, execute(Params{"heater": "extruder", "target": "200", "apply": "true"}))
+	assert.DeepEqual(t, printer.changes, map[string]float64{"hotend_pid.p": 22.2, "hotend_pid.i": 2.17, "hotend_pid.d": 56.89})
+	assert.Equal(t, printer.saved, false)
+	assert.Equal(t, printer.responses[len(printer.responses)-1], "// PID parameters applied")
+
+	context.gcodes, context.response = nil, "PID Autotune failed! Temperature too high\nok"
+	assert.Error(t, execute(Params{"heater": "heater_bed", "target": "150"}), "PID autotune failed: temperature too high")
+
+	assert.Error(t, execute(Params{"target": "60"}), "missing argument HEATER")
+	assert.Error(t, execute(Params{"heater": "chamber", "target": "60"}), `cannot find heater "chamber"`)
+	assert.Error(t, execute(Params{"heater": "extruder", "target": "210", "cycles": "2"}), "CYCLES must be between 3 and 20")
+	assert.Error(t, execute(Params{"heater": "extruder1", "target": "210", "apply": "1"}), "cannot apply PID parameters for extruder1")
+	printer.printManager.printing = true
+	assert.Error(t, execute(Params{"heater": "extruder", "target": "210"}), "cannot calibrate PID while printing")
+	assert.DeepEqual(t, context.gcodes, []string{"M303 E-1 S150.0 C5"})
+}
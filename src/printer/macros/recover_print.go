@@ -0,0 +1,13 @@
+package macros
+
+import "marlinraker/src/shared"
+
+type recoverPrintMacro struct{}
+
+func (recoverPrintMacro) Description() string {
+	return "Resumes a print that was interrupted by a power loss or printer reset"
+}
+
+func (recoverPrintMacro) Execute(manager *MacroManager, context shared.ExecutorContext, _ []string, _ Objects, _ Params) error {
+	return manager.printer.RecoverPrint(context)
+}
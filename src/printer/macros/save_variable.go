@@ -0,0 +1,107 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/database"
+	"marlinraker/src/printer/macros/jinja"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"strings"
+)
+
+type savedVariablesObject struct {
+	key       string
+	variables util.ThreadSafe[map[string]any]
+}
+
+func loadSavedVariables(printerName string) *savedVariablesObject {
+	key := "save_variables"
+	if printerName != "" {
+		key += "_" + printerName
+	}
+	object := &savedVariablesObject{key: key, variables: util.NewThreadSafe(make(map[string]any))}
+
+	item, err := database.GetItem("marlinraker", key, true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if !errors.As(err, &executorErr) || executorErr.Code != 404 {
+			log.Errorf("Failed to load saved variables: %v", err)
+		}
+		return object
+	}
+
+	stored, isMap := item.(map[string]any)
+	if !isMap {
+		log.Errorf("Failed to load saved variables: malformed database entry")
+		return object
+	}
+	variables := object.variables.Get()
+	for name, rawValue := range stored {
+		value, err := jinja.ParseLiteral(fmt.Sprint(rawValue))
+		if err != nil {
+			log.Errorf("Failed to load saved variable %q: %v", name, err)
+			continue
+		}
+		variables[name] = value
+	}
+	return object
+}
+
+func (object *savedVariablesObject) Query() (printer_objects.QueryResult, error) {
+	object.variables.RLock()
+	defer object.variables.RUnlock()
+	variables := make(map[string]any, len(object.variables.Get()))
+	for name, value := range object.variables.Get() {
+		variables[name] = value
+	}
+	return printer_objects.QueryResult{"variables": variables}, nil
+}
+
+func (object *savedVariablesObject) save(name string, value any) error {
+	object.variables.Lock()
+	defer object.variables.Unlock()
+
+	variables := object.variables.Get()
+	variables[name] = value
+	stored := make(map[string]string, len(variables))
+	for name, value := range variables {
+		stored[name] = jinja.Repr(value)
+	}
+	if _, err := database.PostItem("marlinraker", object.key, stored, true); err != nil {
+		return fmt.Errorf("failed to save variable: %w", err)
+	}
+	return nil
+}
+
+type saveVariableMacro struct{}
+
+func (saveVariableMacro) Description() string {
+	return "Save arbitrary variables to disk"
+}
+
+func (saveVariableMacro) Execute(manager *MacroManager, _ shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+
+	name, err := params.RequireString("variable")
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(name) != name {
+		return errors.New("VARIABLE must not contain upper case")
+	}
+	rawValue, err := params.RequireString("value")
+	if err != nil {
+		return err
+	}
+	value, err := parseLiteral(rawValue)
+	if err != nil {
+		return err
+	}
+
+	if err := manager.savedVariables.save(name, value); err != nil {
+		return err
+	}
+	return manager.printer.GetObjects().EmitObject("save_variables")
+}
@@ -0,0 +1,57 @@
+package macros
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/printer_objects"
+	"testing"
+)
+
+func TestSaveVariable(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	assert.NilError(t, database.Init())
+
+	object := loadSavedVariables("voron")
+	registry := printer_objects.NewRegistry("")
+	registry.RegisterObject("save_variables", object)
+	manager := &MacroManager{printer: testPrinter{objects: registry}, savedVariables: object}
+	execute := func(params Params) error {
+		return saveVariableMacro{}.Execute(manager, nil, nil, nil, params)
+	}
+
+	assert.NilError(t, execute(Params{"variable": "bed_offset", "value": "5"}))
+	assert.NilError(t, execute(Params{"variable": "profile", "value": "{'name': 'PLA', 'temps': [215, 60]}"}))
+	assert.Error(t, execute(Params{"variable": "Profile", "value": "1"}), "VARIABLE must not contain upper case")
+	assert.Error(t, execute(Params{"variable": "nozzle"}), "missing argument VALUE")
+	assert.ErrorContains(t, execute(Params{"variable": "nozzle", "value": "brass"}), `unable to parse "brass" as a literal`)
+
+	saved, err := registry.Query("save_variables")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, saved["variables"], map[string]any{
+		"bed_offset": 5,
+		"profile":    map[string]any{"name": "PLA", "temps": []any{215, 60}},
+	})
+
+	stored, err := database.GetItem("marlinraker", "save_variables_voron", true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stored, map[string]any{
+		"bed_offset": "5",
+		"profile":    "{'name': 'PLA', 'temps': [215, 60]}",
+	})
+
+	reloaded, err := loadSavedVariables("voron").Query()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reloaded, saved)
+
+	other, err := loadSavedVariables("").Query()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, other["variables"], map[string]any{})
+
+	_, err = database.PostItem("marlinraker", "save_variables", "malformed", true)
+	assert.NilError(t, err)
+	other, err = loadSavedVariables("").Query()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, other["variables"], map[string]any{})
+}
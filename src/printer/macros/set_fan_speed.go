@@ -0,0 +1,52 @@
+package macros
+
+import (
+	"fmt"
+	"marlinraker/src/shared"
+	"math"
+	"strings"
+)
+
+type setFanSpeedMacro struct {
+	fans map[string]int
+}
+
+func newSetFanSpeedMacro(generic map[string]int) setFanSpeedMacro {
+	fans := make(map[string]int, len(generic))
+	for name, index := range generic {
+		fans[strings.ToLower(name)] = index
+	}
+	return setFanSpeedMacro{fans}
+}
+
+func (setFanSpeedMacro) Description() string {
+	return "Sets the speed of a fan"
+}
+
+func (macro setFanSpeedMacro) Execute(_ *MacroManager, context shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+
+	fan, err := params.RequireString("fan")
+	if err != nil {
+		return err
+	}
+
+	index, exists := macro.fans[strings.ToLower(fan)]
+	if !exists {
+		return fmt.Errorf("cannot find fan %q", fan)
+	}
+
+	speed, err := params.RequireFloat64("speed")
+	if err != nil {
+		return err
+	}
+	if speed < 0 || speed > 1 {
+		return fmt.Errorf("fan speed %.2f out of range [0, 1]", speed)
+	}
+
+	gcode := fmt.Sprintf("M106 P%d S%d", index, int(math.Round(speed*255)))
+	if speed == 0 {
+		gcode = fmt.Sprintf("M107 P%d", index)
+	}
+	<-context.QueueGcode(gcode, true)
+	return nil
+}
@@ -0,0 +1,37 @@
+package macros
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/shared"
+	"testing"
+)
+
+type recordingContext struct {
+	shared.ExecutorContext
+	gcodes []string
+}
+
+func (context *recordingContext) QueueGcode(gcode string, _ bool) chan string {
+	context.gcodes = append(context.gcodes, gcode)
+	ch := make(chan string, 1)
+	ch <- "ok"
+	return ch
+}
+
+func TestSetFanSpeed(t *testing.T) {
+	macro := newSetFanSpeedMacro(map[string]int{"Aux": 1, "chamber": 3})
+	context := &recordingContext{}
+	execute := func(params Params) error {
+		return macro.Execute(nil, context, nil, nil, params)
+	}
+
+	assert.NilError(t, execute(Params{"fan": "aux", "speed": "0.5"}))
+	assert.NilError(t, execute(Params{"fan": "CHAMBER", "speed": "1"}))
+	assert.NilError(t, execute(Params{"fan": "Aux", "speed": "0"}))
+	assert.DeepEqual(t, context.gcodes, []string{"M106 P1 S128", "M106 P3 S255", "M107 P1"})
+
+	assert.Error(t, execute(Params{"fan": "part", "speed": "1"}), `cannot find fan "part"`)
+	assert.Error(t, execute(Params{"fan": "aux", "speed": "1.5"}), "fan speed 1.50 out of range [0, 1]")
+	assert.Error(t, execute(Params{"fan": "aux"}), "missing argument SPEED")
+	assert.Equal(t, len(context.gcodes), 3)
+}
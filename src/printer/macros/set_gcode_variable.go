@@ -0,0 +1,57 @@
+package macros
+
+import (
+	"encoding/json"
+	"fmt"
+	"marlinraker/src/printer/macros/jinja"
+	"marlinraker/src/shared"
+	"strings"
+)
+
+type setGcodeVariableMacro struct{}
+
+func (setGcodeVariableMacro) Description() string {
+	return "Set the value of a G-Code macro variable"
+}
+
+func (setGcodeVariableMacro) Execute(manager *MacroManager, _ shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+
+	macroName, err := params.RequireString("macro")
+	if err != nil {
+		return err
+	}
+	macroName = strings.ToUpper(macroName)
+	object, exists := manager.macroObjects[macroName]
+	if !exists {
+		return fmt.Errorf("unknown gcode_macro %q", macroName)
+	}
+
+	variable, err := params.RequireString("variable")
+	if err != nil {
+		return err
+	}
+	rawValue, err := params.RequireString("value")
+	if err != nil {
+		return err
+	}
+	value, err := parseLiteral(rawValue)
+	if err != nil {
+		return err
+	}
+
+	if err := object.setVariable(variable, value); err != nil {
+		return err
+	}
+	return manager.printer.GetObjects().EmitObject("gcode_macro " + macroName)
+}
+
+func parseLiteral(rawValue string) (any, error) {
+	value, err := jinja.ParseLiteral(rawValue)
+	if err == nil {
+		return value, nil
+	}
+	if json.Unmarshal([]byte(rawValue), &value) == nil {
+		return value, nil
+	}
+	return nil, fmt.Errorf("unable to parse %q as a literal: %w", rawValue, err)
+}
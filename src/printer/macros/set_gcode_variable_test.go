@@ -0,0 +1,62 @@
+package macros
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"testing"
+)
+
+type testPrinter struct {
+	shared.Printer
+	objects *printer_objects.Registry
+}
+
+func (printer testPrinter) GetObjects() *printer_objects.Registry {
+	return printer.objects
+}
+
+func TestParseLiteral(t *testing.T) {
+	for _, test := range []struct {
+		source   string
+		expected any
+	}{
+		{"70", 70},
+		{"0.4", 0.4},
+		{"'PLA'", "PLA"},
+		{"True", true},
+		{"[215, 60]", []any{215, 60}},
+		{"{'name': 'PLA', 'temps': (215, 60)}", map[string]any{"name": "PLA", "temps": []any{215, 60}}},
+		{`{"name": "PETG", "enabled": true}`, map[string]any{"name": "PETG", "enabled": true}},
+		{"null", nil},
+	} {
+		value, err := parseLiteral(test.source)
+		assert.NilError(t, err, test.source)
+		assert.DeepEqual(t, value, test.expected)
+	}
+
+	_, err := parseLiteral("PLA")
+	assert.ErrorContains(t, err, `unable to parse "PLA" as a literal`)
+}
+
+func TestSetGcodeVariable(t *testing.T) {
+	object := newGcodeMacroObject(map[string]any{"bed_temp": 60, "Profile": "PLA"})
+	manager := &MacroManager{
+		macroObjects: map[string]*gcodeMacroObject{"SET_BED": object},
+		printer:      testPrinter{objects: printer_objects.NewRegistry("")},
+	}
+	execute := func(params Params) error {
+		return setGcodeVariableMacro{}.Execute(manager, nil, nil, nil, params)
+	}
+
+	assert.NilError(t, execute(Params{"macro": "set_bed", "variable": "bed_temp", "value": "70"}))
+	assert.NilError(t, execute(Params{"macro": "SET_BED", "variable": "profile", "value": "{'name': 'PETG'}"}))
+	variables, err := object.Query()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, variables, printer_objects.QueryResult{"bed_temp": 70, "Profile": map[string]any{"name": "PETG"}})
+
+	assert.Error(t, execute(Params{"macro": "missing", "variable": "bed_temp", "value": "70"}), `unknown gcode_macro "MISSING"`)
+	assert.Error(t, execute(Params{"macro": "set_bed", "variable": "nozzle", "value": "70"}), `unknown gcode_macro variable "nozzle"`)
+	assert.Error(t, execute(Params{"macro": "set_bed", "variable": "bed_temp"}), "missing argument VALUE")
+	assert.ErrorContains(t, execute(Params{"macro": "set_bed", "variable": "bed_temp", "value": "hot"}), "unable to parse")
+}
@@ -0,0 +1,30 @@
+package macros
+
+import (
+	"fmt"
+	"marlinraker/src/shared"
+	"strconv"
+	"strings"
+)
+
+type setPrintStatsInfoMacro struct{}
+
+func (setPrintStatsInfoMacro) Description() string {
+	return "Set the layer information of the current print"
+}
+
+func (setPrintStatsInfoMacro) Execute(manager *MacroManager, _ shared.ExecutorContext, _ []string, _ Objects, params Params) error {
+	var layers [2]*int
+	for i, name := range []string{"total_layer", "current_layer"} {
+		value, exists := params[name]
+		if !exists {
+			continue
+		}
+		layer, err := strconv.Atoi(value)
+		if err != nil || layer < 0 {
+			return fmt.Errorf("invalid argument %s=%s", strings.ToUpper(name), value)
+		}
+		layers[i] = &layer
+	}
+	return manager.printer.GetPrintManager().SetPrintStatsInfo(layers[0], layers[1])
+}
@@ -0,0 +1,12 @@
+package parser
+
+import "regexp"
+
+var haltRegex = regexp.MustCompile(`^Error:\s*((?:Printer halted|Printer stopped due to errors).*)$`)
+
+func ParseHalt(response string) (string, bool) {
+	if match := haltRegex.FindStringSubmatch(response); match != nil {
+		return match[1], true
+	}
+	return "", false
+}
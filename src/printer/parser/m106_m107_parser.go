@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	pRegex        = regexp.MustCompile(`P([0-9]+)`)
+	fanSpeedRegex = regexp.MustCompile(`S([0-9.]+)`)
+)
+
+func ParseM106M107(request string) (int, float64, error) {
+	index := 0
+	if match := pRegex.FindStringSubmatch(request); match != nil {
+		p, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		index = int(p)
+	}
+
+	if M107.MatchString(request) {
+		return index, 0, nil
+	}
+
+	speed := 255.
+	if match := fanSpeedRegex.FindStringSubmatch(request); match != nil {
+		s, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		speed = s
+	}
+	return index, min(max(speed/255., 0), 1), nil
+}
@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var tachometerRegex = regexp.MustCompile(`([EPC][0-9]*):\s*([0-9]+)\s*RPM`)
+
+func IsM123Report(line string) bool {
+	return tachometerRegex.MatchString(line)
+}
+
+func ParseM123(response string) map[string]int {
+	rpms := make(map[string]int)
+	for _, match := range tachometerRegex.FindAllStringSubmatch(response, -1) {
+		rpm, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		rpms[match[1]] = rpm
+	}
+	return rpms
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type PidAutotuneCycle struct {
+	Min float64
+	Max float64
+}
+
+var (
+	pidCycleRegex   = regexp.MustCompile(`^\s*bias:\s*\d+\s+d:\s*\d+\s+min:\s*([-0-9.]+)\s+max:\s*([-0-9.]+)`)
+	pidDefineRegex  = regexp.MustCompile(`#define\s+DEFAULT_(?:bed|chamber)?K([pid])\s+([-0-9.]+)`)
+	pidValuesRegex  = regexp.MustCompile(`Kp:\s*([-0-9.]+)\s+Ki:\s*([-0-9.]+)\s+Kd:\s*([-0-9.]+)`)
+	pidFailureRegex = regexp.MustCompile(`PID Autotune failed!?\s*(.*)$`)
+)
+
+func ParseM303Cycle(line string) (PidAutotuneCycle, bool) {
+	match := pidCycleRegex.FindStringSubmatch(strings.TrimPrefix(line, "echo:"))
+	if match == nil {
+		return PidAutotuneCycle{}, false
+	}
+	minTemp, _ := strconv.ParseFloat(match[1], 64)
+	maxTemp, _ := strconv.ParseFloat(match[2], 64)
+	return PidAutotuneCycle{Min: minTemp, Max: maxTemp}, true
+}
+
+func ParseM303(response string) (PidSettings, error) {
+
+	var defined, last PidSettings
+	found := make(map[string]bool)
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "echo:"))
+
+		if match := pidFailureRegex.FindStringSubmatch(line); match != nil {
+			reason := strings.TrimSpace(match[1])
+			if reason == "" {
+				reason = "unknown error"
+			}
+			return PidSettings{}, errors.New("PID autotune failed: " + strings.ToLower(reason))
+		}
+
+		if match := pidDefineRegex.FindStringSubmatch(line); match != nil {
+			value, _ := strconv.ParseFloat(match[2], 64)
+			switch match[1] {
+			case "p":
+				defined.P = value
+			case "i":
+				defined.I = value
+			case "d":
+				defined.D = value
+			}
+			found[match[1]] = true
+		} else if match := pidValuesRegex.FindStringSubmatch(line); match != nil {
+			last.P, _ = strconv.ParseFloat(match[1], 64)
+			last.I, _ = strconv.ParseFloat(match[2], 64)
+			last.D, _ = strconv.ParseFloat(match[3], 64)
+			found["values"] = true
+		}
+	}
+
+	switch {
+	case found["p"] && found["i"] && found["d"]:
+		return defined, nil
+	case found["values"]:
+		return last, nil
+	}
+	return PidSettings{}, errors.New("no PID autotune result")
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type BedMeshReport struct {
+	Kind      string
+	Matrix    [][]float64
+	MeshMin   [2]float64
+	MeshMax   [2]float64
+	HasBounds bool
+}
+
+var (
+	meshRowRegex    = regexp.MustCompile(`^\s*(\d+)\s*\|?\s+(.+)$`)
+	meshValueRegex  = regexp.MustCompile(`^[-+]?\d*\.\d+$`)
+	meshCornerRegex = regexp.MustCompile(`\(\s*(-?[0-9.]+)\s*,\s*(-?[0-9.]+)\s*\)`)
+)
+
+func ParseM420(response string) (BedMeshReport, error) {
+
+	report := BedMeshReport{}
+	switch {
+	case strings.Contains(response, "Bed Topography Report"):
+		report.Kind = "ubl"
+	case strings.Contains(response, "Mesh Bed Level data"):
+		report.Kind = "mbl"
+	case strings.Contains(response, "Bilinear Leveling Grid"):
+		report.Kind = "bilinear"
+	default:
+		return report, errors.New("no mesh data")
+	}
+
+	rows := make(map[int][]float64)
+	probed := make(map[int][]bool)
+	var sum float64
+	var count, maxRow int
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "echo:")
+
+		for _, corner := range meshCornerRegex.FindAllStringSubmatch(line, -1) {
+			x, _ := strconv.ParseFloat(corner[1], 64)
+			y, _ := strconv.ParseFloat(corner[2], 64)
+			if !report.HasBounds {
+				report.MeshMin, report.MeshMax, report.HasBounds = [2]float64{x, y}, [2]float64{x, y}, true
+				continue
+			}
+			report.MeshMin = [2]float64{min(report.MeshMin[0], x), min(report.MeshMin[1], y)}
+			report.MeshMax = [2]float64{max(report.MeshMax[0], x), max(report.MeshMax[1], y)}
+		}
+
+		match := meshRowRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		values, isProbed, ok := parseMeshRow(match[2])
+		if !ok {
+			continue
+		}
+		row, _ := strconv.Atoi(match[1])
+		rows[row], probed[row] = values, isProbed
+		maxRow = max(maxRow, row)
+		for i, value := range values {
+			if isProbed[i] {
+				sum += value
+				count++
+			}
+		}
+	}
+
+	if len(rows) == 0 {
+		return report, errors.New("no mesh data")
+	}
+	if len(rows) != maxRow+1 {
+		return report, errors.New("incomplete mesh data")
+	}
+
+	var mean float64
+	if count > 0 {
+		mean = sum / float64(count)
+	}
+	report.Matrix = make([][]float64, len(rows))
+	for i := range report.Matrix {
+		if len(rows[i]) != len(rows[0]) {
+			return report, errors.New("inconsistent mesh size")
+		}
+		for j := range rows[i] {
+			if !probed[i][j] {
+				rows[i][j] = mean
+			}
+		}
+		report.Matrix[i] = rows[i]
+	}
+	return report, nil
+}
+
+func parseMeshRow(row string) ([]float64, []bool, bool) {
+	fields := strings.Fields(row)
+	values, probed := make([]float64, len(fields)), make([]bool, len(fields))
+	hasValue := false
+	for i, field := range fields {
+		field = strings.Trim(field, "[]")
+		switch {
+		case meshValueRegex.MatchString(field):
+			values[i], _ = strconv.ParseFloat(field, 64)
+			probed[i], hasValue = true, true
+		case strings.Trim(field, "=") == "" || field == ".":
+		default:
+			return nil, nil, false
+		}
+	}
+	return values, probed, hasValue
+}
@@ -4,41 +4,181 @@ import (
 	"errors"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
-type PrinterLimits struct {
-	MaxAccel    [3]float32
-	MaxFeedrate [3]float32
+type AccelerationSettings struct {
+	Print   float64 `json:"print"`
+	Retract float64 `json:"retract"`
+	Travel  float64 `json:"travel"`
+}
+
+type AdvancedSettings struct {
+	MinSegmentTime    float64    `json:"min_segment_time"`
+	MinFeedrate       float64    `json:"min_feedrate"`
+	MinTravelFeedrate float64    `json:"min_travel_feedrate"`
+	Jerk              [4]float64 `json:"jerk"`
+	JunctionDeviation float64    `json:"junction_deviation"`
+}
+
+type PidSettings struct {
+	P float64 `json:"p"`
+	I float64 `json:"i"`
+	D float64 `json:"d"`
+}
+
+type LevelingSettings struct {
+	Enabled    bool    `json:"enabled"`
+	FadeHeight float64 `json:"fade_height"`
+}
+
+type FilamentSettings struct {
+	Volumetric bool    `json:"volumetric"`
+	Diameter   float64 `json:"diameter"`
+}
+
+type FirmwareSettings struct {
+	StepsPerUnit    [4]float64           `json:"steps_per_unit"`
+	MaxFeedrate     [4]float64           `json:"max_feedrate"`
+	MaxAccel        [4]float64           `json:"max_accel"`
+	Acceleration    AccelerationSettings `json:"acceleration"`
+	Advanced        AdvancedSettings     `json:"advanced"`
+	HomeOffset      [3]float64           `json:"home_offset"`
+	Filament        *FilamentSettings    `json:"filament"`
+	HotendPid       *PidSettings         `json:"hotend_pid"`
+	BedPid          *PidSettings         `json:"bed_pid"`
+	ProbeOffset     *[3]float64          `json:"probe_offset"`
+	LinearAdvance   *float64             `json:"linear_advance"`
+	StepperCurrents map[string]float64   `json:"stepper_currents"`
+	HybridThreshold map[string]float64   `json:"hybrid_threshold"`
+	Leveling        *LevelingSettings    `json:"leveling"`
 }
 
 var (
-	accelerationRegex = regexp.MustCompile(`(?m)^echo: *M201 X([0-9.]+) Y([0-9.]+) Z([0-9.]+).*$`)
-	feedrateRegex     = regexp.MustCompile(`(?m)^echo: *M203 X([0-9.]+) Y([0-9.]+) Z([0-9.]+).*$`)
+	settingsLineRegex  = regexp.MustCompile(`^(M[0-9]+)(?:\s+(.*))?$`)
+	settingsParamRegex = regexp.MustCompile(`([A-Z])([+-]?[0-9]*\.?[0-9]+)`)
 )
 
-func ParseM503(response string) (PrinterLimits, error) {
+func ParseM503(response string) (FirmwareSettings, error) {
 
-	limits := PrinterLimits{}
+	settings := FirmwareSettings{
+		StepperCurrents: make(map[string]float64),
+		HybridThreshold: make(map[string]float64),
+	}
+	seen := make(map[string]bool)
 
-	accel := accelerationRegex.FindStringSubmatch(response)
-	if accel == nil {
-		return limits, errors.New("invalid response")
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "echo:"))
+		if idx := strings.Index(line, ";"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		match := settingsLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		command, args := match[1], parseSettingsParams(match[2])
+		seen[command] = true
+		switch command {
+		case "M92":
+			applyAxes(&settings.StepsPerUnit, args)
+		case "M203":
+			applyAxes(&settings.MaxFeedrate, args)
+		case "M201":
+			applyAxes(&settings.MaxAccel, args)
+		case "M204":
+			applyParam(&settings.Acceleration.Print, args, "P")
+			applyParam(&settings.Acceleration.Retract, args, "R")
+			applyParam(&settings.Acceleration.Travel, args, "T")
+			if accel, exists := args["S"]; exists {
+				settings.Acceleration.Print, settings.Acceleration.Travel = accel, accel
+			}
+		case "M205":
+			applyParam(&settings.Advanced.MinSegmentTime, args, "B")
+			applyParam(&settings.Advanced.MinFeedrate, args, "S")
+			applyParam(&settings.Advanced.MinTravelFeedrate, args, "T")
+			applyAxes(&settings.Advanced.Jerk, args)
+			applyParam(&settings.Advanced.JunctionDeviation, args, "J")
+		case "M206":
+			for i, axis := range "XYZ" {
+				applyParam(&settings.HomeOffset[i], args, string(axis))
+			}
+		case "M200":
+			if settings.Filament == nil {
+				settings.Filament = &FilamentSettings{}
+			}
+			if diameter, exists := args["D"]; exists {
+				if diameter > 0 {
+					settings.Filament.Diameter = diameter
+				}
+				settings.Filament.Volumetric = diameter > 0
+			}
+			if enabled, exists := args["S"]; exists {
+				settings.Filament.Volumetric = enabled != 0
+			}
+		case "M301":
+			settings.HotendPid = &PidSettings{P: args["P"], I: args["I"], D: args["D"]}
+		case "M304":
+			settings.BedPid = &PidSettings{P: args["P"], I: args["I"], D: args["D"]}
+		case "M851":
+			offset := [3]float64{}
+			for i, axis := range "XYZ" {
+				applyParam(&offset[i], args, string(axis))
+			}
+			settings.ProbeOffset = &offset
+		case "M900":
+			if k, exists := args["K"]; exists {
+				settings.LinearAdvance = &k
+			}
+		case "M906":
+			applyStepperValues(settings.StepperCurrents, args)
+		case "M913":
+			applyStepperValues(settings.HybridThreshold, args)
+		case "M420":
+			settings.Leveling = &LevelingSettings{Enabled: args["S"] != 0, FadeHeight: args["Z"]}
+		}
 	}
 
-	accelX, _ := strconv.ParseFloat(accel[1], 32)
-	accelY, _ := strconv.ParseFloat(accel[2], 32)
-	accelZ, _ := strconv.ParseFloat(accel[3], 32)
-	limits.MaxAccel = [3]float32{float32(accelX), float32(accelY), float32(accelZ)}
+	if !seen["M201"] || !seen["M203"] {
+		return settings, errors.New("invalid response")
+	}
+	return settings, nil
+}
+
+func parseSettingsParams(params string) map[string]float64 {
+	args := make(map[string]float64)
+	for _, match := range settingsParamRegex.FindAllStringSubmatch(params, -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err == nil {
+			args[match[1]] = value
+		}
+	}
+	return args
+}
 
-	feedrate := feedrateRegex.FindStringSubmatch(response)
-	if feedrate == nil {
-		return limits, errors.New("invalid response")
+func applyParam(value *float64, args map[string]float64, name string) {
+	if arg, exists := args[name]; exists {
+		*value = arg
 	}
+}
 
-	feedrateX, _ := strconv.ParseFloat(feedrate[1], 32)
-	feedrateY, _ := strconv.ParseFloat(feedrate[2], 32)
-	feedrateZ, _ := strconv.ParseFloat(feedrate[3], 32)
-	limits.MaxFeedrate = [3]float32{float32(feedrateX), float32(feedrateY), float32(feedrateZ)}
+func applyAxes(values *[4]float64, args map[string]float64) {
+	for i, axis := range "XYZE" {
+		applyParam(&values[i], args, string(axis))
+	}
+}
 
-	return limits, nil
+func applyStepperValues(values map[string]float64, args map[string]float64) {
+	extruder, hasExtruder := args["T"]
+	for _, axis := range "XYZE" {
+		value, exists := args[string(axis)]
+		if !exists {
+			continue
+		}
+		name := string(axis)
+		if axis == 'E' && hasExtruder && extruder > 0 {
+			name += strconv.Itoa(int(extruder))
+		}
+		values[name] = value
+	}
 }
@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	m73Regex = regexp.MustCompile(` ([PR])([0-9.]+)`)
+)
+
+func ParseM73(request string) (map[string]float64, error) {
+	values := make(map[string]float64)
+	for _, match := range m73Regex.FindAllStringSubmatch(request, -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return values, err
+		}
+		values[match[1]] = value
+	}
+	return values, nil
+}
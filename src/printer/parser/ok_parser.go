@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+)
+
+type AdvancedOk struct {
+	PlannerFree int
+	BufferFree  int
+}
+
+var (
+	advancedOkRegex = regexp.MustCompile(`^ok(?:\s+N[0-9]+)?\s+P([0-9]+)\s+B([0-9]+)`)
+)
+
+func ParseAdvancedOk(line string) (AdvancedOk, bool) {
+	match := advancedOkRegex.FindStringSubmatch(line)
+	if match == nil {
+		return AdvancedOk{}, false
+	}
+	planner, err := strconv.Atoi(match[1])
+	if err != nil {
+		return AdvancedOk{}, false
+	}
+	buffer, err := strconv.Atoi(match[2])
+	if err != nil {
+		return AdvancedOk{}, false
+	}
+	return AdvancedOk{PlannerFree: planner, BufferFree: buffer}, true
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/afero"
 	"gotest.tools/assert"
 	"marlinraker/src/files"
+	"math"
 	"strconv"
 	"strings"
 	"testing"
@@ -50,13 +51,61 @@ func TestParseM115(t *testing.T) {
 
 func TestParseM503(t *testing.T) {
 	response := readContent(t, "testdata/m503")
-	limits, err := ParseM503(response)
+	settings, err := ParseM503(response)
 	assert.NilError(t, err)
 
-	assert.DeepEqual(t, limits, PrinterLimits{
-		MaxAccel:    [3]float32{1250.00, 1250.00, 400.00},
-		MaxFeedrate: [3]float32{180.00, 180.00, 12.00},
+	linearAdvance := 0.
+	assert.DeepEqual(t, settings, FirmwareSettings{
+		StepsPerUnit: [4]float64{100, 100, 400, 325},
+		MaxFeedrate:  [4]float64{180, 180, 12, 80},
+		MaxAccel:     [4]float64{1250, 1250, 400, 4000},
+		Acceleration: AccelerationSettings{Print: 1250, Retract: 1250, Travel: 250},
+		Advanced: AdvancedSettings{
+			MinSegmentTime: 20000,
+			Jerk:           [4]float64{8, 8, 2, 10},
+		},
+		HomeOffset:      [3]float64{0, 0, 0},
+		Filament:        &FilamentSettings{Volumetric: false, Diameter: 1.75},
+		HotendPid:       &PidSettings{P: 7, I: 0.5, D: 45},
+		BedPid:          &PidSettings{P: 120, I: 1.5, D: 600},
+		ProbeOffset:     &[3]float64{-29, -3, -1.6},
+		LinearAdvance:   &linearAdvance,
+		StepperCurrents: map[string]float64{"X": 350, "Y": 350, "Z": 350, "E": 400},
+		HybridThreshold: map[string]float64{},
+		Leveling:        &LevelingSettings{Enabled: false, FadeHeight: 0},
 	})
+
+	_, err = ParseM503("echo:  M92 X80.00 Y80.00 Z400.00 E93.00")
+	assert.Error(t, err, "invalid response")
+}
+
+func TestParseM303(t *testing.T) {
+	response := readContent(t, "testdata/m303")
+	pid, err := ParseM303(response)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pid, PidSettings{P: 18.71, I: 1.85, D: 47.29})
+
+	cycles := 0
+	for _, line := range strings.Split(response, "\n") {
+		if _, ok := ParseM303Cycle(line); ok {
+			cycles++
+		}
+	}
+	assert.Equal(t, cycles, 3)
+
+	cycle, ok := ParseM303Cycle(" bias: 89 d: 89 min: 196.62 max: 203.79 Ku: 31.61 Tu: 20.40")
+	assert.Equal(t, ok, true)
+	assert.DeepEqual(t, cycle, PidAutotuneCycle{Min: 196.62, Max: 203.79})
+
+	pid, err = ParseM303(" Kp: 18.97 Ki: 1.86 Kd: 48.37\nok")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pid, PidSettings{P: 18.97, I: 1.86, D: 48.37})
+
+	_, err = ParseM303("PID Autotune start\nPID Autotune failed! Temperature too high\nok")
+	assert.Error(t, err, "PID autotune failed: temperature too high")
+
+	_, err = ParseM303("ok")
+	assert.Error(t, err, "no PID autotune result")
 }
 
 func TestParseM105(t *testing.T) {
@@ -134,6 +183,16 @@ func TestParseG0G1G92(t *testing.T) {
 	})
 }
 
+func TestParseM73(t *testing.T) {
+	values, err := ParseM73("M73 P42 R17")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, map[string]float64{"P": 42, "R": 17})
+
+	values, err = ParseM73("M73 Q40 S20")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, map[string]float64{})
+}
+
 func TestParseM114(t *testing.T) {
 	responseLines := readContent(t, "testdata/m114")
 	expected := [][4]float64{
@@ -152,3 +211,83 @@ func TestParseAction(t *testing.T) {
 	action := ParseAction("// action:pause")
 	assert.Equal(t, action, "pause")
 }
+
+func TestParseHalt(t *testing.T) {
+	message, halted := ParseHalt("Error:Printer halted. kill() called!")
+	assert.Equal(t, halted, true)
+	assert.Equal(t, message, "Printer halted. kill() called!")
+
+	_, halted = ParseHalt("Error:checksum mismatch, Last Line: 12")
+	assert.Equal(t, halted, false)
+}
+
+func TestParseAdvancedOk(t *testing.T) {
+	ok, isAdvanced := ParseAdvancedOk("ok N12 P15 B3")
+	assert.Equal(t, isAdvanced, true)
+	assert.DeepEqual(t, ok, AdvancedOk{PlannerFree: 15, BufferFree: 3})
+
+	ok, isAdvanced = ParseAdvancedOk("ok P7 B0")
+	assert.Equal(t, isAdvanced, true)
+	assert.DeepEqual(t, ok, AdvancedOk{PlannerFree: 7, BufferFree: 0})
+
+	_, isAdvanced = ParseAdvancedOk("ok T:21.3 /0.0 B:22.1 /0.0")
+	assert.Equal(t, isAdvanced, false)
+}
+
+func TestParseM106M107(t *testing.T) {
+	for gcode, expected := range map[string][2]float64{
+		"M106":          {0, 1},
+		"M106 S127.5":   {0, 0.5},
+		"M106 P1 S51":   {1, 0.2},
+		"M106 P2 S1000": {2, 1},
+		"M107":          {0, 0},
+		"M107 P3":       {3, 0},
+	} {
+		index, speed, err := ParseM106M107(gcode)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, [2]float64{float64(index), speed}, expected)
+	}
+}
+
+func TestParseM123(t *testing.T) {
+	assert.Assert(t, !IsM123Report("T:200.00 /200.00 B:60.00 /60.00"))
+	assert.Assert(t, IsM123Report("E0:4200 RPM P0:0 RPM"))
+	assert.DeepEqual(t, ParseM123("E0:4200 RPM P0:0 RPM P1: 1500 RPM C:900 RPM"), map[string]int{
+		"E0": 4200, "P0": 0, "P1": 1500, "C": 900,
+	})
+}
+
+func TestParseM420(t *testing.T) {
+	round := func(matrix [][]float64) [][]float64 {
+		for _, row := range matrix {
+			for i, value := range row {
+				row[i] = math.Round(value*1e5) / 1e5
+			}
+		}
+		return matrix
+	}
+
+	report, err := ParseM420(readContent(t, "testdata/m420_bilinear"))
+	assert.NilError(t, err)
+	assert.Equal(t, report.Kind, "bilinear")
+	assert.Equal(t, report.HasBounds, false)
+	assert.DeepEqual(t, round(report.Matrix), [][]float64{
+		{0.12, 0.05, -0.03},
+		{0.08, 0.01, -0.06},
+		{0.04, 0.01375, -0.1},
+	})
+
+	report, err = ParseM420(readContent(t, "testdata/m420_ubl"))
+	assert.NilError(t, err)
+	assert.Equal(t, report.Kind, "ubl")
+	assert.Equal(t, report.MeshMin, [2]float64{0, 0})
+	assert.Equal(t, report.MeshMax, [2]float64{220, 220})
+	assert.DeepEqual(t, round(report.Matrix), [][]float64{
+		{0.01, -0.01, -0.03},
+		{0.02, 0, -0.01},
+		{0.03, 0.01, 0.0025},
+	})
+
+	_, err = ParseM420("echo:Bed Leveling OFF\nok")
+	assert.Error(t, err, "no mesh data")
+}
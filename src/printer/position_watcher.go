@@ -3,7 +3,6 @@ package printer
 import (
 	log "github.com/sirupsen/logrus"
 	"marlinraker/src/printer/parser"
-	"marlinraker/src/printer_objects"
 	"math"
 	"strings"
 	"time"
@@ -11,7 +10,6 @@ import (
 
 type positionWatcher struct {
 	printer        *Printer
-	ticker         *time.Ticker
 	closeCh        chan struct{}
 	last           time.Time
 	autoReport     bool
@@ -29,7 +27,7 @@ func newPositionWatcher(printer *Printer) *positionWatcher {
 	}
 
 	if watcher.autoReport && !printer.IsPrusa {
-		<-printer.context.QueueGcode("M154 S1", true)
+		<-printer.context.Load().QueueGcode("M154 S1", true)
 	} else if !watcher.autoReport {
 		go watcher.runTimer()
 	}
@@ -51,37 +49,35 @@ func (watcher *positionWatcher) readPos(line string) {
 		return
 	}
 
+	state := watcher.printer.GcodeState
+	state.mu.Lock()
 	if watcher.reportVelocity {
 		now := time.Now()
 		if !watcher.last.IsZero() {
 			dt := now.Sub(watcher.last).Seconds()
 			if dt < 0.5 {
-				oldPos := watcher.printer.GcodeState.Position
+				oldPos := state.Position
 				dxy := math.Hypot(position[0]-oldPos[0], position[1]-oldPos[1])
-				watcher.printer.GcodeState.Velocity = dxy / dt
+				state.Velocity = dxy / dt
 				de := position[3] - oldPos[3]
-				watcher.printer.GcodeState.EVelocity = de / dt
+				state.EVelocity = de / dt
 			} else {
-				watcher.printer.GcodeState.Velocity = 0
-				watcher.printer.GcodeState.EVelocity = 0
+				state.Velocity = 0
+				state.EVelocity = 0
 			}
 		}
 		watcher.last = now
 	}
 
-	for i := 0; i < 4; i++ {
-		watcher.printer.GcodeState.Position[i] = position[i]
-	}
+	state.Position = position
+	state.mu.Unlock()
 
-	if err := printer_objects.EmitObject("toolhead", "motion_report", "gcode_move"); err != nil {
+	if err := watcher.printer.Objects.EmitObject("toolhead", "motion_report", "gcode_move"); err != nil {
 		log.Errorf("Failed to emit objects: %v", err)
 	}
 }
 
 func (watcher *positionWatcher) stop() {
-	if watcher.ticker != nil {
-		watcher.ticker.Stop()
-	}
 	close(watcher.closeCh)
 }
 
@@ -92,12 +88,13 @@ func (watcher *positionWatcher) runTimer() {
 		duration = 200 * time.Millisecond
 	}
 
-	watcher.ticker = time.NewTicker(duration)
+	ticker := time.NewTicker(duration)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-watcher.closeCh:
-			break
-		case <-watcher.ticker.C:
+			return
+		case <-ticker.C:
 			watcher.tick()
 		}
 	}
@@ -108,6 +105,6 @@ func (watcher *positionWatcher) tick() {
 		// prevent stuttering while printing
 		return
 	}
-	response := <-watcher.printer.context.QueueGcode("M114 R", true)
+	response := <-watcher.printer.context.Load().QueueGcode("M114 R", true)
 	watcher.readPos(response)
 }
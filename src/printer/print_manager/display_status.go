@@ -0,0 +1,129 @@
+package print_manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/printer_objects"
+	"math"
+	"time"
+)
+
+type displayStatus struct {
+	message           string
+	progress          float64
+	remaining         float64
+	reportedPrintTime float64
+}
+
+func newDisplayStatus() displayStatus {
+	return displayStatus{progress: -1, remaining: -1}
+}
+
+func (manager *PrintManager) SetMessage(message string) {
+	manager.displayStatus.Do(func(status displayStatus) displayStatus {
+		status.message = message
+		return status
+	})
+	manager.emitDisplayStatus()
+}
+
+func (manager *PrintManager) ReportProgress(values map[string]float64) {
+	var printTime float64
+	if job := manager.currentJob.Load(); job != nil {
+		printTime = job.getPrintTime().Seconds()
+	}
+	manager.displayStatus.Do(func(status displayStatus) displayStatus {
+		if progress, exists := values["P"]; exists {
+			status.progress = min(max(progress/100, 0), 1)
+		}
+		if remaining, exists := values["R"]; exists {
+			status.remaining, status.reportedPrintTime = remaining*60, printTime
+		}
+		return status
+	})
+	manager.emitDisplayStatus()
+}
+
+func (manager *PrintManager) resetProgressReport() {
+	manager.displayStatus.Do(func(status displayStatus) displayStatus {
+		status.progress, status.remaining, status.reportedPrintTime = -1, -1, 0
+		return status
+	})
+}
+
+func (manager *PrintManager) getProgress() float64 {
+	if status := manager.displayStatus.Load(); status.progress >= 0 {
+		return status.progress
+	}
+	if job := manager.currentJob.Load(); job != nil {
+		return job.progress.Load()
+	}
+	return 0
+}
+
+func (manager *PrintManager) GetRemainingTime() float64 {
+	job, state := manager.currentJob.Load(), manager.state.Load()
+	if !manager.isPrinting(job, state) {
+		return 0
+	}
+	slicerRemaining := -1.
+	if status := manager.displayStatus.Load(); status.remaining >= 0 {
+		elapsed := job.getPrintTime().Seconds() - status.reportedPrintTime
+		slicerRemaining = max(status.remaining-elapsed, 0)
+	}
+	return job.getRemainingTime(manager.getProgress(), slicerRemaining)
+}
+
+func (manager *PrintManager) emitDisplayStatus() {
+	if err := manager.printer.GetObjects().EmitObject("display_status"); err != nil {
+		log.Errorf("Failed to emit display_status: %v", err)
+	}
+}
+
+type displayStatusObject struct {
+	manager *PrintManager
+}
+
+func (object displayStatusObject) Query() (printer_objects.QueryResult, error) {
+	return printer_objects.QueryResult{
+		"progress": object.manager.getProgress(),
+		"message":  object.manager.displayStatus.Load().message,
+	}, nil
+}
+
+func (manager *PrintManager) sendProgress() {
+	if !manager.sendM73 || manager.state.Load() != "printing" {
+		return
+	}
+	now := time.Now()
+	if now.Sub(manager.lastM73.Load()) < manager.m73Interval {
+		return
+	}
+	manager.lastM73.Store(now)
+	progress := int(math.Round(manager.getProgress() * 100))
+	remaining := int(math.Ceil(manager.GetRemainingTime() / 60))
+	manager.queueM73(progress, remaining)
+}
+
+func (manager *PrintManager) queueM73(progress int, remaining int) {
+	line := fmt.Sprintf("M73 P%d R%d", progress, remaining)
+	manager.queuedM73.Do(func(queued map[string]int) map[string]int {
+		queued[line]++
+		return queued
+	})
+	_ = manager.printer.MainExecutorContext().QueueGcode(line, true)
+}
+
+func (manager *PrintManager) IsQueuedProgress(line string) bool {
+	isQueued := false
+	manager.queuedM73.Do(func(queued map[string]int) map[string]int {
+		if queued[line] > 0 {
+			isQueued = true
+			if queued[line]--; queued[line] == 0 {
+				delete(queued, line)
+			}
+		}
+		return queued
+	})
+	return isQueued
+}
@@ -0,0 +1,71 @@
+package print_manager
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"testing"
+	"time"
+)
+
+func TestDisplayStatus(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+
+	queryStatus := func() map[string]any {
+		result, err := printer.objects.Query("display_status")
+		assert.NilError(t, err)
+		return result
+	}
+
+	manager.SetMessage("Printing cube")
+	assert.DeepEqual(t, queryStatus(), map[string]any{"progress": 0., "message": "Printing cube"})
+
+	manager.ReportProgress(map[string]float64{"P": 25})
+	assert.DeepEqual(t, queryStatus(), map[string]any{"progress": 0.25, "message": "Printing cube"})
+	manager.ReportProgress(map[string]float64{"P": 150, "R": 10})
+	manager.SetMessage("")
+	assert.DeepEqual(t, queryStatus(), map[string]any{"progress": 1., "message": ""})
+	assert.Equal(t, manager.GetRemainingTime(), 0.)
+
+	manager.resetProgressReport()
+	assert.DeepEqual(t, queryStatus(), map[string]any{"progress": 0., "message": ""})
+
+	manager.queueM73(50, 3)
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{"M73 P50 R3"})
+	assert.Equal(t, manager.IsQueuedProgress("M73 P50 R3"), true)
+	assert.Equal(t, manager.IsQueuedProgress("M73 P50 R3"), false)
+	assert.Equal(t, manager.IsQueuedProgress("M73 P25"), false)
+}
+
+func TestRemainingTime(t *testing.T) {
+	job := newPrintJob(nil, "remaining.gcode")
+	job.isPaused.Store(true)
+	job.printDuration.Store(100 * time.Second)
+
+	assert.Equal(t, job.getRemainingTime(0.5, -1), 100.)
+	assert.Equal(t, job.getRemainingTime(0.5, 60), 80.)
+	job.estimatedTime = 300
+	assert.Equal(t, job.getRemainingTime(0.5, -1), 125.)
+	assert.Equal(t, job.getRemainingTime(0, -1), 300.)
+
+	job.printDuration.Store(0)
+	assert.Equal(t, job.getRemainingTime(0.5, 60), 60.)
+}
+
+func TestDisplayStatusPrint(t *testing.T) {
+	manager, printer := setupPrintManager(t, func(cfg *config.Config) {
+		cfg.Printer.Gcode.SendM73 = true
+	})
+
+	gcode := ";TIME:600\nG28\nM73 P0 R10\nG1 X10 Y10\nM73 P50 R5\nG1 X20 Y20\n"
+	printFile(t, manager, "status.gcode", gcode)
+	waitFor(t, func() bool {
+		return len(printer.context.Gcodes()) == 5
+	})
+
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{"G28", "G1 X10 Y10", "G1 X20 Y20", "M400", "M73 P100 R0"})
+	assert.Equal(t, manager.IsQueuedProgress("M73 P100 R0"), true)
+	status := manager.displayStatus.Load()
+	assert.Equal(t, status.progress, 0.5)
+	assert.Equal(t, status.remaining, 300.)
+	assert.Equal(t, manager.GetRemainingTime(), 0.)
+}
@@ -0,0 +1,131 @@
+package print_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"marlinraker/src/files"
+	"marlinraker/src/printer_objects"
+	"sync"
+)
+
+type excludeObjectState struct {
+	mu       *sync.RWMutex
+	objects  []files.ObjectDefinition
+	excluded []string
+	current  string
+}
+
+func newExcludeObjectState() *excludeObjectState {
+	return &excludeObjectState{
+		mu:       &sync.RWMutex{},
+		objects:  []files.ObjectDefinition{},
+		excluded: []string{},
+	}
+}
+
+func (state *excludeObjectState) load(objects []files.ObjectDefinition) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.objects = append([]files.ObjectDefinition{}, objects...)
+	state.excluded = []string{}
+	state.current = ""
+}
+
+func (state *excludeObjectState) handleLabel(kind files.ObjectLabelKind, definition files.ObjectDefinition) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	switch kind {
+	case files.ObjectDefine:
+		state.objects = files.AddObjectDefinition(state.objects, definition)
+	case files.ObjectStart:
+		state.objects = files.AddObjectDefinition(state.objects, files.ObjectDefinition{Name: definition.Name})
+		state.current = definition.Name
+	case files.ObjectEnd:
+		state.current = ""
+	}
+}
+
+func (state *excludeObjectState) isSkipping() bool {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.current != "" && lo.Contains(state.excluded, state.current)
+}
+
+func (state *excludeObjectState) exclude(name string) (string, error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if name == "" {
+		if state.current == "" {
+			return "", errors.New("there is no current object to exclude")
+		}
+		name = state.current
+	}
+	if len(state.objects) > 0 && !lo.ContainsBy(state.objects, func(object files.ObjectDefinition) bool {
+		return object.Name == name
+	}) {
+		return "", fmt.Errorf("unknown object %q", name)
+	}
+	if !lo.Contains(state.excluded, name) {
+		state.excluded = append(state.excluded, name)
+	}
+	return name, nil
+}
+
+func (state *excludeObjectState) reset(name string) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if name == "" {
+		state.excluded = []string{}
+	} else {
+		state.excluded = lo.Without(state.excluded, name)
+	}
+}
+
+func (state *excludeObjectState) getExcluded() []string {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return append([]string{}, state.excluded...)
+}
+
+type excludeObjectObject struct {
+	manager *PrintManager
+}
+
+func (object excludeObjectObject) Query() (printer_objects.QueryResult, error) {
+
+	var (
+		objects  = []map[string]any{}
+		excluded = []string{}
+		current  any
+	)
+
+	if job := object.manager.currentJob.Load(); job != nil {
+		state := job.excludeObject
+		state.mu.RLock()
+		for _, definition := range state.objects {
+			object := map[string]any{"name": definition.Name}
+			if definition.Center != nil {
+				object["center"] = definition.Center
+			}
+			if definition.Polygon != nil {
+				object["polygon"] = definition.Polygon
+			}
+			objects = append(objects, object)
+		}
+		excluded = append(excluded, state.excluded...)
+		if state.current != "" {
+			current = state.current
+		}
+		state.mu.RUnlock()
+	}
+
+	return printer_objects.QueryResult{
+		"objects":          objects,
+		"excluded_objects": excluded,
+		"current_object":   current,
+	}, nil
+}
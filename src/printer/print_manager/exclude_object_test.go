@@ -0,0 +1,80 @@
+package print_manager
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/files"
+	"testing"
+)
+
+func TestExcludeObjectState(t *testing.T) {
+	state := newExcludeObjectState()
+	state.load([]files.ObjectDefinition{{Name: "A", Center: []float64{10, 10}}})
+
+	_, err := state.exclude("")
+	assert.Error(t, err, "there is no current object to exclude")
+	_, err = state.exclude("C")
+	assert.Error(t, err, `unknown object "C"`)
+
+	state.handleLabel(files.ObjectDefine, files.ObjectDefinition{Name: "B", Center: []float64{50, 50}})
+	state.handleLabel(files.ObjectStart, files.ObjectDefinition{Name: "B"})
+	assert.Equal(t, state.isSkipping(), false)
+	name, err := state.exclude("")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "B")
+	assert.Equal(t, state.isSkipping(), true)
+
+	state.handleLabel(files.ObjectEnd, files.ObjectDefinition{Name: "B"})
+	assert.Equal(t, state.isSkipping(), false)
+	state.handleLabel(files.ObjectStart, files.ObjectDefinition{Name: "A"})
+	assert.Equal(t, state.isSkipping(), false)
+
+	_, err = state.exclude("B")
+	assert.NilError(t, err)
+	_, err = state.exclude("A")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, state.getExcluded(), []string{"B", "A"})
+	assert.Equal(t, state.isSkipping(), true)
+
+	state.reset("A")
+	assert.DeepEqual(t, state.getExcluded(), []string{"B"})
+	state.reset("")
+	assert.DeepEqual(t, state.getExcluded(), []string{})
+
+	state.load(nil)
+	name, err = state.exclude("anything")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "anything")
+}
+
+func TestExcludeObjectPrint(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+
+	assert.Error(t, manager.ExcludeObject("A"), "no file selected")
+	assert.DeepEqual(t, manager.ExcludedObjects(), []string{})
+
+	gcode := "EXCLUDE_OBJECT_DEFINE NAME=a CENTER=10,10\nEXCLUDE_OBJECT_DEFINE NAME=b CENTER=50,50\n" +
+		"EXCLUDE_OBJECT_START NAME=a\nG1 X10 Y10 E1\nEXCLUDE_OBJECT_END NAME=a\n" +
+		"EXCLUDE_OBJECT_START NAME=b\nG1 X50 Y50 F3000\nM106 S128\nG1 X55 Y50 E2\nEXCLUDE_OBJECT_END NAME=b\n" +
+		"G1 X0 Y0\n"
+	writeGcodeFile(t, "objects.gcode", gcode)
+	assert.NilError(t, manager.SelectFile("objects.gcode"))
+	assert.Error(t, manager.ExcludeObject("C"), `unknown object "C"`)
+	assert.NilError(t, manager.ExcludeObject("B"))
+	assert.DeepEqual(t, printer.responses, []string{"// Excluding object B"})
+	runPrint(t, manager)
+
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{"G1 X10 Y10 E1", "M106 S128", "G0 RESUME\nG1 X0 Y0", "M400"})
+	assert.DeepEqual(t, printer.state.skipped, []string{"G1 X50 Y50 F3000", "G1 X55 Y50 E2"})
+
+	result, err := printer.objects.Query("exclude_object")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result["objects"], []map[string]any{
+		{"name": "A", "center": []float64{10, 10}},
+		{"name": "B", "center": []float64{50, 50}},
+	})
+	assert.DeepEqual(t, result["excluded_objects"], []string{"B"})
+	assert.Equal(t, result["current_object"], nil)
+
+	assert.NilError(t, manager.ResetExcludedObjects(""))
+	assert.DeepEqual(t, manager.ExcludedObjects(), []string{})
+}
@@ -6,6 +6,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"io"
 	"marlinraker/src/files"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/marlinraker/job_queue"
 	"marlinraker/src/printer/parser"
 	"marlinraker/src/shared"
 	"marlinraker/src/util"
@@ -20,6 +22,7 @@ type printJob struct {
 	manager        *PrintManager
 	fileName       string
 	filePath       string
+	historyId      string
 	pauseCh        chan struct{}
 	cancelCh       chan struct{}
 	isPaused       atomic.Bool
@@ -28,12 +31,18 @@ type printJob struct {
 	reader         *bufio.Reader
 	position       atomic.Int64
 	fileSize       int64
+	gcodeStartByte int64
+	gcodeEndByte   int64
+	estimatedTime  float64
 	progress       util.ThreadSafe[float64]
 	startTime      util.ThreadSafe[time.Time]
 	lastResumeTime util.ThreadSafe[time.Time]
 	endTime        util.ThreadSafe[time.Time]
 	printDuration  util.ThreadSafe[time.Duration]
 	ePosStart      util.ThreadSafe[float64]
+	excludeObject  *excludeObjectState
+	layerCount     int
+	info           util.ThreadSafe[printStatsInfo]
 }
 
 func newPrintJob(manager *PrintManager, fileName string) *printJob {
@@ -51,12 +60,14 @@ func newPrintJob(manager *PrintManager, fileName string) *printJob {
 		endTime:        util.NewThreadSafe(time.Time{}),
 		printDuration:  util.NewThreadSafe[time.Duration](0),
 		ePosStart:      util.NewThreadSafe(0.),
+		excludeObject:  newExcludeObjectState(),
+		info:           util.NewThreadSafe(printStatsInfo{}),
 	}
 	close(job.pauseCh)
 	return job
 }
 
-func (job *printJob) start(context shared.ExecutorContext) error {
+func (job *printJob) start(offset int64) error {
 
 	stat, err := files.Fs.Stat(job.filePath)
 	if err != nil {
@@ -68,14 +79,27 @@ func (job *printJob) start(context shared.ExecutorContext) error {
 		return err
 	}
 
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+
 	now := time.Now()
 	job.startTime.Store(now)
 	job.lastResumeTime.Store(now)
 	job.isStarted.Store(true)
 	job.fileSize = stat.Size()
 	job.printDuration.Store(0)
-	job.position.Store(0)
-	job.progress.Store(0)
+	job.position.Store(offset)
+	job.progress.Store(job.fileProgress(offset))
+	job.ePosStart.Store(job.manager.printer.GetGcodeState().ExtrudedFilament())
+	job.info.Store(printStatsInfo{totalLayer: job.layerCount})
+
+	if job.historyId, err = history.AddJob(job.fileName); err != nil {
+		log.Errorf("Failed to add job to history: %v", err)
+	}
 
 	reader := bufio.NewReader(file)
 
@@ -97,9 +121,9 @@ func (job *printJob) start(context shared.ExecutorContext) error {
 
 			read, line := int64(len(bytes)), strings.TrimRight(string(bytes), "\r\n")
 			position := job.position.Add(read)
-			job.progress.Store(float64(position) / float64(job.fileSize))
+			job.progress.Store(job.fileProgress(position))
 
-			if canceled, err := job.nextLine(line); err != nil || canceled {
+			if canceled, err := job.nextLine(line, position-read); err != nil || canceled {
 				if err != nil {
 					log.Errorf("Failed to process line %q: %v", line, err)
 				}
@@ -107,16 +131,44 @@ func (job *printJob) start(context shared.ExecutorContext) error {
 			}
 		}
 
-		job.finish("complete", context)
+		job.finish("complete", job.manager.printer.MainExecutorContext())
 	}()
 
 	return nil
 }
 
-func (job *printJob) nextLine(line string) (bool, error) {
+func (job *printJob) nextLine(line string, offset int64) (bool, error) {
+
+	kind, definition, err := files.ParseObjectLabel(line)
+	if err != nil {
+		log.Errorf("Failed to parse object label: %v", err)
+	}
+	if kind != files.NoObjectLabel {
+		job.excludeObject.handleLabel(kind, definition)
+		job.manager.emitExcludeObject()
+	}
+
+	switch kind, value := files.ParseLayerComment(line); kind {
+	case files.LayerChange:
+		job.nextLayer(layerSourceComment)
+	case files.LayerNumber:
+		job.setCurrentLayer(layerSourceComment, value)
+	case files.LayerCount:
+		job.setTotalLayer(layerSourceComment, value)
+	}
 
 	gcode := parser.CleanGcode(line)
-	if gcode == "" {
+	if gcode == "" || kind != files.NoObjectLabel {
+		return false, nil
+	}
+
+	if job.manager.sendM73 && parser.M73.MatchString(gcode) {
+		values, err := parser.ParseM73(gcode)
+		if err != nil {
+			log.Errorf("Failed to parse progress: %v", err)
+		} else {
+			job.manager.ReportProgress(values)
+		}
 		return false, nil
 	}
 
@@ -133,7 +185,36 @@ func (job *printJob) nextLine(line string) (bool, error) {
 	case <-job.cancelCh:
 		return true, nil
 	}
+	job.manager.printer.UpdateCheckpoint(job.fileName, offset)
+
+	state := job.manager.printer.GetGcodeState()
+	isSkipping := job.excludeObject.isSkipping()
+	if isSkipping && parser.G0_G1_G2_G3.MatchString(gcode) {
+		if err := state.SkipMove(gcode); err != nil {
+			log.Errorf("Failed to skip move: %v", err)
+		}
+		return false, nil
+	}
+	isExtrusion := false
+	if parser.G0_G1.MatchString(gcode) {
+		values, err := parser.ParseG0G1G92(gcode)
+		if err == nil {
+			_, hasE := values["E"]
+			_, hasX := values["X"]
+			_, hasY := values["Y"]
+			isExtrusion = hasE && (hasX || hasY)
+		}
+	}
+	if !isSkipping {
+		if resumeGcode := state.ResumeGcode(); resumeGcode != "" {
+			gcode = resumeGcode + gcode
+		}
+	}
+
 	<-context.QueueGcode(gcode, true)
+	if isExtrusion {
+		job.updateLayerZ(state.GetGcodePosition()[2])
+	}
 	return false, nil
 }
 
@@ -147,6 +228,7 @@ func (job *printJob) pause(context shared.ExecutorContext) bool {
 			return duration + now.Sub(job.lastResumeTime.Load())
 		})
 		job.manager.setState("paused")
+		job.updateHistory()
 		return true
 	}
 	return false
@@ -159,22 +241,29 @@ func (job *printJob) resume(context shared.ExecutorContext) bool {
 		close(job.pauseCh)
 		job.lastResumeTime.Store(time.Now())
 		job.manager.setState("printing")
+		job.updateHistory()
 		return true
 	}
 	return false
 }
 
 func (job *printJob) cancel(context shared.ExecutorContext) bool {
+	return job.stop("cancelled", context)
+}
+
+func (job *printJob) stop(state string, context shared.ExecutorContext) bool {
 	if job.hasEnded.Load() || !job.isStarted.Load() {
 		return false
 	}
 	close(job.cancelCh)
-	job.finish("cancelled", context)
+	job.finish(state, context)
 	return true
 }
 
 func (job *printJob) finish(state string, context shared.ExecutorContext) {
-	job.waitForPrintMoves(context)
+	if context != nil {
+		job.waitForPrintMoves(context)
+	}
 	now := time.Now()
 	job.progress.Store(1)
 	job.hasEnded.Store(true)
@@ -185,6 +274,49 @@ func (job *printJob) finish(state string, context shared.ExecutorContext) {
 		})
 	}
 	job.manager.setState(state)
+	if state == "complete" && job.manager.sendM73 {
+		job.manager.queueM73(100, 0)
+	}
+	if state == "complete" || state == "cancelled" {
+		job.manager.printer.ClearCheckpoint()
+	}
+	job.finishHistory(state)
+	job_queue.JobFinished(job.manager.printer, state)
+}
+
+func (job *printJob) historyProgress() history.Progress {
+	return history.Progress{
+		TotalDuration: job.getTotalTime().Seconds(),
+		PrintDuration: job.getPrintTime().Seconds(),
+		FilamentUsed:  job.getFilamentUsed(),
+	}
+}
+
+func (job *printJob) updateHistory() {
+	if job.historyId == "" {
+		return
+	}
+	if err := history.UpdateJob(job.historyId, job.historyProgress()); err != nil {
+		log.Errorf("Failed to update job in history: %v", err)
+	}
+}
+
+func (job *printJob) finishHistory(state string) {
+	if job.historyId == "" {
+		return
+	}
+	status := history.JobStatus(state)
+	switch state {
+	case "complete":
+		status = history.Completed
+	case "cancelled":
+		status = history.Cancelled
+	case "error":
+		status = history.Error
+	}
+	if err := history.FinishJob(job.historyId, status, job.historyProgress()); err != nil {
+		log.Errorf("Failed to finish job in history: %v", err)
+	}
 }
 
 func (job *printJob) waitForPrintMoves(context shared.ExecutorContext) {
@@ -209,6 +341,32 @@ func (job *printJob) getPrintTime() time.Duration {
 	return duration
 }
 
+func (job *printJob) fileProgress(position int64) float64 {
+	start, end := job.gcodeStartByte, job.gcodeEndByte
+	if end <= start || end > job.fileSize {
+		start, end = 0, job.fileSize
+	}
+	if end <= start {
+		return 0
+	}
+	return min(max(float64(position-start)/float64(end-start), 0), 1)
+}
+
+func (job *printJob) getRemainingTime(progress float64, slicerRemaining float64) float64 {
+	if slicerRemaining < 0 && job.estimatedTime > 0 {
+		slicerRemaining = job.estimatedTime * (1 - progress)
+	}
+	printTime := job.getPrintTime().Seconds()
+	if progress <= 0 || printTime <= 0 {
+		return max(slicerRemaining, 0)
+	}
+	observed := printTime/progress - printTime
+	if slicerRemaining < 0 {
+		return observed
+	}
+	return (1-progress)*slicerRemaining + progress*observed
+}
+
 func (job *printJob) getFilamentUsed() float64 {
 	extruded := job.manager.printer.GetGcodeState().ExtrudedFilament()
 	return extruded - job.ePosStart.Load()
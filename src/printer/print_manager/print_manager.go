@@ -3,8 +3,9 @@ package print_manager
 import (
 	"errors"
 	"fmt"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/config"
 	"marlinraker/src/files"
-	"marlinraker/src/printer_objects"
 	"marlinraker/src/shared"
 	"marlinraker/src/util"
 	"path/filepath"
@@ -14,27 +15,39 @@ import (
 )
 
 type PrintManager struct {
-	printer    shared.Printer
-	state      util.ThreadSafe[string]
-	currentJob atomic.Pointer[printJob]
-	ticker     *time.Ticker
-	closeCh    chan struct{}
+	printer       shared.Printer
+	state         util.ThreadSafe[string]
+	currentJob    atomic.Pointer[printJob]
+	displayStatus util.ThreadSafe[displayStatus]
+	sendM73       bool
+	m73Interval   time.Duration
+	lastM73       util.ThreadSafe[time.Time]
+	queuedM73     util.ThreadSafe[map[string]int]
+	ticker        *time.Ticker
+	closeCh       chan struct{}
 }
 
 var (
 	gcodeExtensionRegex = regexp.MustCompile(`(?i)\.gcode$`)
 )
 
-func NewPrintManager(printer shared.Printer) *PrintManager {
+func NewPrintManager(printer shared.Printer, config *config.Config) *PrintManager {
 	manager := &PrintManager{
-		printer: printer,
-		state:   util.NewThreadSafe("standby"),
-		ticker:  time.NewTicker(time.Second),
-		closeCh: make(chan struct{}),
-	}
-	printer_objects.RegisterObject("print_stats", printStatsObject{manager})
-	printer_objects.RegisterObject("virtual_sdcard", virtualSdcardObject{manager})
-	printer_objects.RegisterObject("pause_resume", pauseResumeObject{manager})
+		printer:       printer,
+		state:         util.NewThreadSafe("standby"),
+		displayStatus: util.NewThreadSafe(newDisplayStatus()),
+		sendM73:       config.Printer.Gcode.SendM73,
+		m73Interval:   time.Duration(config.Printer.Gcode.M73Interval * float64(time.Second)),
+		lastM73:       util.NewThreadSafe(time.Time{}),
+		queuedM73:     util.NewThreadSafe(make(map[string]int)),
+		ticker:        time.NewTicker(time.Second),
+		closeCh:       make(chan struct{}),
+	}
+	printer.GetObjects().RegisterObject("print_stats", printStatsObject{manager})
+	printer.GetObjects().RegisterObject("virtual_sdcard", virtualSdcardObject{manager})
+	printer.GetObjects().RegisterObject("pause_resume", pauseResumeObject{manager})
+	printer.GetObjects().RegisterObject("exclude_object", excludeObjectObject{manager})
+	printer.GetObjects().RegisterObject("display_status", displayStatusObject{manager})
 	go func() {
 		for {
 			select {
@@ -43,6 +56,7 @@ func NewPrintManager(printer shared.Printer) *PrintManager {
 			case <-manager.ticker.C:
 				if manager.isPrinting(manager.currentJob.Load(), manager.state.Load()) {
 					manager.emit()
+					manager.sendProgress()
 				}
 			}
 		}
@@ -52,16 +66,24 @@ func NewPrintManager(printer shared.Printer) *PrintManager {
 
 func (manager *PrintManager) Cleanup(context shared.ExecutorContext) {
 	if job := manager.currentJob.Load(); job != nil {
-		job.cancel(context)
+		job.stop("error", context)
 	}
-	printer_objects.UnregisterObject("print_stats")
-	printer_objects.UnregisterObject("virtual_sdcard")
-	printer_objects.UnregisterObject("pause_resume")
+	manager.printer.GetObjects().UnregisterObject("print_stats")
+	manager.printer.GetObjects().UnregisterObject("virtual_sdcard")
+	manager.printer.GetObjects().UnregisterObject("pause_resume")
+	manager.printer.GetObjects().UnregisterObject("exclude_object")
+	manager.printer.GetObjects().UnregisterObject("display_status")
 	manager.ticker.Stop()
 	manager.closeCh <- struct{}{}
 	close(manager.closeCh)
 }
 
+func (manager *PrintManager) Abort() {
+	if job := manager.currentJob.Load(); job != nil {
+		job.stop("error", nil)
+	}
+}
+
 func (manager *PrintManager) SelectFile(fileName string) error {
 	job, state := manager.currentJob.Load(), manager.state.Load()
 	if manager.isPrinting(job, state) {
@@ -74,17 +96,41 @@ func (manager *PrintManager) SelectFile(fileName string) error {
 	if _, err := files.Fs.Stat(diskPath); err != nil {
 		return err
 	}
-	manager.currentJob.Store(newPrintJob(manager, fileName))
+	job = newPrintJob(manager, fileName)
+	if metadata, err := files.LoadOrScanMetadata(fileName); err != nil {
+		log.Errorf("Failed to load metadata for %q: %v", fileName, err)
+	} else {
+		job.excludeObject.load(metadata.Objects)
+		job.layerCount = metadata.LayerCount
+		job.gcodeStartByte, job.gcodeEndByte = metadata.GcodeStartByte, metadata.GcodeEndByte
+		job.estimatedTime = metadata.EstimatedTime
+	}
+	manager.currentJob.Store(job)
+	manager.resetProgressReport()
 	manager.emit()
+	manager.emitExcludeObject()
 	return nil
 }
 
-func (manager *PrintManager) Start(context shared.ExecutorContext) error {
+func (manager *PrintManager) Start(_ shared.ExecutorContext) error {
+	return manager.start(0)
+}
+
+func (manager *PrintManager) Recover(_ shared.ExecutorContext, fileName string, offset int64) error {
+	if err := manager.SelectFile(fileName); err != nil {
+		return fmt.Errorf("failed to recover print: %w", err)
+	}
+	return manager.start(offset)
+}
+
+func (manager *PrintManager) start(offset int64) error {
 	job, state := manager.currentJob.Load(), manager.state.Load()
 	if !manager.isReadyToPrint(job, state) {
 		return errors.New("failed to start print: already printing")
 	}
-	if err := job.start(context); err != nil {
+	manager.resetProgressReport()
+	manager.lastM73.Store(time.Time{})
+	if err := job.start(offset); err != nil {
 		return fmt.Errorf("failed to start print: %w", err)
 	}
 	if err := manager.setState("printing"); err != nil {
@@ -137,12 +183,55 @@ func (manager *PrintManager) Reset(context shared.ExecutorContext) error {
 		}
 	}
 	manager.currentJob.Store(nil)
+	manager.emitExcludeObject()
 	if err := manager.setState("standby"); err != nil {
 		return fmt.Errorf("failed to reset print: %w", err)
 	}
 	return nil
 }
 
+func (manager *PrintManager) ExcludeObject(name string) error {
+	job := manager.currentJob.Load()
+	if job == nil {
+		return errors.New("no file selected")
+	}
+	name, err := job.excludeObject.exclude(name)
+	if err != nil {
+		return err
+	}
+	if err := manager.printer.Respond("// Excluding object " + name); err != nil {
+		log.Errorf("Failed to send response: %v", err)
+	}
+	manager.emitExcludeObject()
+	return nil
+}
+
+func (manager *PrintManager) ResetExcludedObjects(name string) error {
+	job := manager.currentJob.Load()
+	if job == nil {
+		return errors.New("no file selected")
+	}
+	job.excludeObject.reset(name)
+	manager.emitExcludeObject()
+	return nil
+}
+
+func (manager *PrintManager) ExcludedObjects() []string {
+	if job := manager.currentJob.Load(); job != nil {
+		return job.excludeObject.getExcluded()
+	}
+	return []string{}
+}
+
+func (manager *PrintManager) SetPrintStatsInfo(totalLayer *int, currentLayer *int) error {
+	job := manager.currentJob.Load()
+	if job == nil {
+		return errors.New("no file selected")
+	}
+	job.setPrintStatsInfo(totalLayer, currentLayer)
+	return manager.emit()
+}
+
 func (manager *PrintManager) GetState() string {
 	return manager.state.Load()
 }
@@ -189,12 +278,18 @@ func (manager *PrintManager) setState(state string) error {
 }
 
 func (manager *PrintManager) emit() error {
-	if err := printer_objects.EmitObject("print_stats", "virtual_sdcard"); err != nil {
+	if err := manager.printer.GetObjects().EmitObject("print_stats", "virtual_sdcard", "display_status"); err != nil {
 		return fmt.Errorf("failed to emit print stats: %w", err)
 	}
 	return nil
 }
 
+func (manager *PrintManager) emitExcludeObject() {
+	if err := manager.printer.GetObjects().EmitObject("exclude_object"); err != nil {
+		log.Errorf("Failed to emit exclude_object: %v", err)
+	}
+}
+
 func (manager *PrintManager) getFilamentUsed() float64 {
 	if job := manager.currentJob.Load(); job != nil {
 		return job.getFilamentUsed()
@@ -12,12 +12,14 @@ func (object printStatsObject) Query() (printer_objects.QueryResult, error) {
 		fileName      string
 		totalDuration float64
 		printDuration float64
+		info          printStatsInfo
 	)
 
 	if job := object.manager.currentJob.Load(); job != nil {
 		fileName = job.fileName
 		totalDuration = job.getTotalTime().Seconds()
 		printDuration = job.getPrintTime().Seconds()
+		info = job.info.Load()
 	}
 
 	return printer_objects.QueryResult{
@@ -27,6 +29,7 @@ func (object printStatsObject) Query() (printer_objects.QueryResult, error) {
 		"filament_used":  object.manager.getFilamentUsed(),
 		"state":          object.manager.state.Load(),
 		"message":        "",
+		"info":           info.query(),
 	}, nil
 }
 
@@ -0,0 +1,267 @@
+package print_manager
+
+import (
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/shared"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testGcodeState struct {
+	mu       *sync.Mutex
+	position [4]float64
+	skipped  []string
+	skipping bool
+}
+
+func (state *testGcodeState) ExtrudedFilament() float64 {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.position[3]
+}
+
+func (state *testGcodeState) GetGcodePosition() [4]float64 {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.position
+}
+
+func (state *testGcodeState) SkipMove(line string) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.skipped = append(state.skipped, line)
+	state.skipping = true
+	return nil
+}
+
+func (state *testGcodeState) ResumeGcode() string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.skipping {
+		return ""
+	}
+	state.skipping = false
+	return "G0 RESUME\n"
+}
+
+func (state *testGcodeState) apply(gcode string) {
+	if !parser.G0_G1.MatchString(gcode) {
+		return
+	}
+	values, err := parser.ParseG0G1G92(gcode)
+	if err != nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for i, axis := range []string{"X", "Y", "Z", "E"} {
+		if value, exists := values[axis]; exists {
+			state.position[i] = value
+		}
+	}
+}
+
+type testContext struct {
+	shared.ExecutorContext
+	mu      *sync.Mutex
+	state   *testGcodeState
+	gcodes  []string
+	pending chan struct{}
+}
+
+func (context *testContext) QueueGcode(gcode string, _ bool) chan string {
+	context.mu.Lock()
+	context.gcodes = append(context.gcodes, gcode)
+	context.mu.Unlock()
+	context.state.apply(gcode)
+	ch := make(chan string, 1)
+	ch <- "ok"
+	return ch
+}
+
+func (context *testContext) Pending() chan struct{} {
+	if context.pending != nil {
+		return context.pending
+	}
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+func (context *testContext) Gcodes() []string {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	return append([]string{}, context.gcodes...)
+}
+
+type testPrinter struct {
+	shared.Printer
+	mu        *sync.Mutex
+	objects   *printer_objects.Registry
+	state     *testGcodeState
+	context   *testContext
+	responses []string
+}
+
+func (printer *testPrinter) Name() string {
+	return "test"
+}
+
+func (printer *testPrinter) GetObjects() *printer_objects.Registry {
+	return printer.objects
+}
+
+func (printer *testPrinter) GetGcodeState() shared.GcodeState {
+	return printer.state
+}
+
+func (printer *testPrinter) MainExecutorContext() shared.ExecutorContext {
+	return printer.context
+}
+
+func (printer *testPrinter) Respond(message string) error {
+	printer.mu.Lock()
+	defer printer.mu.Unlock()
+	printer.responses = append(printer.responses, message)
+	return nil
+}
+
+func (printer *testPrinter) UpdateCheckpoint(string, int64) {}
+
+func (printer *testPrinter) ClearCheckpoint() {}
+
+var setupOnce sync.Once
+
+func setupPrintManager(t *testing.T, configure func(cfg *config.Config)) (*PrintManager, *testPrinter) {
+	setupOnce.Do(func() {
+		files.Fs = afero.NewMemMapFs()
+		notification.Testing = true
+	})
+	entries, err := afero.ReadDir(files.Fs, files.DataDir)
+	assert.NilError(t, err)
+	for _, entry := range entries {
+		assert.NilError(t, files.Fs.RemoveAll(filepath.Join(files.DataDir, entry.Name())))
+	}
+	assert.NilError(t, database.Init())
+	assert.NilError(t, history.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.Printer.Gcode.SendM73 = false
+	if configure != nil {
+		configure(cfg)
+	}
+	state := &testGcodeState{mu: &sync.Mutex{}}
+	printer := &testPrinter{
+		mu:      &sync.Mutex{},
+		objects: printer_objects.NewRegistry(""),
+		state:   state,
+		context: &testContext{mu: &sync.Mutex{}, state: state},
+	}
+	manager := NewPrintManager(printer, cfg)
+	t.Cleanup(func() {
+		manager.Cleanup(nil)
+	})
+	return manager, printer
+}
+
+func writeGcodeFile(t *testing.T, fileName string, gcode string) {
+	path := filepath.Join(files.DataDir, "gcodes", fileName)
+	assert.NilError(t, afero.WriteFile(files.Fs, path, []byte(gcode), 0644))
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func runPrint(t *testing.T, manager *PrintManager) {
+	assert.NilError(t, manager.Start(nil))
+	waitFor(t, func() bool {
+		return manager.GetState() == "complete"
+	})
+}
+
+func printFile(t *testing.T, manager *PrintManager, fileName string, gcode string) {
+	writeGcodeFile(t, fileName, gcode)
+	assert.NilError(t, manager.SelectFile(fileName))
+	runPrint(t, manager)
+}
+
+func TestPrintJob(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+
+	gcode := "G28\n\n; comment\nG1 X10 Y10 E1 ; move\nG1 X20 Y10 E2\n"
+	printFile(t, manager, "job.gcode", gcode)
+
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{"G28", "G1 X10 Y10 E1", "G1 X20 Y10 E2", "M400"})
+	assert.Equal(t, manager.IsPrinting(), false)
+	assert.Equal(t, manager.getFilamentUsed(), 2.)
+
+	result, err := printer.objects.Query("virtual_sdcard")
+	assert.NilError(t, err)
+	assert.Equal(t, result["progress"], 1.)
+	assert.Equal(t, result["file_position"], int64(len(gcode)))
+	assert.Equal(t, result["is_active"], false)
+
+	assert.Error(t, manager.Pause(printer.context), "failed to pause print: not currently printing")
+	assert.NilError(t, manager.Reset(printer.context))
+	assert.Equal(t, manager.GetState(), "standby")
+	assert.Error(t, manager.Reset(printer.context), "no file selected")
+}
+
+func TestSelectFile(t *testing.T) {
+	manager, _ := setupPrintManager(t, nil)
+
+	assert.Error(t, manager.SelectFile("cube.txt"), "invalid file extension")
+	assert.ErrorContains(t, manager.SelectFile("missing.gcode"), "file does not exist")
+	assert.Equal(t, manager.CanPrint("missing.gcode"), false)
+
+	writeGcodeFile(t, "cube.gcode", "G28\n")
+	assert.Equal(t, manager.CanPrint("cube.gcode"), true)
+	assert.NilError(t, manager.SelectFile("cube.gcode"))
+	assert.Equal(t, manager.GetState(), "standby")
+}
+
+func TestRecover(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+
+	assert.ErrorContains(t, manager.Recover(nil, "missing.gcode", 0), "failed to recover print: ")
+
+	writeGcodeFile(t, "recover.gcode", "G28\nG1 X10 Y10 E1\nG1 X20 Y10 E2\n")
+	assert.NilError(t, manager.Recover(nil, "recover.gcode", int64(len("G28\n"))))
+	waitFor(t, func() bool {
+		return manager.GetState() == "complete"
+	})
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{"G1 X10 Y10 E1", "G1 X20 Y10 E2", "M400"})
+}
+
+func TestAbort(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+	printer.context.pending = make(chan struct{})
+
+	writeGcodeFile(t, "abort.gcode", "G28\nG1 X10 Y10\n")
+	assert.NilError(t, manager.SelectFile("abort.gcode"))
+	assert.NilError(t, manager.Start(nil))
+	assert.Equal(t, manager.GetState(), "printing")
+
+	manager.Abort()
+	assert.Equal(t, manager.GetState(), "error")
+	assert.DeepEqual(t, printer.context.Gcodes(), []string{})
+	_, jobs := history.ListJobs(0, 0, 0, 0, false)
+	assert.Equal(t, jobs[0].Status, history.Error)
+}
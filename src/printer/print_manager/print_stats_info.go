@@ -0,0 +1,91 @@
+package print_manager
+
+type layerSource int
+
+const (
+	layerSourceNone layerSource = iota
+	layerSourceZ
+	layerSourceComment
+	layerSourceMacro
+)
+
+type printStatsInfo struct {
+	totalLayer   int
+	currentLayer int
+	source       layerSource
+	lastZ        float64
+}
+
+func (info printStatsInfo) withCurrentLayer(source layerSource, layer int) printStatsInfo {
+	if source < info.source {
+		return info
+	}
+	info.source = source
+	info.currentLayer = max(layer, 0)
+	if info.totalLayer > 0 {
+		info.currentLayer = min(info.currentLayer, info.totalLayer)
+	}
+	return info
+}
+
+func (job *printJob) nextLayer(source layerSource) {
+	job.info.Do(func(info printStatsInfo) printStatsInfo {
+		return info.withCurrentLayer(source, info.currentLayer+1)
+	})
+}
+
+func (job *printJob) setCurrentLayer(source layerSource, layer int) {
+	job.info.Do(func(info printStatsInfo) printStatsInfo {
+		return info.withCurrentLayer(source, layer)
+	})
+}
+
+func (job *printJob) setTotalLayer(source layerSource, total int) {
+	job.info.Do(func(info printStatsInfo) printStatsInfo {
+		if source >= info.source {
+			info.totalLayer = max(total, 0)
+		}
+		return info
+	})
+}
+
+func (job *printJob) updateLayerZ(z float64) {
+	job.info.Do(func(info printStatsInfo) printStatsInfo {
+		if info.source > layerSourceZ || z <= info.lastZ+1e-6 {
+			return info
+		}
+		info.lastZ = z
+		return info.withCurrentLayer(layerSourceZ, info.currentLayer+1)
+	})
+}
+
+func (job *printJob) setPrintStatsInfo(totalLayer *int, currentLayer *int) {
+	job.info.Do(func(info printStatsInfo) printStatsInfo {
+		info.source = layerSourceMacro
+		if totalLayer != nil {
+			if *totalLayer <= 0 {
+				info.totalLayer, info.currentLayer = 0, 0
+			} else if *totalLayer != info.totalLayer {
+				info.totalLayer, info.currentLayer = *totalLayer, 0
+			}
+		}
+		if currentLayer != nil {
+			info = info.withCurrentLayer(layerSourceMacro, *currentLayer)
+		}
+		return info
+	})
+}
+
+func (info printStatsInfo) query() map[string]any {
+	var totalLayer, currentLayer any
+	if info.totalLayer > 0 {
+		totalLayer = info.totalLayer
+	}
+	if info.source != layerSourceNone {
+		currentLayer = info.currentLayer
+	}
+	return map[string]any{
+		"total_layer":   totalLayer,
+		"current_layer": currentLayer,
+	}
+}
@@ -0,0 +1,72 @@
+package print_manager
+
+import (
+	"fmt"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func TestPrintStatsInfo(t *testing.T) {
+	job := newPrintJob(nil, "info.gcode")
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": nil, "current_layer": nil})
+
+	job.updateLayerZ(0.2)
+	job.updateLayerZ(0.2)
+	job.updateLayerZ(0.1)
+	job.updateLayerZ(0.4)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": nil, "current_layer": 2})
+
+	job.setTotalLayer(layerSourceComment, 5)
+	job.setCurrentLayer(layerSourceComment, 7)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": 5, "current_layer": 5})
+	job.updateLayerZ(0.6)
+	job.nextLayer(layerSourceZ)
+	job.setCurrentLayer(layerSourceComment, -1)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": 5, "current_layer": 0})
+
+	total, current := 10, 4
+	job.setPrintStatsInfo(&total, &current)
+	job.nextLayer(layerSourceComment)
+	job.setTotalLayer(layerSourceComment, 3)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": 10, "current_layer": 4})
+
+	total = 12
+	job.setPrintStatsInfo(&total, nil)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": 12, "current_layer": 0})
+	job.nextLayer(layerSourceMacro)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": 12, "current_layer": 1})
+
+	total = 0
+	job.setPrintStatsInfo(&total, nil)
+	assert.DeepEqual(t, job.info.Load().query(), map[string]any{"total_layer": nil, "current_layer": 0})
+}
+
+func TestPrintStatsInfoPrint(t *testing.T) {
+	manager, printer := setupPrintManager(t, nil)
+
+	queryInfo := func() any {
+		result, err := printer.objects.Query("print_stats")
+		assert.NilError(t, err)
+		return result["info"]
+	}
+
+	assert.Error(t, manager.SetPrintStatsInfo(nil, nil), "no file selected")
+
+	gcode := ";LAYER_COUNT:3\n"
+	for layer := 0; layer < 3; layer++ {
+		gcode += fmt.Sprintf(";LAYER:%d\nG1 Z%.1f\nG1 X10 Y10 E1\nG1 Z%.1f\n", layer, 0.2*float64(layer+1), 0.2*float64(layer+1)+0.4)
+	}
+	printFile(t, manager, "comments.gcode", gcode)
+	assert.DeepEqual(t, queryInfo(), map[string]any{"total_layer": 3, "current_layer": 3})
+
+	gcode = ""
+	for layer := 1; layer <= 4; layer++ {
+		gcode += fmt.Sprintf("G1 Z%.1f\nG1 X10 Y10 E1\nG1 X20 Y10 E2\nG1 Z%.1f\n", 0.2*float64(layer), 0.2*float64(layer)+0.4)
+	}
+	printFile(t, manager, "moves.gcode", gcode)
+	assert.DeepEqual(t, queryInfo(), map[string]any{"total_layer": nil, "current_layer": 4})
+
+	total, current := 10, 4
+	assert.NilError(t, manager.SetPrintStatsInfo(&total, &current))
+	assert.DeepEqual(t, queryInfo(), map[string]any{"total_layer": 10, "current_layer": 4})
+}
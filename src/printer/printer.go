@@ -6,16 +6,21 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"go.bug.st/serial"
+	"io"
 	"marlinraker/src/api/notification"
 	"marlinraker/src/config"
 	"marlinraker/src/marlinraker/gcode_store"
+	"marlinraker/src/marlinraker/temp_store"
 	"marlinraker/src/printer/macros"
 	"marlinraker/src/printer/parser"
 	"marlinraker/src/printer/print_manager"
+	"marlinraker/src/printer/virtual"
 	"marlinraker/src/printer_objects"
 	"marlinraker/src/shared"
 	"marlinraker/src/util"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,40 +30,61 @@ type watcher interface {
 }
 
 type Printer struct {
+	Objects            *printer_objects.Registry
+	TempStore          *temp_store.Store
 	Capabilities       map[string]bool
 	IsPrusa            bool
 	CloseCh            chan struct{}
 	PrintManager       *print_manager.PrintManager
 	MacroManager       *macros.MacroManager
 	GcodeState         *GcodeState
-	Error              error
+	name               string
 	config             *config.Config
-	context            *executorContext
+	context            util.ThreadSafe[*executorContext]
+	err                util.ThreadSafe[error]
 	path               string
-	port               serial.Port
+	port               io.ReadWriteCloser
+	protocol           *serialProtocol
 	info               parser.PrinterInfo
 	hasEmergencyParser bool
-	limits             parser.PrinterLimits
+	firmwareSettings   util.ThreadSafe[parser.FirmwareSettings]
 	watchers           util.ThreadSafe[[]watcher]
-	connected          bool
+	connected          atomic.Bool
+	halted             atomic.Bool
+	stopRequested      atomic.Bool
+	captureEcho        atomic.Bool
 	heaters            heatersObject
+	bedMesh            *bedMesh
 	savedGcodeStates   map[string]GcodeState
+	lastCheckpoint     time.Time
 }
 
-func New(config *config.Config, path string, baudRate int) (*Printer, error) {
+func New(name string, config *config.Config, objects *printer_objects.Registry, tempStore *temp_store.Store, path string, baudRate int) (*Printer, error) {
 
-	port, err := serial.Open(path, &serial.Mode{BaudRate: baudRate})
-	if err != nil {
-		return nil, fmt.Errorf("failed to open serial port %q: %w", path, err)
+	var port io.ReadWriteCloser
+	if path == virtual.PortName {
+		port = virtual.Open(config.VirtualPrinter)
+	} else {
+		serialPort, err := serial.Open(path, &serial.Mode{BaudRate: baudRate})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open serial port %q: %w", path, err)
+		}
+		port = serialPort
 	}
 
 	printer := &Printer{
-		config:    config,
-		path:      path,
-		port:      port,
-		watchers:  util.NewThreadSafe(make([]watcher, 0)),
-		CloseCh:   make(chan struct{}),
-		connected: false,
+		Objects:          objects,
+		TempStore:        tempStore,
+		name:             name,
+		config:           config,
+		path:             path,
+		port:             port,
+		protocol:         newSerialProtocol(port, path, config.Serial.Checksum),
+		watchers:         util.NewThreadSafe(make([]watcher, 0)),
+		firmwareSettings: util.NewThreadSafe(parser.FirmwareSettings{}),
+		CloseCh:          make(chan struct{}),
+		context:          util.NewThreadSafe[*executorContext](nil),
+		err:              util.NewThreadSafe[error](nil),
 		GcodeState: &GcodeState{
 			Position:             [4]float64{0, 0, 0, 0},
 			IsAbsoluteCoordinate: true,
@@ -66,28 +92,31 @@ func New(config *config.Config, path string, baudRate int) (*Printer, error) {
 			SpeedFactor:          100,
 			ExtrudeFactor:        100,
 			Feedrate:             0,
+			fanObjects:           fanObjectNames(config.Printer.Fans),
+			objects:              objects,
+			mu:                   &sync.RWMutex{},
 		},
 		savedGcodeStates: make(map[string]GcodeState),
 	}
-	printer.PrintManager = print_manager.NewPrintManager(printer)
+	printer.PrintManager = print_manager.NewPrintManager(printer, printer.config)
 	printer.MacroManager = macros.NewMacroManager(printer, printer.config)
 
 	go printer.readPort()
-	err = printer.tryToConnect()
-	if err != nil {
+	if err := printer.tryToConnect(); err != nil {
 		return nil, err
 	}
 
-	printer_objects.RegisterObject("toolhead", toolheadObject{printer})
-	printer_objects.RegisterObject("motion_report", motionReportObject{printer})
-	printer_objects.RegisterObject("gcode_move", gcodeMoveObject{printer})
+	printer.Objects.RegisterObject("toolhead", toolheadObject{printer})
+	printer.Objects.RegisterObject("motion_report", motionReportObject{printer})
+	printer.Objects.RegisterObject("gcode_move", gcodeMoveObject{printer})
+	printer.Objects.RegisterObject("serial", printer.protocol)
 
-	printer.connected = true
+	printer.connected.Store(true)
 	return printer, nil
 }
 
 func (printer *Printer) MainExecutorContext() shared.ExecutorContext {
-	return printer.context
+	return printer.context.Load()
 }
 
 func (printer *Printer) Disconnect() error {
@@ -99,8 +128,12 @@ func (printer *Printer) Disconnect() error {
 	return nil
 }
 
+func (printer *Printer) Halted() bool {
+	return printer.halted.Load()
+}
+
 func (printer *Printer) EmergencyStop() {
-	<-printer.context.QueueGcode("M112", true)
+	<-printer.context.Load().QueueGcode("M112", true)
 }
 
 func (printer *Printer) tryToConnect() error {
@@ -119,16 +152,21 @@ func (printer *Printer) tryToConnect() error {
 }
 
 func (printer *Printer) handshake(attempt int) error {
-	printer.context = newExecutorContext(printer, fmt.Sprintf("handshake%d", attempt))
-	defer printer.context.close()
+	context := newExecutorContext(printer, fmt.Sprintf("handshake%d", attempt))
+	printer.context.Store(context)
+	defer context.close()
 
 	errCh1, errCh2 := make(chan error), make(chan error)
 
 	go func() {
 		defer close(errCh1)
 
+		if printer.config.Serial.Checksum {
+			<-printer.context.Load().QueueGcode("M110 N0", true)
+		}
+
 		for {
-			info, capabilities, err := parser.ParseM115(<-printer.context.QueueGcode("M115", true))
+			info, capabilities, err := parser.ParseM115(<-printer.context.Load().QueueGcode("M115", true))
 			if err != nil {
 				log.Errorf("Failed to parse capabilities: %v", err)
 				continue
@@ -179,16 +217,20 @@ func (printer *Printer) cleanup() {
 	for _, watcher := range printer.watchers.Load() {
 		watcher.stop()
 	}
-	printer.PrintManager.Cleanup(printer.context)
+	printer.PrintManager.Cleanup(printer.context.Load())
 	printer.MacroManager.Cleanup()
-	printer_objects.UnregisterObject("toolhead")
-	printer_objects.UnregisterObject("motion_report")
-	printer_objects.UnregisterObject("gcode_move")
+	if printer.bedMesh != nil {
+		printer.bedMesh.cleanup()
+	}
+	printer.Objects.UnregisterObject("toolhead")
+	printer.Objects.UnregisterObject("motion_report")
+	printer.Objects.UnregisterObject("gcode_move")
+	printer.Objects.UnregisterObject("serial")
 }
 
 func (printer *Printer) setup() error {
 
-	printer.context = newExecutorContext(printer, "main")
+	printer.context.Store(newExecutorContext(printer, "main"))
 	errorCh1, errorCh2 := make(chan error), make(chan error)
 
 	go func() {
@@ -206,26 +248,30 @@ func (printer *Printer) setup() error {
 			if !printer.config.Printer.Gcode.ReportVelocity {
 				c |= 1 << 2
 			}
-			<-printer.context.QueueGcode(fmt.Sprintf("M155 S1 C%d", c), true)
+			<-printer.context.Load().QueueGcode(fmt.Sprintf("M155 S1 C%d", c), true)
 		}
 
 		for {
-			ch := printer.context.QueueGcode("M503", true)
-			limits, err := parser.ParseM503(<-ch)
+			ch := printer.context.Load().QueueGcode("M503", true)
+			settings, err := parser.ParseM503(<-ch)
 			if err != nil {
-				log.Errorf("Failed parsing limits: %v", err)
+				log.Errorf("Failed parsing firmware settings: %v", err)
 				continue
 			}
-			printer.limits = limits
+			printer.firmwareSettings.Store(settings)
 			break
 		}
 
-		tempWatcher, positionWatcher := newTempWatcher(printer), newPositionWatcher(printer)
+		tempWatcher, positionWatcher, fanWatcher := newTempWatcher(printer), newPositionWatcher(printer), newFanWatcher(printer)
 		printer.watchers.Do(func(watchers []watcher) []watcher {
-			return append(watchers, tempWatcher, positionWatcher)
+			return append(watchers, tempWatcher, positionWatcher, fanWatcher)
 		})
 		printer.heaters = <-tempWatcher.heatersCh
 
+		if printer.config.Printer.BedMesh {
+			printer.bedMesh = newBedMesh(printer)
+		}
+
 		errorCh1 <- nil
 	}()
 
@@ -245,12 +291,26 @@ func (printer *Printer) setup() error {
 }
 
 func (printer *Printer) handleRequestLine(line string) {
+	if parser.M503.MatchString(line) {
+		printer.captureEcho.Store(false)
+	}
 	switch {
 	case parser.M112.MatchString(line):
-		printer.Error = errors.New("emergency stop")
-		if err := printer.Disconnect(); err != nil {
-			log.Errorf("Failed updating state: %v", err)
+		printer.halt(errors.New("emergency stop"))
+
+	case parser.M73.MatchString(line):
+		if printer.PrintManager.IsQueuedProgress(line) {
+			break
+		}
+		values, err := parser.ParseM73(line)
+		if err != nil {
+			log.Errorf("Failed to parse progress: %v", err)
+			break
 		}
+		printer.PrintManager.ReportProgress(values)
+
+	case parser.M117.MatchString(line):
+		printer.PrintManager.SetMessage(strings.TrimSpace(line[4:]))
 
 	default:
 		if err := printer.GcodeState.update(line); err != nil {
@@ -266,43 +326,85 @@ func (printer *Printer) handleResponseLine(line string) bool {
 		switch action {
 		case "cancel":
 			log.Println("Canceling print")
-			_ = printer.context.QueueGcode("CANCEL_PRINT", true)
+			_ = printer.context.Load().QueueGcode("CANCEL_PRINT", true)
 		case "pause":
 			log.Println("Pausing print")
-			_ = printer.context.QueueGcode("PAUSE", true)
+			_ = printer.context.Load().QueueGcode("PAUSE", true)
 		case "resume":
 			log.Println("Resuming print")
-			_ = printer.context.QueueGcode("RESUME", true)
+			_ = printer.context.Load().QueueGcode("RESUME", true)
 		}
 		return true
 	}
 
+	if message, halted := parser.ParseHalt(line); halted && printer.connected.Load() {
+		log.Errorln(message)
+		err := errors.New(strings.ToLower(message))
+		if printer.stopRequested.Load() {
+			err = errors.New("emergency stop")
+		}
+		go printer.halt(err)
+	}
+
 	for _, watcher := range printer.watchers.Load() {
 		watcher.handle(line)
 	}
 
-	if printer.connected && strings.HasPrefix(line, "echo:") {
+	if printer.connected.Load() && strings.HasPrefix(line, "echo:") {
 		message := line[5:]
 		if strings.HasPrefix(message, "busy:") {
 			return false
 		}
 
-		err := notification.Publish(notification.New("notify_gcode_response", []any{message}))
+		err := printer.publishGcodeResponse(message)
 		if err != nil {
 			log.Errorf("Error publishing notification: %v", err)
 		}
 
 		gcode_store.LogNow(message, gcode_store.Response)
-		return true
+		return !printer.captureEcho.Load()
 	}
 
 	return false
 }
 
+func (printer *Printer) halt(err error) {
+	if !printer.halted.CompareAndSwap(false, true) {
+		return
+	}
+	printer.setError(err)
+	if err := printer.Disconnect(); err != nil {
+		log.Errorf("Failed to disconnect from printer: %v", err)
+	}
+}
+
+func (printer *Printer) GetError() error {
+	return printer.err.Load()
+}
+
+func (printer *Printer) setError(err error) {
+	printer.err.Do(func(current error) error {
+		if current != nil {
+			return current
+		}
+		return err
+	})
+}
+
+func (printer *Printer) prepareRequestLine(line string) {
+	if parser.M112.MatchString(line) {
+		printer.stopRequested.Store(true)
+	}
+	if parser.M503.MatchString(line) {
+		printer.captureEcho.Store(true)
+	}
+}
+
 func (printer *Printer) executeEmergencyCommand(gcode string) bool {
 	if printer.hasEmergencyParser && parser.IsEmergencyCommand(gcode) {
 		log.Debugf("emergency: %s", gcode)
-		if _, err := printer.port.Write([]byte(fmt.Sprintln(gcode))); err != nil {
+		printer.prepareRequestLine(gcode)
+		if err := printer.protocol.writeRaw(gcode); err != nil {
 			log.Errorf("Failed writing to printer port: %v", err)
 		}
 		printer.handleRequestLine(gcode)
@@ -312,30 +414,56 @@ func (printer *Printer) executeEmergencyCommand(gcode string) bool {
 }
 
 func (printer *Printer) readLine(line string) {
-	if printer.handleResponseLine(line) {
+	if printer.handleResponseLine(line) || printer.protocol.handleLine(line) {
 		return
 	}
-	if printer.context != nil {
-		printer.context.readLine(line)
+	if context := printer.context.Load(); context != nil {
+		context.readLine(line)
 	}
 }
 
+func (printer *Printer) Name() string {
+	return printer.name
+}
+
+func (printer *Printer) GetObjects() *printer_objects.Registry {
+	return printer.Objects
+}
+
 func (printer *Printer) GetPrintManager() shared.PrintManager {
 	return printer.PrintManager
 }
 
 func (printer *Printer) Respond(message string) error {
 	gcode_store.LogNow(message, gcode_store.Response)
-	return notification.Publish(notification.New("notify_gcode_response", []any{message}))
+	return printer.publishGcodeResponse(message)
+}
+
+func (printer *Printer) publishGcodeResponse(message string) error {
+	params := []any{message}
+	if printer.name != "" {
+		params = append(params, printer.name)
+	}
+	return notification.Publish(notification.New("notify_gcode_response", params))
 }
 
 func (printer *Printer) GetGcodeState() shared.GcodeState {
 	return printer.GcodeState
 }
 
+func (printer *Printer) FirmwareSettings() parser.FirmwareSettings {
+	return printer.firmwareSettings.Load()
+}
+
+func (printer *Printer) GetBedMesh() shared.BedMesh {
+	if printer.bedMesh == nil {
+		return nil
+	}
+	return printer.bedMesh
+}
+
 func (printer *Printer) SaveGcodeState(name string) {
-	currentState := *printer.GcodeState
-	printer.savedGcodeStates[name] = currentState
+	printer.savedGcodeStates[name] = printer.GcodeState.Snapshot()
 }
 
 func (printer *Printer) RestoreGcodeState(context shared.ExecutorContext, name string) error {
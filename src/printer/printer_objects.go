@@ -11,8 +11,15 @@ type toolheadObject struct {
 
 func (object toolheadObject) Query() (printer_objects.QueryResult, error) {
 
+	settings := object.printer.FirmwareSettings()
+	maxAccel := settings.Acceleration.Print
+	if maxAccel == 0 {
+		maxAccel = min(settings.MaxAccel[0], settings.MaxAccel[1])
+	}
+
+	state := object.printer.GcodeState.Snapshot()
 	var homedAxes strings.Builder
-	for i, homed := range object.printer.GcodeState.HomedAxes {
+	for i, homed := range state.HomedAxes {
 		if homed {
 			homedAxes.WriteByte("xyz"[i])
 		}
@@ -26,10 +33,10 @@ func (object toolheadObject) Query() (printer_objects.QueryResult, error) {
 		"stalls":                 0,
 		"estimated_print_time":   0,
 		"extruder":               "extruder",
-		"position":               object.printer.GcodeState.Position,
-		"max_velocity":           300,
-		"max_accel":              3000,
-		"max_accel_to_decel":     1500,
+		"position":               state.Position,
+		"max_velocity":           max(settings.MaxFeedrate[0], settings.MaxFeedrate[1]),
+		"max_accel":              maxAccel,
+		"max_accel_to_decel":     maxAccel / 2,
 		"square_corner_velocity": 5,
 	}, nil
 }
@@ -39,10 +46,11 @@ type motionReportObject struct {
 }
 
 func (object motionReportObject) Query() (printer_objects.QueryResult, error) {
+	state := object.printer.GcodeState.Snapshot()
 	return printer_objects.QueryResult{
-		"live_position":          object.printer.GcodeState.Position,
-		"live_velocity":          object.printer.GcodeState.Velocity,
-		"live_extruder_velocity": object.printer.GcodeState.EVelocity,
+		"live_position":          state.Position,
+		"live_velocity":          state.Velocity,
+		"live_extruder_velocity": state.EVelocity,
 	}, nil
 }
 
@@ -51,14 +59,15 @@ type gcodeMoveObject struct {
 }
 
 func (object gcodeMoveObject) Query() (printer_objects.QueryResult, error) {
+	state := object.printer.GcodeState.Snapshot()
 	return printer_objects.QueryResult{
-		"gcode_position":       object.printer.GcodeState.Position,
-		"position":             object.printer.GcodeState.Position,
+		"gcode_position":       state.Position,
+		"position":             state.Position,
 		"homing_origin":        [4]float64{0, 0, 0, 0},
-		"speed":                object.printer.GcodeState.Feedrate / 60.0,
-		"speed_factor":         float64(object.printer.GcodeState.SpeedFactor) / 100.0,
-		"extrude_factor":       float64(object.printer.GcodeState.ExtrudeFactor) / 100.0,
-		"absolute_coordinates": object.printer.GcodeState.IsAbsoluteCoordinate,
-		"absolute_extrude":     object.printer.GcodeState.IsAbsoluteExtrude,
+		"speed":                state.Feedrate / 60.0,
+		"speed_factor":         float64(state.SpeedFactor) / 100.0,
+		"extrude_factor":       float64(state.ExtrudeFactor) / 100.0,
+		"absolute_coordinates": state.IsAbsoluteCoordinate,
+		"absolute_extrude":     state.IsAbsoluteExtrude,
 	}, nil
 }
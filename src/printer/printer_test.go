@@ -0,0 +1,225 @@
+package printer
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"io"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/database"
+	"marlinraker/src/files"
+	"marlinraker/src/marlinraker/history"
+	"marlinraker/src/marlinraker/job_queue"
+	"marlinraker/src/marlinraker/temp_store"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer/print_manager"
+	"marlinraker/src/printer/virtual"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/util"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var setupOnce sync.Once
+
+func setupDatabase(t *testing.T) {
+	setupOnce.Do(func() {
+		files.Fs = afero.NewMemMapFs()
+		notification.Testing = true
+	})
+	entries, err := afero.ReadDir(files.Fs, files.DataDir)
+	assert.NilError(t, err)
+	for _, entry := range entries {
+		assert.NilError(t, files.Fs.RemoveAll(filepath.Join(files.DataDir, entry.Name())))
+	}
+	assert.NilError(t, database.Init())
+}
+
+func setupVirtualPrinter(t *testing.T, configure func(cfg *config.Config)) *Printer {
+	setupDatabase(t)
+	assert.NilError(t, history.Init())
+
+	cfg := config.DefaultConfig()
+	cfg.Serial.Port = virtual.PortName
+	cfg.VirtualPrinter.TimeScale = 0
+	if configure != nil {
+		configure(cfg)
+	}
+	assert.NilError(t, job_queue.Init(cfg))
+
+	printer, err := New("", cfg, printer_objects.NewRegistry(""), temp_store.New(), virtual.PortName, 0)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, printer.Disconnect())
+	})
+	return printer
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+type recordingWriter struct {
+	io.Writer
+	mu    sync.Mutex
+	lines []string
+}
+
+func (writer *recordingWriter) Write(bytes []byte) (int, error) {
+	writer.mu.Lock()
+	for _, line := range strings.Split(strings.TrimSpace(string(bytes)), "\n") {
+		if match := framedLineRegex.FindStringSubmatch(line); match != nil {
+			line = match[1]
+		}
+		writer.lines = append(writer.lines, line)
+	}
+	writer.mu.Unlock()
+	return writer.Writer.Write(bytes)
+}
+
+func (writer *recordingWriter) Lines() []string {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return append([]string{}, writer.lines...)
+}
+
+var framedLineRegex = regexp.MustCompile(`^N-?[0-9]+ (.*)\*[0-9]+$`)
+
+func TestVirtualPrinter(t *testing.T) {
+	printer := setupVirtualPrinter(t, nil)
+
+	assert.Equal(t, printer.info.MachineType, "Virtual Printer")
+	assert.Equal(t, printer.Capabilities["AUTOREPORT_TEMP"], true)
+	assert.Equal(t, printer.Capabilities["EMERGENCY_PARSER"], true)
+	settings := printer.FirmwareSettings()
+	assert.DeepEqual(t, settings.MaxAccel, [4]float64{3000, 3000, 100, 10000})
+	assert.DeepEqual(t, settings.MaxFeedrate, [4]float64{300, 300, 5, 25})
+	assert.DeepEqual(t, settings.HotendPid, &parser.PidSettings{P: 22.2, I: 1.08, D: 114})
+
+	toolhead, err := printer.Objects.Query("toolhead")
+	assert.NilError(t, err)
+	assert.Equal(t, toolhead["max_velocity"], 300.)
+	assert.Equal(t, toolhead["max_accel"], 3000.)
+
+	<-printer.context.Load().QueueGcode("G28", true)
+	<-printer.context.Load().QueueGcode("G1 X10 Y20 Z5 F3000", true)
+	position, err := parser.ParseM114(<-printer.context.Load().QueueGcode("M114", true))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, position, [4]float64{10, 20, 5, 0})
+	assert.DeepEqual(t, printer.GcodeState.GcodePosition, [4]float64{10, 20, 5, 0})
+
+	<-printer.context.Load().QueueGcode("M104 S200\nM140 S60", true)
+	waitFor(t, func() bool {
+		extruder, err := printer.Objects.Query("extruder")
+		assert.NilError(t, err)
+		bed, err := printer.Objects.Query("heater_bed")
+		assert.NilError(t, err)
+		return extruder["temperature"] == 200. && bed["temperature"] == 60.
+	})
+
+	response := <-printer.context.Load().QueueGcode("M109 S200", true)
+	assert.Assert(t, strings.HasSuffix(response, "ok"))
+}
+
+func TestVirtualPrinterChecksumAndPipelining(t *testing.T) {
+	printer := setupVirtualPrinter(t, func(cfg *config.Config) {
+		cfg.Serial.Checksum = true
+		cfg.VirtualPrinter.Capabilities = map[string]bool{"ADVANCED_OK": true}
+	})
+	assert.Equal(t, printer.context.Load().pipelining, true)
+
+	gcode := make([]string, 0)
+	for i := 1; i <= 50; i++ {
+		gcode = append(gcode, fmt.Sprintf("G1 X%d Y%d", i, i*2))
+	}
+	<-printer.context.Load().QueueGcode(strings.Join(gcode, "\n"), true)
+	<-printer.context.Load().Pending()
+
+	position, err := parser.ParseM114(<-printer.context.Load().QueueGcode("M114", true))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, position, [4]float64{50, 100, 0, 0})
+
+	serial, err := printer.protocol.Query()
+	assert.NilError(t, err)
+	assert.Equal(t, serial["resends"], 0)
+	assert.Assert(t, serial["line_number"].(int) > 50)
+}
+
+func TestHandleProgressLines(t *testing.T) {
+	notification.Testing = true
+	objects := printer_objects.NewRegistry("")
+	printer := &Printer{
+		Objects:    objects,
+		watchers:   util.NewThreadSafe(make([]watcher, 0)),
+		GcodeState: &GcodeState{objects: objects, mu: &sync.RWMutex{}},
+	}
+	printer.PrintManager = print_manager.NewPrintManager(printer, config.DefaultConfig())
+	t.Cleanup(func() {
+		printer.PrintManager.Cleanup(nil)
+	})
+
+	printer.handleRequestLine("M117 Printing cube")
+	printer.handleRequestLine("M73 P25 R10")
+	result, err := objects.Query("display_status")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]any(result), map[string]any{"progress": 0.25, "message": "Printing cube"})
+
+	printer.handleRequestLine("M117")
+	printer.handleRequestLine("M73 P150")
+	result, err = objects.Query("display_status")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]any(result), map[string]any{"progress": 1., "message": ""})
+}
+
+func TestHandleEchoLines(t *testing.T) {
+	notification.Testing = true
+	objects := printer_objects.NewRegistry("")
+	printer := &Printer{
+		Objects:    objects,
+		watchers:   util.NewThreadSafe(make([]watcher, 0)),
+		GcodeState: &GcodeState{objects: objects, mu: &sync.RWMutex{}},
+	}
+	printer.connected.Store(true)
+
+	assert.Assert(t, printer.handleResponseLine("echo:Unknown command: \"G999\""))
+	assert.Assert(t, !printer.handleResponseLine("echo:busy: processing"))
+	assert.Assert(t, !printer.handleResponseLine("ok"))
+
+	printer.prepareRequestLine("M503")
+	assert.Assert(t, !printer.handleResponseLine("echo:  M92 X80.00 Y80.00 Z400.00 E93.00"))
+	printer.handleRequestLine("M503")
+	assert.Assert(t, printer.handleResponseLine("echo:  M92 X80.00 Y80.00 Z400.00 E93.00"))
+}
+
+func TestGcodeStateSkipMoves(t *testing.T) {
+	state := &GcodeState{
+		GcodePosition:        [4]float64{10, 10, 0.2, 1},
+		IsAbsoluteCoordinate: true,
+		IsAbsoluteExtrude:    true,
+		Feedrate:             3000,
+		mu:                   &sync.RWMutex{},
+	}
+	assert.Equal(t, state.ResumeGcode(), "")
+	assert.NilError(t, state.SkipMove("G1 X50 Y50"))
+	assert.NilError(t, state.SkipMove("G2 X55 Y50 I2.5 J0 E2 F1200"))
+	assert.NilError(t, state.SkipMove("G1 Z1 F600"))
+	assert.DeepEqual(t, state.GcodePosition, [4]float64{10, 10, 0.2, 1})
+	assert.Equal(t, state.ResumeGcode(), "G0 X55.000 Y50.000 Z1.000 F600\nG92 E2.000\n")
+	assert.Equal(t, state.ResumeGcode(), "")
+
+	state.IsAbsoluteCoordinate, state.IsAbsoluteExtrude = false, false
+	assert.NilError(t, state.SkipMove("G1 X5 E0.5 F4000"))
+	assert.NilError(t, state.SkipMove("G1 Y-2 E0.5"))
+	assert.Equal(t, state.ResumeGcode(), "G0 X5.000 Y-2.000 F4000\n")
+}
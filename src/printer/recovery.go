@@ -0,0 +1,213 @@
+package printer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/database"
+	"marlinraker/src/shared"
+	"marlinraker/src/util"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PrintCheckpoint struct {
+	FileName             string             `json:"filename"`
+	FilePosition         int64              `json:"file_position"`
+	Position             [4]float64         `json:"position"`
+	IsAbsoluteCoordinate bool               `json:"absolute_coordinates"`
+	IsAbsoluteExtrude    bool               `json:"absolute_extrude"`
+	Feedrate             float64            `json:"feedrate"`
+	EOffset              float64            `json:"e_offset"`
+	SpeedFactor          int                `json:"speed_factor"`
+	ExtrudeFactor        int                `json:"extrude_factor"`
+	HeaterTargets        map[string]float64 `json:"heater_targets"`
+	Time                 float64            `json:"time"`
+}
+
+func LoadCheckpoint(printerName string) (*PrintCheckpoint, error) {
+	item, err := database.GetItem("marlinraker", checkpointKey(printerName), true)
+	if err != nil {
+		var executorErr *util.ExecutorError
+		if errors.As(err, &executorErr) && executorErr.Code == 404 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &PrintCheckpoint{}
+	if err := json.Unmarshal(bytes, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func (printer *Printer) UpdateCheckpoint(fileName string, filePosition int64) {
+	recovery := printer.config.Printer.PowerLossRecovery
+	if !recovery.Enabled {
+		return
+	}
+
+	now := time.Now()
+	interval := time.Duration(recovery.CheckpointInterval * float64(time.Second))
+	if now.Sub(printer.lastCheckpoint) < interval {
+		return
+	}
+	printer.lastCheckpoint = now
+
+	targets := make(map[string]float64)
+	for _, heater := range printer.heaters.availableHeaters {
+		result, err := printer.Objects.Query(heater)
+		if err != nil {
+			log.Errorf("Failed to query heater %s: %v", heater, err)
+			continue
+		}
+		if target, isFloat := result["target"].(float64); isFloat {
+			targets[heater] = target
+		}
+	}
+
+	state := printer.GcodeState.Snapshot()
+	checkpoint := PrintCheckpoint{
+		FileName:             fileName,
+		FilePosition:         filePosition,
+		Position:             state.GcodePosition,
+		IsAbsoluteCoordinate: state.IsAbsoluteCoordinate,
+		IsAbsoluteExtrude:    state.IsAbsoluteExtrude,
+		Feedrate:             state.Feedrate,
+		EOffset:              state.EOffset,
+		SpeedFactor:          state.SpeedFactor,
+		ExtrudeFactor:        state.ExtrudeFactor,
+		HeaterTargets:        targets,
+		Time:                 float64(now.UnixMilli()) / 1000.0,
+	}
+
+	if _, err := database.PostItem("marlinraker", checkpointKey(printer.name), checkpoint, true); err != nil {
+		log.Errorf("Failed to save print checkpoint: %v", err)
+	}
+}
+
+func (printer *Printer) ClearCheckpoint() {
+	printer.lastCheckpoint = time.Time{}
+	if _, err := database.DeleteItem("marlinraker", checkpointKey(printer.name), true); err != nil {
+		var executorErr *util.ExecutorError
+		if !errors.As(err, &executorErr) || executorErr.Code != 404 {
+			log.Errorf("Failed to clear print checkpoint: %v", err)
+		}
+	}
+}
+
+func (printer *Printer) RecoverPrint(context shared.ExecutorContext) error {
+
+	checkpoint, err := LoadCheckpoint(printer.name)
+	if err != nil {
+		return fmt.Errorf("failed to load print checkpoint: %w", err)
+	}
+	if checkpoint == nil {
+		return errors.New("there is no interrupted print to recover")
+	}
+	if printer.PrintManager.IsPrinting() {
+		return errors.New("cannot recover print while printing")
+	}
+	if !printer.PrintManager.CanPrint(checkpoint.FileName) {
+		return fmt.Errorf("cannot print file %q", checkpoint.FileName)
+	}
+
+	log.Printf("Recovering print of %q from byte %d", checkpoint.FileName, checkpoint.FilePosition)
+	if err := printer.Respond(fmt.Sprintf("// Recovering print of %q", checkpoint.FileName)); err != nil {
+		log.Errorf("Failed to send response: %v", err)
+	}
+
+	zLift := printer.config.Printer.PowerLossRecovery.ZLift
+	<-context.QueueGcode(recoveryGcode(checkpoint, zLift), true)
+	<-context.Pending()
+	printer.GcodeState.setEOffset(checkpoint.EOffset)
+
+	return printer.PrintManager.Recover(context, checkpoint.FileName, checkpoint.FilePosition)
+}
+
+func checkpointKey(printerName string) string {
+	if printerName == "" {
+		return "print_checkpoint"
+	}
+	return "print_checkpoint_" + printerName
+}
+
+func recoveryGcode(checkpoint *PrintCheckpoint, zLift float64) string {
+
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 3, 64)
+	}
+
+	x, y, z, e := checkpoint.Position[0], checkpoint.Position[1], checkpoint.Position[2], checkpoint.Position[3]
+
+	var builder strings.Builder
+	builder.WriteString("G92 Z" + formatFloat(z) + "\n")
+	builder.WriteString("G91\n")
+	builder.WriteString("G1 Z" + formatFloat(zLift) + " F600\n")
+	builder.WriteString("G90\n")
+	writeHeaterGcodes(&builder, checkpoint.HeaterTargets)
+	builder.WriteString("G28 X Y\n")
+	builder.WriteString("G1 X" + formatFloat(x) + " Y" + formatFloat(y) + " F3000\n")
+	builder.WriteString("G1 Z" + formatFloat(z) + " F600\n")
+	builder.WriteString("G92 E" + formatFloat(e) + "\n")
+
+	if checkpoint.IsAbsoluteCoordinate {
+		if !checkpoint.IsAbsoluteExtrude {
+			builder.WriteString("M83\n")
+		}
+	} else {
+		builder.WriteString("G91\n")
+		if checkpoint.IsAbsoluteExtrude {
+			builder.WriteString("M82\n")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("M220 S%d\n", checkpoint.SpeedFactor))
+	builder.WriteString(fmt.Sprintf("M221 S%d\n", checkpoint.ExtrudeFactor))
+	if checkpoint.Feedrate > 0 {
+		builder.WriteString(fmt.Sprintf("G0 F%d\n", int(checkpoint.Feedrate)))
+	}
+
+	return builder.String()
+}
+
+func writeHeaterGcodes(builder *strings.Builder, targets map[string]float64) {
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var waitGcodes []string
+	for _, name := range names {
+		target := targets[name]
+		if target <= 0 {
+			continue
+		}
+		switch {
+		case name == "heater_bed":
+			builder.WriteString(fmt.Sprintf("M140 S%.2f\n", target))
+			waitGcodes = append(waitGcodes, fmt.Sprintf("M190 S%.2f", target))
+
+		case strings.HasPrefix(name, "extruder"):
+			idx := name[8:]
+			if idx == "" {
+				idx = "0"
+			}
+			builder.WriteString(fmt.Sprintf("M104 T%s S%.2f\n", idx, target))
+			waitGcodes = append(waitGcodes, fmt.Sprintf("M109 T%s S%.2f", idx, target))
+		}
+	}
+
+	for _, gcode := range waitGcodes {
+		builder.WriteString(gcode + "\n")
+	}
+}
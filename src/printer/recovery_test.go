@@ -0,0 +1,116 @@
+package printer
+
+import (
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/printer_objects"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testHeaterObject float64
+
+func (object testHeaterObject) Query() (printer_objects.QueryResult, error) {
+	return printer_objects.QueryResult{"target": float64(object)}, nil
+}
+
+func TestRecoveryGcode(t *testing.T) {
+	checkpoint := &PrintCheckpoint{
+		Position:             [4]float64{20, 10, 0.2, 10},
+		IsAbsoluteCoordinate: true,
+		IsAbsoluteExtrude:    true,
+		Feedrate:             3000,
+		SpeedFactor:          100,
+		ExtrudeFactor:        95,
+		HeaterTargets:        map[string]float64{"heater_bed": 60, "extruder": 200, "extruder1": 0},
+	}
+	assert.DeepEqual(t, strings.Split(recoveryGcode(checkpoint, 2), "\n"), []string{
+		"G92 Z0.200", "G91", "G1 Z2.000 F600", "G90",
+		"M104 T0 S200.00", "M140 S60.00", "M109 T0 S200.00", "M190 S60.00",
+		"G28 X Y", "G1 X20.000 Y10.000 F3000", "G1 Z0.200 F600", "G92 E10.000",
+		"M220 S100", "M221 S95", "G0 F3000", "",
+	})
+
+	checkpoint.IsAbsoluteExtrude = false
+	checkpoint.Feedrate = 0
+	checkpoint.HeaterTargets = map[string]float64{"extruder1": 210}
+	assert.DeepEqual(t, strings.Split(recoveryGcode(checkpoint, 0), "\n")[4:], []string{
+		"M104 T1 S210.00", "M109 T1 S210.00",
+		"G28 X Y", "G1 X20.000 Y10.000 F3000", "G1 Z0.200 F600", "G92 E10.000",
+		"M83", "M220 S100", "M221 S95", "",
+	})
+
+	checkpoint.IsAbsoluteCoordinate, checkpoint.IsAbsoluteExtrude = false, true
+	assert.DeepEqual(t, strings.Split(recoveryGcode(checkpoint, 0), "\n")[10:], []string{
+		"G91", "M82", "M220 S100", "M221 S95", "",
+	})
+}
+
+func TestCheckpoint(t *testing.T) {
+	setupDatabase(t)
+
+	cfg := config.DefaultConfig()
+	cfg.Printer.PowerLossRecovery.Enabled = true
+	cfg.Printer.PowerLossRecovery.CheckpointInterval = 60
+
+	objects := printer_objects.NewRegistry("")
+	objects.RegisterObject("extruder", testHeaterObject(200))
+	objects.RegisterObject("heater_bed", testHeaterObject(60))
+	printer := &Printer{
+		name:    "voron",
+		config:  cfg,
+		Objects: objects,
+		heaters: heatersObject{availableHeaters: []string{"extruder", "heater_bed"}},
+		GcodeState: &GcodeState{
+			GcodePosition:        [4]float64{20, 10, 0.2, 10},
+			IsAbsoluteCoordinate: true,
+			Feedrate:             3000,
+			EOffset:              12.5,
+			SpeedFactor:          100,
+			ExtrudeFactor:        100,
+			mu:                   &sync.RWMutex{},
+		},
+	}
+
+	printer.UpdateCheckpoint("cube.gcode", 1024)
+	printer.GcodeState.GcodePosition[0] = 40
+	printer.UpdateCheckpoint("cube.gcode", 2048)
+
+	checkpoint, err := LoadCheckpoint("voron")
+	assert.NilError(t, err)
+	assert.Assert(t, checkpoint != nil)
+	checkpoint.Time = 0
+	assert.DeepEqual(t, *checkpoint, PrintCheckpoint{
+		FileName:             "cube.gcode",
+		FilePosition:         1024,
+		Position:             [4]float64{20, 10, 0.2, 10},
+		IsAbsoluteCoordinate: true,
+		Feedrate:             3000,
+		EOffset:              12.5,
+		SpeedFactor:          100,
+		ExtrudeFactor:        100,
+		HeaterTargets:        map[string]float64{"extruder": 200, "heater_bed": 60},
+	})
+	checkpoint, err = LoadCheckpoint("")
+	assert.NilError(t, err)
+	assert.Assert(t, checkpoint == nil)
+
+	printer.ClearCheckpoint()
+	printer.UpdateCheckpoint("cube.gcode", 2048)
+	checkpoint, err = LoadCheckpoint("voron")
+	assert.NilError(t, err)
+	assert.Equal(t, checkpoint.FilePosition, int64(2048))
+	assert.DeepEqual(t, checkpoint.Position, [4]float64{40, 10, 0.2, 10})
+
+	printer.ClearCheckpoint()
+	checkpoint, err = LoadCheckpoint("voron")
+	assert.NilError(t, err)
+	assert.Assert(t, checkpoint == nil)
+
+	cfg.Printer.PowerLossRecovery.Enabled = false
+	printer.UpdateCheckpoint("cube.gcode", 4096)
+	checkpoint, err = LoadCheckpoint("voron")
+	assert.NilError(t, err)
+	assert.Assert(t, checkpoint == nil)
+}
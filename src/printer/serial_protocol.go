@@ -0,0 +1,158 @@
+package printer
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"marlinraker/src/printer_objects"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const resendBufferSize = 256
+
+var (
+	m110Regex   = regexp.MustCompile(`^M110(?:\s+N(-?[0-9]+))?(\s|$)`)
+	resendRegex = regexp.MustCompile(`^(?i:resend|rs):?\s*N?:?\s*([0-9]+)`)
+)
+
+type serialProtocol struct {
+	mutex          *sync.Mutex
+	port           io.Writer
+	path           string
+	checksum       bool
+	lineNumber     int
+	sentLines      [resendBufferSize]string
+	resendPending  bool
+	resendFrom     int
+	swallowOks     int
+	resends        int
+	checksumErrors int
+}
+
+func newSerialProtocol(port io.Writer, path string, checksum bool) *serialProtocol {
+	return &serialProtocol{
+		mutex:    &sync.Mutex{},
+		port:     port,
+		path:     path,
+		checksum: checksum,
+	}
+}
+
+func (protocol *serialProtocol) writeLine(gcode string) error {
+	protocol.mutex.Lock()
+	defer protocol.mutex.Unlock()
+
+	if !protocol.checksum {
+		return protocol.write(gcode)
+	}
+
+	if match := m110Regex.FindStringSubmatch(gcode); match != nil {
+		lineNumber := 0
+		if match[1] != "" {
+			lineNumber, _ = strconv.Atoi(match[1])
+		}
+		protocol.lineNumber = lineNumber - 1
+		gcode = fmt.Sprintf("M110 N%d", lineNumber)
+	}
+
+	protocol.lineNumber++
+	framed := frameLine(protocol.lineNumber, gcode)
+	protocol.sentLines[protocol.bufferIndex(protocol.lineNumber)] = framed
+	return protocol.write(framed)
+}
+
+func (protocol *serialProtocol) writeRaw(gcode string) error {
+	protocol.mutex.Lock()
+	defer protocol.mutex.Unlock()
+	return protocol.write(gcode)
+}
+
+func (protocol *serialProtocol) handleLine(line string) bool {
+	if !protocol.checksum {
+		return false
+	}
+
+	protocol.mutex.Lock()
+	defer protocol.mutex.Unlock()
+
+	switch {
+	case strings.HasPrefix(line, "ok"):
+		if protocol.swallowOks > 0 {
+			protocol.swallowOks--
+			return true
+		}
+		protocol.resendPending = false
+		return false
+
+	case resendRegex.MatchString(line):
+		lineNumber, err := strconv.Atoi(resendRegex.FindStringSubmatch(line)[1])
+		if err != nil {
+			log.Errorf("Cannot parse resend request %q: %v", line, err)
+			return true
+		}
+		protocol.swallowOks++
+		protocol.resends++
+		if protocol.resendPending && protocol.resendFrom == lineNumber {
+			// printer rejected a line that was sent before the first resend request
+			return true
+		}
+		protocol.resendPending, protocol.resendFrom = true, lineNumber
+		protocol.resend(lineNumber)
+		return true
+
+	case strings.HasPrefix(line, "Error:"):
+		message := strings.ToLower(line)
+		if strings.Contains(message, "checksum") || strings.Contains(message, "line number") {
+			protocol.checksumErrors++
+			log.WithField("port", protocol.path).Warnln(line)
+			return true
+		}
+	}
+	return false
+}
+
+func (protocol *serialProtocol) resend(from int) {
+	if from > protocol.lineNumber || protocol.lineNumber-from >= resendBufferSize {
+		log.Errorf("Cannot resend line %d, last line sent was %d", from, protocol.lineNumber)
+		return
+	}
+	log.WithField("port", protocol.path).Warnf("Resending from line %d", from)
+	for lineNumber := from; lineNumber <= protocol.lineNumber; lineNumber++ {
+		if err := protocol.write(protocol.sentLines[protocol.bufferIndex(lineNumber)]); err != nil {
+			log.Errorf("Failed to resend line %d: %v", lineNumber, err)
+			return
+		}
+	}
+}
+
+func (protocol *serialProtocol) write(line string) error {
+	_, err := protocol.port.Write([]byte(line + "\n"))
+	return err
+}
+
+func (protocol *serialProtocol) bufferIndex(lineNumber int) int {
+	return (lineNumber%resendBufferSize + resendBufferSize) % resendBufferSize
+}
+
+func (protocol *serialProtocol) Query() (printer_objects.QueryResult, error) {
+	protocol.mutex.Lock()
+	defer protocol.mutex.Unlock()
+	return printer_objects.QueryResult{
+		"checksum":        protocol.checksum,
+		"line_number":     protocol.lineNumber,
+		"resends":         protocol.resends,
+		"checksum_errors": protocol.checksumErrors,
+	}, nil
+}
+
+func frameLine(lineNumber int, gcode string) string {
+	line := fmt.Sprintf("N%d %s", lineNumber, gcode)
+	checksum := byte(0)
+	for i := 0; i < len(line); i++ {
+		checksum ^= line[i]
+	}
+	return fmt.Sprintf("%s*%d", line, checksum)
+}
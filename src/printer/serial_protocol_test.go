@@ -0,0 +1,48 @@
+package printer
+
+import (
+	"bytes"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func TestFrameLine(t *testing.T) {
+	assert.Equal(t, frameLine(0, "M110 N0"), "N0 M110 N0*125")
+	assert.Equal(t, frameLine(1, "G28"), "N1 G28*18")
+}
+
+func TestSerialProtocol(t *testing.T) {
+	buf := &bytes.Buffer{}
+	protocol := newSerialProtocol(buf, "test", true)
+
+	assert.NilError(t, protocol.writeLine("M110 N0"))
+	assert.NilError(t, protocol.writeLine("G28"))
+	assert.NilError(t, protocol.writeLine("M105"))
+	assert.Equal(t, buf.String(), "N0 M110 N0*125\nN1 G28*18\nN2 M105*37\n")
+	buf.Reset()
+
+	assert.Equal(t, protocol.handleLine("ok"), false)
+	assert.Equal(t, protocol.handleLine("Error:checksum mismatch, Last Line: 0"), true)
+	assert.Equal(t, protocol.handleLine("Resend: 1"), true)
+	assert.Equal(t, buf.String(), "N1 G28*18\nN2 M105*37\n")
+	assert.Equal(t, protocol.handleLine("ok"), true)
+	buf.Reset()
+
+	assert.Equal(t, protocol.handleLine("Error:Line Number is not Last Line Number+1, Last Line: 0"), true)
+	assert.Equal(t, protocol.handleLine("Resend: 1"), true)
+	assert.Equal(t, buf.String(), "")
+	assert.Equal(t, protocol.handleLine("ok"), true)
+	assert.Equal(t, protocol.handleLine("ok"), false)
+
+	result, err := protocol.Query()
+	assert.NilError(t, err)
+	assert.Equal(t, result["resends"], 2)
+	assert.Equal(t, result["checksum_errors"], 2)
+	assert.Equal(t, result["line_number"], 2)
+
+	buf.Reset()
+	protocol = newSerialProtocol(buf, "test", false)
+	assert.NilError(t, protocol.writeLine("G28"))
+	assert.Equal(t, buf.String(), "G28\n")
+	assert.Equal(t, protocol.handleLine("Resend: 1"), false)
+}
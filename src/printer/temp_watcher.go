@@ -2,7 +2,6 @@ package printer
 
 import (
 	log "github.com/sirupsen/logrus"
-	"marlinraker/src/marlinraker/temp_store"
 	"marlinraker/src/printer/parser"
 	"marlinraker/src/printer_objects"
 	"math"
@@ -61,12 +60,12 @@ type tempWatcher struct {
 	printer       *Printer
 	heatersCh     chan heatersObject
 	firstRead     bool
-	ticker        *time.Ticker
 	closeCh       chan struct{}
 	heaterObjects map[string]*heaterObject
 	sensorObjects map[string]*temperatureSensorObject
 	objectsMutex  *sync.Mutex
 	autoReport    bool
+	watchdog      *thermalWatchdog
 }
 
 var (
@@ -86,8 +85,12 @@ func newTempWatcher(printer *Printer) *tempWatcher {
 		firstRead:     true,
 	}
 
+	if printer.config.Printer.ThermalSafety.Enabled {
+		watcher.watchdog = newThermalWatchdog(printer)
+	}
+
 	if watcher.autoReport && !printer.IsPrusa {
-		_ = printer.context.QueueGcode("M115 S1", true)
+		_ = printer.context.Load().QueueGcode("M155 S1", true)
 	}
 
 	go watcher.runTimer()
@@ -95,12 +98,13 @@ func newTempWatcher(printer *Printer) *tempWatcher {
 }
 
 func (watcher *tempWatcher) runTimer() {
-	watcher.ticker = time.NewTicker(time.Second)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-watcher.closeCh:
-			break
-		case <-watcher.ticker.C:
+			return
+		case <-ticker.C:
 			watcher.tick()
 		}
 	}
@@ -111,35 +115,36 @@ func (watcher *tempWatcher) tick() {
 	defer watcher.objectsMutex.Unlock()
 
 	for name := range watcher.heaterObjects {
-		err := printer_objects.EmitObject(name)
+		err := watcher.printer.Objects.EmitObject(name)
 		if err != nil {
 			log.Errorf("Failed to emit object %s: %v", name, err)
 		}
 	}
 
 	for name := range watcher.sensorObjects {
-		err := printer_objects.EmitObject(name)
+		err := watcher.printer.Objects.EmitObject(name)
 		if err != nil {
 			log.Errorf("Failed to emit object %s: %v", name, err)
 		}
 	}
 
+	if watcher.watchdog != nil {
+		watcher.watchdog.checkReports()
+	}
+
 	if !watcher.autoReport {
-		watcher.parseTemps(<-watcher.printer.context.QueueGcode("M105", true))
+		watcher.parseTemps(<-watcher.printer.context.Load().QueueGcode("M105", true))
 	}
 }
 
 func (watcher *tempWatcher) stop() {
-	if watcher.ticker != nil {
-		watcher.ticker.Stop()
-	}
 	close(watcher.closeCh)
-	printer_objects.UnregisterObject("heaters")
+	watcher.printer.Objects.UnregisterObject("heaters")
 	for name := range watcher.heaterObjects {
-		printer_objects.UnregisterObject(name)
+		watcher.printer.Objects.UnregisterObject(name)
 	}
 	for name := range watcher.sensorObjects {
-		printer_objects.UnregisterObject(name)
+		watcher.printer.Objects.UnregisterObject(name)
 	}
 }
 
@@ -164,7 +169,7 @@ func (watcher *tempWatcher) parseTemps(data string) {
 		watcher.firstRead = false
 	}
 
-	temp_store.SetLastMeasured(temps)
+	watcher.printer.TempStore.SetLastMeasured(temps)
 
 	for name, temp := range temps {
 
@@ -188,6 +193,10 @@ func (watcher *tempWatcher) parseTemps(data string) {
 			}
 		}
 	}
+
+	if watcher.watchdog != nil {
+		watcher.watchdog.update(temps)
+	}
 }
 
 func (watcher *tempWatcher) setupHeaters(temps map[string]any) heatersObject {
@@ -201,17 +210,17 @@ func (watcher *tempWatcher) setupHeaters(temps map[string]any) heatersObject {
 		case parser.Heater:
 			heaters = append(heaters, name)
 			obj := &heaterObject{&sync.RWMutex{}, temp.Temperature, temp.Target, temp.Power}
-			printer_objects.RegisterObject(name, obj)
+			watcher.printer.Objects.RegisterObject(name, obj)
 			watcher.heaterObjects[name] = obj
 
 		case parser.Sensor:
 			obj := &temperatureSensorObject{&sync.RWMutex{}, temp.Temperature, temp.Temperature, temp.Temperature}
-			printer_objects.RegisterObject(name, obj)
+			watcher.printer.Objects.RegisterObject(name, obj)
 			watcher.sensorObjects[name] = obj
 		}
 	}
 
 	obj := heatersObject{heaters, sensors}
-	printer_objects.RegisterObject("heaters", obj)
+	watcher.printer.Objects.RegisterObject("heaters", obj)
 	return obj
 }
@@ -0,0 +1,164 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/util"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	targetReachedWindow = 2.
+	minTemperatureRise  = 2.
+)
+
+var disconnectedReadings = []float64{-14, 999}
+
+type heaterSafetyState struct {
+	target     float64
+	since      time.Time
+	holding    bool
+	lastTemp   float64
+	lastChange time.Time
+}
+
+type thermalWatchdog struct {
+	printer    *Printer
+	config     config.ThermalSafety
+	states     map[string]*heaterSafetyState
+	mu         *sync.Mutex
+	lastReport util.ThreadSafe[time.Time]
+	triggered  atomic.Bool
+}
+
+func newThermalWatchdog(printer *Printer) *thermalWatchdog {
+	return &thermalWatchdog{
+		printer:    printer,
+		config:     printer.config.Printer.ThermalSafety,
+		states:     make(map[string]*heaterSafetyState),
+		mu:         &sync.Mutex{},
+		lastReport: util.NewThreadSafe(time.Now()),
+	}
+}
+
+func (watchdog *thermalWatchdog) update(temps map[string]any) {
+	now := time.Now()
+	watchdog.lastReport.Store(now)
+	for name, temp := range temps {
+		if heater, isHeater := temp.(parser.Heater); isHeater {
+			if err := watchdog.check(name, heater, now); err != nil {
+				watchdog.trigger(err)
+				return
+			}
+		}
+	}
+}
+
+func (watchdog *thermalWatchdog) checkReports() {
+	timeout := time.Duration(watchdog.config.ReportTimeout * float64(time.Second))
+	if elapsed := time.Since(watchdog.lastReport.Load()); elapsed > timeout {
+		watchdog.trigger(fmt.Errorf("no temperature report received for %.0f seconds", elapsed.Seconds()))
+	}
+}
+
+func (watchdog *thermalWatchdog) check(name string, heater parser.Heater, now time.Time) error {
+	temperature, target := heater.Temperature, heater.Target
+
+	if lo.ContainsBy(disconnectedReadings, func(reading float64) bool { return math.Abs(temperature-reading) < 0.01 }) {
+		return fmt.Errorf("%s reads %.1f, sensor may be disconnected", name, temperature)
+	}
+	if limits, exists := watchdog.heaterLimits(name); exists &&
+		(temperature < float64(limits.MinTemp) || temperature > float64(limits.MaxTemp)) {
+		return fmt.Errorf("%s temperature %.1f is outside of the allowed range %d-%d", name, temperature, limits.MinTemp, limits.MaxTemp)
+	}
+
+	watchdog.mu.Lock()
+	defer watchdog.mu.Unlock()
+
+	state, exists := watchdog.states[name]
+	if !exists {
+		state = &heaterSafetyState{target: target, since: now, lastTemp: temperature, lastChange: now}
+		watchdog.states[name] = state
+	}
+	if target != state.target {
+		state.target, state.since, state.holding, state.lastChange = target, now, false, now
+	}
+	if temperature >= state.lastTemp+minTemperatureRise {
+		state.lastTemp, state.lastChange = temperature, now
+	} else if temperature < state.lastTemp {
+		state.lastTemp = temperature
+	}
+	if target <= 0 {
+		return nil
+	}
+
+	switch {
+	case state.holding:
+		if math.Abs(temperature-target) > watchdog.config.MaxDeviation {
+			return fmt.Errorf("%s temperature %.1f deviates from target %.1f by more than %.1f", name, temperature, target, watchdog.config.MaxDeviation)
+		}
+	case math.Abs(temperature-target) <= targetReachedWindow:
+		state.holding = true
+	case temperature < target:
+		if now.Sub(state.since).Seconds() > watchdog.config.HeatingTimeout {
+			return fmt.Errorf("%s did not reach target %.1f within %.0f seconds", name, target, watchdog.config.HeatingTimeout)
+		}
+		if now.Sub(state.lastChange).Seconds() > watchdog.config.StuckTimeout {
+			return fmt.Errorf("%s temperature is stuck at %.1f while heating", name, temperature)
+		}
+	}
+	return nil
+}
+
+func (watchdog *thermalWatchdog) heaterLimits(name string) (config.Heater, bool) {
+	switch {
+	case strings.HasPrefix(name, "extruder"):
+		return watchdog.printer.config.Printer.Extruder.Heater, true
+	case name == "heater_bed":
+		return watchdog.printer.config.Printer.HeaterBed.Heater, true
+	}
+	return config.Heater{}, false
+}
+
+func (watchdog *thermalWatchdog) trigger(err error) {
+	if watchdog.triggered.Swap(true) {
+		return
+	}
+
+	message := "Thermal safety: " + err.Error()
+	log.Errorln(message)
+	if err := watchdog.printer.Respond("!! " + message); err != nil {
+		log.Errorf("Failed to send response: %v", err)
+	}
+	for _, gcode := range heatersOffGcode(watchdog.printer.heaters.availableHeaters) {
+		if err := watchdog.printer.protocol.writeRaw(gcode); err != nil {
+			log.Errorf("Failed to turn off heaters: %v", err)
+		}
+	}
+	watchdog.printer.setError(errors.New(message))
+	watchdog.printer.PrintManager.Abort()
+	go watchdog.printer.EmergencyStop()
+}
+
+func heatersOffGcode(heaters []string) []string {
+	gcodes := make([]string, 0, len(heaters))
+	for _, name := range heaters {
+		switch {
+		case name == "extruder":
+			gcodes = append(gcodes, "M104 S0")
+		case strings.HasPrefix(name, "extruder"):
+			gcodes = append(gcodes, "M104 T"+name[8:]+" S0")
+		case name == "heater_bed":
+			gcodes = append(gcodes, "M140 S0")
+		}
+	}
+	return gcodes
+}
@@ -0,0 +1,139 @@
+package printer
+
+import (
+	"bytes"
+	"github.com/samber/lo"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/marlinraker/gcode_store"
+	"marlinraker/src/printer/macros"
+	"marlinraker/src/printer/parser"
+	"marlinraker/src/printer/print_manager"
+	"marlinraker/src/printer_objects"
+	"marlinraker/src/util"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestWatchdog() *thermalWatchdog {
+	cfg := config.DefaultConfig()
+	return &thermalWatchdog{
+		printer: &Printer{config: cfg},
+		config:  cfg.Printer.ThermalSafety,
+		states:  make(map[string]*heaterSafetyState),
+		mu:      &sync.Mutex{},
+	}
+}
+
+func heaterReading(temperature float64, target float64) parser.Heater {
+	return parser.Heater{Sensor: parser.Sensor{Temperature: temperature}, Target: target}
+}
+
+func TestThermalWatchdogReadings(t *testing.T) {
+	watchdog, now := newTestWatchdog(), time.Now()
+	assert.NilError(t, watchdog.check("extruder", heaterReading(25, 0), now))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(0, 0), now))
+	assert.Error(t, watchdog.check("extruder", heaterReading(-14, 0), now), "extruder reads -14.0, sensor may be disconnected")
+	assert.Error(t, watchdog.check("extruder", heaterReading(999, 0), now), "extruder reads 999.0, sensor may be disconnected")
+	assert.Error(t, watchdog.check("extruder", heaterReading(-5, 0), now), "extruder temperature -5.0 is outside of the allowed range 0-250")
+	assert.Error(t, watchdog.check("heater_bed", heaterReading(120, 0), now), "heater_bed temperature 120.0 is outside of the allowed range 0-100")
+	assert.NilError(t, watchdog.check("temperature_sensor", heaterReading(120, 0), now))
+}
+
+func TestThermalWatchdogHeating(t *testing.T) {
+	watchdog, start := newTestWatchdog(), time.Now()
+	at := func(seconds float64) time.Time { return start.Add(time.Duration(seconds * float64(time.Second))) }
+
+	assert.NilError(t, watchdog.check("extruder", heaterReading(25, 0), at(0)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(25, 200), at(1)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(100, 200), at(50)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(100, 200), at(100)))
+	assert.Error(t, watchdog.check("extruder", heaterReading(100, 200), at(111)), "extruder temperature is stuck at 100.0 while heating")
+
+	watchdog = newTestWatchdog()
+	for seconds := 0.; seconds <= 60; seconds += 10 {
+		assert.NilError(t, watchdog.check("extruder", heaterReading(100+seconds/40, 200), at(seconds)))
+	}
+	assert.Error(t, watchdog.check("extruder", heaterReading(101.6, 200), at(61)), "extruder temperature is stuck at 101.6 while heating")
+
+	watchdog = newTestWatchdog()
+	assert.NilError(t, watchdog.check("extruder", heaterReading(100, 200), at(0)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(99, 200), at(50)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(101, 200), at(55)))
+	assert.Error(t, watchdog.check("extruder", heaterReading(100.5, 200), at(116)), "extruder temperature is stuck at 100.5 while heating")
+
+	watchdog = newTestWatchdog()
+	for seconds := 0.; seconds <= 300; seconds += 30 {
+		assert.NilError(t, watchdog.check("extruder", heaterReading(25+seconds/3, 200), at(seconds)))
+	}
+	assert.Error(t, watchdog.check("extruder", heaterReading(130, 200), at(301)), "extruder did not reach target 200.0 within 300 seconds")
+}
+
+func TestThermalWatchdogHolding(t *testing.T) {
+	watchdog, start := newTestWatchdog(), time.Now()
+	at := func(seconds float64) time.Time { return start.Add(time.Duration(seconds * float64(time.Second))) }
+
+	assert.NilError(t, watchdog.check("extruder", heaterReading(199, 200), at(0)))
+	assert.Assert(t, watchdog.states["extruder"].holding)
+	assert.NilError(t, watchdog.check("extruder", heaterReading(200.1, 200), at(10)))
+	assert.NilError(t, watchdog.check("extruder", heaterReading(186, 200), at(20)))
+	assert.Error(t, watchdog.check("extruder", heaterReading(180, 200), at(30)), "extruder temperature 180.0 deviates from target 200.0 by more than 15.0")
+
+	assert.NilError(t, watchdog.check("extruder", heaterReading(180, 0), at(40)))
+	assert.Assert(t, !watchdog.states["extruder"].holding)
+	assert.NilError(t, watchdog.check("extruder", heaterReading(180, 0), at(200)))
+
+	assert.NilError(t, watchdog.check("extruder", heaterReading(210, 210), at(210)))
+	for seconds := 220.; seconds <= 1000; seconds += 10 {
+		assert.NilError(t, watchdog.check("extruder", heaterReading(210, 210), at(seconds)))
+	}
+}
+
+func TestHeatersOffGcode(t *testing.T) {
+	assert.DeepEqual(t, heatersOffGcode([]string{"extruder", "extruder1", "heater_bed", "temperature_sensor x"}),
+		[]string{"M104 S0", "M104 T1 S0", "M140 S0"})
+}
+
+func TestThermalWatchdogTrigger(t *testing.T) {
+	setupDatabase(t)
+	cfg := config.DefaultConfig()
+	cfg.Printer.HeaterBed.MaxTemp = 60
+	writer := &recordingWriter{Writer: &bytes.Buffer{}}
+	printer := &Printer{
+		config:       cfg,
+		Objects:      printer_objects.NewRegistry(""),
+		MacroManager: &macros.MacroManager{},
+		protocol:     newSerialProtocol(writer, "test", false),
+		heaters:      heatersObject{availableHeaters: []string{"extruder", "extruder1", "heater_bed"}},
+		context:      util.NewThreadSafe[*executorContext](nil),
+		err:          util.NewThreadSafe[error](nil),
+	}
+	printer.PrintManager = print_manager.NewPrintManager(printer, cfg)
+	context := newExecutorContext(printer, "main")
+	printer.context.Store(context)
+	t.Cleanup(func() {
+		printer.PrintManager.Cleanup(nil)
+		context.close()
+	})
+	watchdog := newThermalWatchdog(printer)
+	countErrors := func() int {
+		return lo.CountBy(gcode_store.GcodeStore, func(log gcode_store.GcodeLog) bool {
+			return strings.HasPrefix(log.Message, "!! Thermal safety: ")
+		})
+	}
+	errors := countErrors()
+
+	watchdog.update(map[string]any{"heater_bed": heaterReading(50, 50), "extruder": heaterReading(25, 0)})
+	assert.NilError(t, printer.GetError())
+
+	watchdog.update(map[string]any{"heater_bed": heaterReading(80, 50)})
+	watchdog.update(map[string]any{"extruder": heaterReading(999, 0)})
+	waitFor(t, func() bool {
+		return lo.Contains(writer.Lines(), "M112")
+	})
+	assert.DeepEqual(t, writer.Lines(), []string{"M104 S0", "M104 T1 S0", "M140 S0", "M112"})
+	assert.Error(t, printer.GetError(), "Thermal safety: heater_bed temperature 80.0 is outside of the allowed range 0-60")
+	assert.Equal(t, countErrors(), errors+1)
+}
@@ -0,0 +1,799 @@
+package virtual
+
+import (
+	"fmt"
+	"marlinraker/src/config"
+	"marlinraker/src/constants"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	commandBufferSize = 4
+	plannerBufferSize = 16
+	tickInterval      = 100 * time.Millisecond
+	fanCount          = 2
+	fanMaxRpm         = 6000
+	meshSize          = 3
+)
+
+var (
+	lineNumberRegex = regexp.MustCompile(`^N([0-9]+)\s+`)
+	checksumRegex   = regexp.MustCompile(`\*([0-9]+)$`)
+	commandRegex    = regexp.MustCompile(`^([GMT][0-9]+)`)
+	paramRegex      = regexp.MustCompile(`(?:^|\s)([A-Z])([+-]?[0-9.]*)`)
+
+	defaultCapabilities = map[string]bool{
+		"SERIAL_XON_XOFF":       false,
+		"BINARY_FILE_TRANSFER":  false,
+		"EEPROM":                true,
+		"VOLUMETRIC":            true,
+		"AUTOREPORT_POS":        false,
+		"AUTOREPORT_TEMP":       true,
+		"PROGRESS":              false,
+		"PRINT_JOB":             true,
+		"AUTOLEVEL":             true,
+		"RUNOUT":                false,
+		"Z_PROBE":               true,
+		"LEVELING_DATA":         true,
+		"BUILD_PERCENT":         false,
+		"SOFTWARE_POWER":        false,
+		"TOGGLE_LIGHTS":         false,
+		"CASE_LIGHT_BRIGHTNESS": false,
+		"EMERGENCY_PARSER":      true,
+		"HOST_ACTION_COMMANDS":  true,
+		"PROMPT_SUPPORT":        false,
+		"SDCARD":                false,
+		"AUTOREPORT_SD_STATUS":  false,
+		"THERMAL_PROTECTION":    true,
+		"MOTION_MODES":          false,
+		"ARCS":                  false,
+		"BABYSTEPPING":          false,
+		"CHAMBER_TEMPERATURE":   false,
+		"ADVANCED_OK":           false,
+	}
+
+	noopCommands = map[string]bool{
+		"G4": true, "G21": true, "M17": true, "M18": true, "M84": true,
+		"M117": true, "M220": true, "M221": true, "M400": true, "M876": true, "T0": true,
+	}
+
+	settingCommands = []struct {
+		command string
+		params  string
+	}{
+		{"M92", "XYZE"}, {"M203", "XYZE"}, {"M201", "XYZE"}, {"M204", "PRT"}, {"M205", "BSTJ"},
+		{"M206", "XYZ"}, {"M301", "PID"}, {"M304", "PID"}, {"M851", "XYZ"}, {"M900", "K"},
+	}
+
+	defaultSettings = map[string]map[string]float64{
+		"M92":  {"X": 80, "Y": 80, "Z": 400, "E": 93},
+		"M203": {"X": 300, "Y": 300, "Z": 5, "E": 25},
+		"M201": {"X": 3000, "Y": 3000, "Z": 100, "E": 10000},
+		"M204": {"P": 3000, "R": 3000, "T": 3000},
+		"M205": {"B": 20000, "S": 0, "T": 0, "J": 0.01},
+		"M206": {"X": 0, "Y": 0, "Z": 0},
+		"M301": {"P": 22.2, "I": 1.08, "D": 114},
+		"M304": {"P": 10, "I": 0.02, "D": 305.4},
+		"M851": {"X": -40, "Y": -10, "Z": -1.5},
+		"M900": {"K": 0.05},
+	}
+)
+
+type heater struct {
+	temperature float64
+	target      float64
+}
+
+type firmware struct {
+	port            *Port
+	config          config.VirtualPrinter
+	capabilities    map[string]bool
+	commandCh       chan string
+	mu              *sync.Mutex
+	hotend          heater
+	bed             heater
+	fans            [fanCount]float64
+	progress        [2]float64
+	mesh            [][]float64
+	meshActive      bool
+	settingValues   map[string]map[string]float64
+	eeprom          map[string]map[string]float64
+	position        [4]float64
+	feedrate        float64
+	absolute        bool
+	absoluteExtrude bool
+	lastLine        int
+	autoReport      time.Duration
+	lastReport      time.Time
+	fanReport       time.Duration
+	lastFanReport   time.Time
+	halted          atomic.Bool
+	abortWait       atomic.Bool
+	abortMove       atomic.Bool
+}
+
+func newFirmware(port *Port, cfg config.VirtualPrinter) *firmware {
+	capabilities := make(map[string]bool)
+	for name, enabled := range defaultCapabilities {
+		capabilities[name] = enabled
+	}
+	for name, enabled := range cfg.Capabilities {
+		capabilities[strings.ToUpper(name)] = enabled
+	}
+
+	return &firmware{
+		port:            port,
+		config:          cfg,
+		capabilities:    capabilities,
+		commandCh:       make(chan string, 64),
+		mu:              &sync.Mutex{},
+		hotend:          heater{temperature: cfg.AmbientTemp},
+		bed:             heater{temperature: cfg.AmbientTemp},
+		feedrate:        1500,
+		absolute:        true,
+		absoluteExtrude: true,
+		settingValues:   copySettings(defaultSettings),
+		eeprom:          copySettings(defaultSettings),
+	}
+}
+
+func (firmware *firmware) run() {
+	for {
+		select {
+		case <-firmware.port.closeCh:
+			return
+		case line := <-firmware.commandCh:
+			if firmware.halted.Load() {
+				firmware.port.send("echo:Printer halted. kill() called!")
+				continue
+			}
+			if gcode, ok := firmware.checkLine(line); ok {
+				firmware.execute(gcode)
+			}
+		}
+	}
+}
+
+func (firmware *firmware) checkLine(line string) (string, bool) {
+	match := lineNumberRegex.FindStringSubmatch(line)
+	if match == nil {
+		return line, true
+	}
+
+	lineNumber, _ := strconv.Atoi(match[1])
+	gcode := strings.TrimSpace(line[len(match[0]):])
+
+	if checksumMatch := checksumRegex.FindStringSubmatch(gcode); checksumMatch != nil {
+		expected, _ := strconv.Atoi(checksumMatch[1])
+		framed := line[:len(line)-len(checksumMatch[0])]
+		checksum := 0
+		for i := 0; i < len(framed); i++ {
+			checksum ^= int(framed[i])
+		}
+		if checksum != expected {
+			firmware.requestResend("checksum mismatch")
+			return "", false
+		}
+		gcode = strings.TrimSpace(gcode[:len(gcode)-len(checksumMatch[0])])
+	} else {
+		firmware.requestResend("No Checksum with line number")
+		return "", false
+	}
+
+	if strings.HasPrefix(gcode, "M110") {
+		firmware.lastLine = lineNumber
+		if n, exists := params(gcode)["N"]; exists {
+			firmware.lastLine = int(n)
+		}
+		return gcode, true
+	}
+
+	if lineNumber != firmware.lastLine+1 {
+		firmware.requestResend("Line Number is not Last Line Number+1")
+		return "", false
+	}
+	firmware.lastLine = lineNumber
+	return gcode, true
+}
+
+func (firmware *firmware) requestResend(reason string) {
+	firmware.port.send(
+		fmt.Sprintf("Error:%s, Last Line: %d", reason, firmware.lastLine),
+		fmt.Sprintf("Resend: %d", firmware.lastLine+1),
+		firmware.ok(),
+	)
+}
+
+func (firmware *firmware) ok() string {
+	if !firmware.capabilities["ADVANCED_OK"] {
+		return "ok"
+	}
+	bufferFree := min(commandBufferSize, cap(firmware.commandCh)-len(firmware.commandCh))
+	return fmt.Sprintf("ok N%d P%d B%d", firmware.lastLine, plannerBufferSize, bufferFree)
+}
+
+func (firmware *firmware) execute(gcode string) {
+	match := commandRegex.FindStringSubmatch(gcode)
+	if match == nil {
+		firmware.port.send(fmt.Sprintf("echo:Unknown command: %q", gcode), firmware.ok())
+		return
+	}
+
+	command, args := match[1], params(gcode)
+	switch command {
+	case "G0", "G1":
+		firmware.move(args)
+	case "G28":
+		firmware.home(args)
+	case "G29":
+		firmware.probeMesh()
+	case "M420":
+		firmware.mu.Lock()
+		if enable, exists := args["S"]; exists {
+			firmware.meshActive = enable != 0 && firmware.mesh != nil
+		}
+		firmware.mu.Unlock()
+		if _, exists := args["V"]; exists {
+			firmware.port.send(firmware.meshReport()...)
+		}
+		firmware.port.send(firmware.levelingState())
+	case "M421":
+		firmware.setMeshPoint(args)
+	case "M303":
+		firmware.autotune(args)
+	case "G90":
+		firmware.mu.Lock()
+		firmware.absolute, firmware.absoluteExtrude = true, true
+		firmware.mu.Unlock()
+	case "G91":
+		firmware.mu.Lock()
+		firmware.absolute, firmware.absoluteExtrude = false, false
+		firmware.mu.Unlock()
+	case "G92":
+		firmware.mu.Lock()
+		for i, axis := range "XYZE" {
+			if value, exists := args[string(axis)]; exists {
+				firmware.position[i] = value
+			}
+		}
+		firmware.mu.Unlock()
+	case "M73":
+		firmware.mu.Lock()
+		for i, param := range "PR" {
+			if value, exists := args[string(param)]; exists {
+				firmware.progress[i] = value
+			}
+		}
+		progress := firmware.progress
+		firmware.mu.Unlock()
+		if len(args) == 0 {
+			firmware.port.send(fmt.Sprintf("echo: M73 Progress: %d%%; Time left: %dm;", int(progress[0]), int(progress[1])))
+		}
+	case "M82", "M83":
+		firmware.mu.Lock()
+		firmware.absoluteExtrude = command == "M82"
+		firmware.mu.Unlock()
+	case "M104", "M109":
+		firmware.setTarget(&firmware.hotend, args, command == "M109")
+	case "M140", "M190":
+		firmware.setTarget(&firmware.bed, args, command == "M190")
+	case "M105":
+		firmware.port.send("ok " + firmware.temperatures())
+		return
+	case "M106", "M107":
+		index, speed := int(args["P"]), 0.
+		if command == "M106" {
+			speed = 255
+			if s, exists := args["S"]; exists {
+				speed = s
+			}
+		}
+		if index < fanCount {
+			firmware.mu.Lock()
+			firmware.fans[index] = math.Min(math.Max(speed/255, 0), 1)
+			firmware.mu.Unlock()
+		}
+	case "M123":
+		firmware.mu.Lock()
+		firmware.fanReport = time.Duration(args["S"]) * time.Second
+		firmware.mu.Unlock()
+		if _, exists := args["S"]; !exists {
+			firmware.port.send(firmware.tachometers())
+		}
+	case "M108", "M112", "M410":
+		firmware.emergency(command)
+	case "M110":
+		if n, exists := args["N"]; exists {
+			firmware.lastLine = int(n)
+		}
+	case "M114":
+		firmware.port.send(firmware.positionReport())
+	case "M115":
+		firmware.port.send(firmware.identify()...)
+	case "M118":
+		firmware.port.send("echo:" + strings.TrimSpace(strings.TrimPrefix(gcode, command)))
+	case "M155":
+		firmware.mu.Lock()
+		firmware.autoReport = time.Duration(args["S"]) * time.Second
+		firmware.mu.Unlock()
+	case "M92", "M201", "M203", "M204", "M205", "M206", "M301", "M304", "M851", "M900":
+		if z, exists := args["Z"]; exists && command == "M851" && (z < -20 || z > 20) {
+			firmware.port.send("echo:?Z out of range (-20 to 20)")
+			break
+		}
+		firmware.mu.Lock()
+		if accel, exists := args["S"]; exists && command == "M204" {
+			args["P"], args["T"] = accel, accel
+		}
+		values := firmware.settingValues[command]
+		for param, value := range args {
+			if _, exists := values[param]; exists {
+				values[param] = value
+			}
+		}
+		firmware.mu.Unlock()
+	case "M500":
+		firmware.mu.Lock()
+		firmware.eeprom = copySettings(firmware.settingValues)
+		firmware.mu.Unlock()
+		firmware.port.send("echo:Settings Stored (616 bytes; crc 48137)")
+	case "M501":
+		firmware.mu.Lock()
+		firmware.settingValues = copySettings(firmware.eeprom)
+		firmware.mu.Unlock()
+		firmware.port.send("echo:V86 stored settings retrieved (616 bytes; crc 48137)")
+	case "M502":
+		firmware.mu.Lock()
+		firmware.settingValues = copySettings(defaultSettings)
+		firmware.mu.Unlock()
+		firmware.port.send("echo:Hardcoded Default Settings Loaded")
+	case "M503":
+		firmware.port.send(firmware.settings()...)
+	default:
+		if !noopCommands[command] {
+			firmware.port.send(fmt.Sprintf("echo:Unknown command: %q", gcode))
+		}
+	}
+
+	if !firmware.halted.Load() {
+		firmware.port.send(firmware.ok())
+	}
+}
+
+func (firmware *firmware) emergency(command string) {
+	switch {
+	case strings.HasPrefix(command, "M112"):
+		firmware.halted.Store(true)
+		firmware.abortWait.Store(true)
+		firmware.abortMove.Store(true)
+		firmware.mu.Lock()
+		firmware.hotend.target, firmware.bed.target = 0, 0
+		firmware.mu.Unlock()
+		firmware.port.send("Error:Printer halted. kill() called!")
+	case strings.HasPrefix(command, "M108"):
+		firmware.abortWait.Store(true)
+	case strings.HasPrefix(command, "M410"):
+		firmware.abortMove.Store(true)
+	}
+}
+
+func (firmware *firmware) move(args map[string]float64) {
+	firmware.mu.Lock()
+	if feedrate, exists := args["F"]; exists && feedrate > 0 {
+		firmware.feedrate = feedrate
+	}
+	from, to := firmware.position, firmware.position
+	for i, axis := range "XYZE" {
+		value, exists := args[string(axis)]
+		if !exists {
+			continue
+		}
+		if (i < 3 && firmware.absolute) || (i == 3 && firmware.absoluteExtrude) {
+			to[i] = value
+		} else {
+			to[i] += value
+		}
+	}
+	feedrate := firmware.feedrate
+	firmware.mu.Unlock()
+
+	distance := math.Sqrt(math.Pow(to[0]-from[0], 2) + math.Pow(to[1]-from[1], 2) + math.Pow(to[2]-from[2], 2))
+	if distance == 0 {
+		distance = math.Abs(to[3] - from[3])
+	}
+	firmware.travel(from, to, distance/(feedrate/60))
+}
+
+func (firmware *firmware) home(args map[string]float64) {
+	firmware.mu.Lock()
+	from, to := firmware.position, firmware.position
+	_, homeX := args["X"]
+	_, homeY := args["Y"]
+	_, homeZ := args["Z"]
+	homeAll := !homeX && !homeY && !homeZ
+	for i, axis := range "XYZ" {
+		if _, exists := args[string(axis)]; exists || homeAll {
+			to[i] = 0
+		}
+	}
+	firmware.mu.Unlock()
+
+	distance := math.Max(math.Abs(from[0]-to[0]), math.Max(math.Abs(from[1]-to[1]), math.Abs(from[2]-to[2])))
+	firmware.travel(from, to, 1+distance/50)
+}
+
+func (firmware *firmware) travel(from [4]float64, to [4]float64, seconds float64) {
+	firmware.abortMove.Store(false)
+	duration := time.Duration(seconds * firmware.config.TimeScale * float64(time.Second))
+	start := time.Now()
+
+	for elapsed := time.Duration(0); elapsed < duration; elapsed = time.Since(start) {
+		if firmware.abortMove.Load() || !firmware.sleep(min(tickInterval, duration-elapsed)) {
+			return
+		}
+		progress := float64(time.Since(start)) / float64(duration)
+		firmware.mu.Lock()
+		for i := range firmware.position {
+			firmware.position[i] = from[i] + (to[i]-from[i])*math.Min(progress, 1)
+		}
+		firmware.mu.Unlock()
+	}
+
+	firmware.mu.Lock()
+	firmware.position = to
+	firmware.mu.Unlock()
+}
+
+func (firmware *firmware) probeMesh() {
+	mesh := make([][]float64, meshSize)
+	for j := range mesh {
+		mesh[j] = make([]float64, meshSize)
+		for i := range mesh[j] {
+			mesh[j][i] = math.Round((0.05*float64(j)-0.04*float64(i)+0.01*float64(i*j))*1000) / 1000
+		}
+	}
+	firmware.mu.Lock()
+	firmware.mesh, firmware.meshActive = mesh, false
+	firmware.mu.Unlock()
+}
+
+func (firmware *firmware) setMeshPoint(args map[string]float64) {
+	i, j := int(args["I"]), int(args["J"])
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	if firmware.mesh == nil {
+		firmware.mesh = make([][]float64, meshSize)
+		for row := range firmware.mesh {
+			firmware.mesh[row] = make([]float64, meshSize)
+		}
+	}
+	if i < 0 || j < 0 || i >= meshSize || j >= meshSize {
+		firmware.port.send("echo:?(I,J) out of range.")
+		return
+	}
+	firmware.mesh[j][i] = args["Z"]
+}
+
+func (firmware *firmware) meshReport() []string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	if firmware.mesh == nil {
+		return nil
+	}
+	lines := []string{"Bilinear Leveling Grid:"}
+	header := "    "
+	for i := 0; i < meshSize; i++ {
+		header += fmt.Sprintf("  %d    ", i)
+	}
+	lines = append(lines, header)
+	for j, row := range firmware.mesh {
+		line := fmt.Sprintf(" %d", j)
+		for _, z := range row {
+			line += fmt.Sprintf(" %+.3f", z)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (firmware *firmware) levelingState() string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	if firmware.meshActive {
+		return "echo:Bed Leveling ON"
+	}
+	return "echo:Bed Leveling OFF"
+}
+
+func (firmware *firmware) setTarget(heater *heater, args map[string]float64, wait bool) {
+	target, exists := args["S"]
+	waitForCooling := false
+	if !exists {
+		target, waitForCooling = args["R"], true
+	}
+
+	firmware.mu.Lock()
+	heater.target = target
+	firmware.mu.Unlock()
+
+	if !wait {
+		return
+	}
+
+	firmware.abortWait.Store(false)
+	lastReport := time.Now()
+	for {
+		firmware.mu.Lock()
+		reached := heater.temperature >= heater.target-1 &&
+			(!waitForCooling || heater.temperature <= heater.target+1)
+		firmware.mu.Unlock()
+		if reached || firmware.abortWait.Load() {
+			return
+		}
+		if time.Since(lastReport) >= time.Second {
+			firmware.port.send(" " + firmware.temperatures() + " W:?")
+			lastReport = time.Now()
+		}
+		if !firmware.sleep(tickInterval) {
+			return
+		}
+	}
+}
+
+func (firmware *firmware) autotune(args map[string]float64) {
+	target, cycles := args["S"], int(args["C"])
+	if cycles == 0 {
+		cycles = 5
+	}
+	cycles = min(max(cycles, 3), 20)
+
+	heater, prefix, maxTemp, ku, tu := &firmware.hotend, "", 275., 37., 20.5
+	switch int(args["E"]) {
+	case 0:
+	case -1:
+		heater, prefix, maxTemp, ku, tu = &firmware.bed, "bed", 110, 120, 60
+	default:
+		firmware.port.send("PID Autotune failed! Bad extruder number")
+		return
+	}
+	if target > maxTemp {
+		firmware.port.send("PID Autotune failed! Temperature too high")
+		return
+	}
+
+	firmware.port.send("PID Autotune start")
+	firmware.setTarget(heater, map[string]float64{"S": target}, true)
+	defer func() {
+		firmware.mu.Lock()
+		heater.target = 0
+		firmware.mu.Unlock()
+	}()
+
+	kp, ki, kd := 0.6*ku, 1.2*ku/tu, 0.075*ku*tu
+	for cycle := 0; cycle < cycles; cycle++ {
+		if firmware.abortWait.Load() || !firmware.sleep(time.Duration(tu*firmware.config.TimeScale*float64(time.Second))) {
+			firmware.port.send("PID Autotune failed! Interrupted")
+			return
+		}
+		line := fmt.Sprintf(" bias: %d d: %d min: %.2f max: %.2f", 92-cycle%3, 92-cycle%3, target-3.5, target+3.7)
+		if cycle == 0 {
+			firmware.port.send(line)
+			continue
+		}
+		firmware.port.send(
+			line+fmt.Sprintf(" Ku: %.2f Tu: %.2f", ku, tu),
+			" Classic PID",
+			fmt.Sprintf(" Kp: %.2f Ki: %.2f Kd: %.2f", kp, ki, kd),
+		)
+	}
+	firmware.port.send(
+		"PID Autotune finished! Put the last Kp, Ki and Kd constants from below into Configuration.h",
+		fmt.Sprintf("#define DEFAULT_%sKp %.2f", prefix, kp),
+		fmt.Sprintf("#define DEFAULT_%sKi %.2f", prefix, ki),
+		fmt.Sprintf("#define DEFAULT_%sKd %.2f", prefix, kd),
+	)
+}
+
+func (firmware *firmware) simulate() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-firmware.port.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		firmware.mu.Lock()
+		for _, heater := range []*heater{&firmware.hotend, &firmware.bed} {
+			firmware.updateHeater(heater, tickInterval.Seconds())
+		}
+		report := firmware.autoReport > 0 && time.Since(firmware.lastReport) >= firmware.autoReport
+		if report {
+			firmware.lastReport = time.Now()
+		}
+		fanReport := firmware.fanReport > 0 && time.Since(firmware.lastFanReport) >= firmware.fanReport
+		if fanReport {
+			firmware.lastFanReport = time.Now()
+		}
+		firmware.mu.Unlock()
+
+		if report {
+			firmware.port.send(" " + firmware.temperatures())
+		}
+		if fanReport {
+			firmware.port.send(firmware.tachometers())
+		}
+	}
+}
+
+func (firmware *firmware) updateHeater(heater *heater, seconds float64) {
+	target := math.Max(heater.target, firmware.config.AmbientTemp)
+	if firmware.config.TimeScale <= 0 {
+		heater.temperature = target
+		return
+	}
+
+	step := firmware.config.HeatingRate * seconds / firmware.config.TimeScale
+	if heater.temperature < target {
+		heater.temperature = math.Min(heater.temperature+step, target)
+	} else {
+		heater.temperature = math.Max(heater.temperature-step/2, target)
+	}
+}
+
+func (firmware *firmware) sleep(duration time.Duration) bool {
+	if duration <= 0 {
+		return true
+	}
+	select {
+	case <-firmware.port.closeCh:
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+func (firmware *firmware) temperatures() string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	return fmt.Sprintf("T:%.2f /%.2f B:%.2f /%.2f @:%d B@:%d",
+		firmware.hotend.temperature, firmware.hotend.target,
+		firmware.bed.temperature, firmware.bed.target,
+		power(firmware.hotend), power(firmware.bed))
+}
+
+func (firmware *firmware) tachometers() string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	hotendFan := 0
+	if firmware.hotend.temperature >= 50 {
+		hotendFan = fanMaxRpm
+	}
+	report := fmt.Sprintf("E0:%d RPM", hotendFan)
+	for i, speed := range firmware.fans {
+		report += fmt.Sprintf(" P%d:%d RPM", i, int(speed*fanMaxRpm))
+	}
+	return report
+}
+
+func (firmware *firmware) positionReport() string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+	position := firmware.position
+	return fmt.Sprintf("X:%.2f Y:%.2f Z:%.2f E:%.2f Count X:%d Y:%d Z:%d",
+		position[0], position[1], position[2], position[3],
+		int(position[0]*80), int(position[1]*80), int(position[2]*400))
+}
+
+func (firmware *firmware) identify() []string {
+	lines := []string{fmt.Sprintf("FIRMWARE_NAME:Marlin %s (virtual) SOURCE_CODE_URL:%s PROTOCOL_VERSION:1.0 "+
+		"MACHINE_TYPE:%s EXTRUDER_COUNT:1 UUID:00000000-0000-0000-0000-000000000000",
+		constants.Version, "github.com/pauhull/marlinraker-go", firmware.config.MachineType)}
+
+	names := make([]string, 0, len(firmware.capabilities))
+	for name := range firmware.capabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		enabled := 0
+		if firmware.capabilities[name] {
+			enabled = 1
+		}
+		lines = append(lines, fmt.Sprintf("Cap:%s:%d", name, enabled))
+	}
+	return lines
+}
+
+func (firmware *firmware) settings() []string {
+	firmware.mu.Lock()
+	defer firmware.mu.Unlock()
+
+	leveling := 0
+	if firmware.meshActive {
+		leveling = 1
+	}
+	values := make(map[string]string, len(settingCommands))
+	for _, setting := range settingCommands {
+		line := "echo:  " + setting.command
+		for _, param := range setting.params {
+			line += fmt.Sprintf(" %c%.2f", param, firmware.settingValues[setting.command][string(param)])
+		}
+		values[setting.command] = line
+	}
+
+	return []string{
+		"echo:; Linear Units:",
+		"echo:  G21 ; (mm)",
+		"echo:; Steps per unit:",
+		values["M92"],
+		"echo:; Filament settings: Disabled",
+		"echo:  M200 S0 D1.75",
+		"echo:; Max feedrates (units/s):",
+		values["M203"],
+		"echo:; Max Acceleration (units/s2):",
+		values["M201"],
+		"echo:; Acceleration (units/s2) (P<print-accel> R<retract-accel> T<travel-accel>):",
+		values["M204"],
+		"echo:; Advanced (B<min_segment_time_us> S<min_feedrate> T<min_travel_feedrate> J<junc_dev>):",
+		values["M205"],
+		"echo:; Home offset:",
+		values["M206"],
+		"echo:; Hotend PID:",
+		values["M301"],
+		"echo:; Bed PID:",
+		values["M304"],
+		"echo:; Auto Bed Leveling:",
+		fmt.Sprintf("echo:  M420 S%d Z10.00", leveling),
+		"echo:; Z-Probe Offset:",
+		values["M851"],
+		"echo:; Linear Advance:",
+		values["M900"],
+		"echo:; Stepper driver current:",
+		"echo:  M906 X800 Y800 Z800",
+		"echo:  M906 T0 E650",
+		"echo:; Hybrid Threshold:",
+		"echo:  M913 X100 Y100 Z3",
+		"echo:  M913 T0 E30",
+	}
+}
+
+func copySettings(settings map[string]map[string]float64) map[string]map[string]float64 {
+	copied := make(map[string]map[string]float64, len(settings))
+	for command, values := range settings {
+		copied[command] = make(map[string]float64, len(values))
+		for param, value := range values {
+			copied[command][param] = value
+		}
+	}
+	return copied
+}
+
+func power(heater heater) int {
+	if heater.target > 0 && heater.temperature < heater.target {
+		return 127
+	}
+	return 0
+}
+
+func params(gcode string) map[string]float64 {
+	args := make(map[string]float64)
+	fields := strings.SplitN(gcode, " ", 2)
+	if len(fields) < 2 {
+		return args
+	}
+	for _, match := range paramRegex.FindAllStringSubmatch(fields[1], -1) {
+		value, _ := strconv.ParseFloat(match[2], 64)
+		args[match[1]] = value
+	}
+	return args
+}
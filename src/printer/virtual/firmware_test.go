@@ -0,0 +1,158 @@
+package virtual
+
+import (
+	"bufio"
+	"fmt"
+	"gotest.tools/assert"
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPort struct {
+	*Port
+	lines chan string
+}
+
+func openTestPort(t *testing.T, configure func(cfg *config.VirtualPrinter)) *testPort {
+	cfg := config.DefaultConfig().VirtualPrinter
+	cfg.TimeScale = 0
+	if configure != nil {
+		configure(&cfg)
+	}
+	port := &testPort{Port: Open(cfg), lines: make(chan string, 256)}
+	go func() {
+		scanner := bufio.NewScanner(port)
+		for scanner.Scan() {
+			port.lines <- scanner.Text()
+		}
+	}()
+	t.Cleanup(func() {
+		assert.NilError(t, port.Close())
+	})
+	return port
+}
+
+func (port *testPort) request(t *testing.T, line string) string {
+	_, err := port.Write([]byte(line + "\n"))
+	assert.NilError(t, err)
+
+	var response []string
+	for {
+		select {
+		case line := <-port.lines:
+			response = append(response, line)
+			if strings.HasPrefix(line, "ok") {
+				return strings.Join(response, "\n")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no response to %q, received %q", line, response)
+		}
+	}
+}
+
+func frame(lineNumber int, gcode string) string {
+	line := fmt.Sprintf("N%d %s", lineNumber, gcode)
+	checksum := 0
+	for i := 0; i < len(line); i++ {
+		checksum ^= int(line[i])
+	}
+	return fmt.Sprintf("%s*%d", line, checksum)
+}
+
+func TestFirmwareIdentify(t *testing.T) {
+	port := openTestPort(t, func(cfg *config.VirtualPrinter) {
+		cfg.MachineType = "Test Printer"
+		cfg.Capabilities = map[string]bool{"advanced_ok": true, "EMERGENCY_PARSER": false}
+	})
+
+	info, capabilities, err := parser.ParseM115(port.request(t, "M115"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.MachineType, "Test Printer")
+	assert.Equal(t, capabilities["AUTOREPORT_TEMP"], true)
+	assert.Equal(t, capabilities["ADVANCED_OK"], true)
+	assert.Equal(t, capabilities["EMERGENCY_PARSER"], false)
+	assert.Equal(t, port.request(t, "G90"), "ok N0 P16 B4")
+}
+
+func TestFirmwareMoves(t *testing.T) {
+	port := openTestPort(t, nil)
+
+	port.request(t, "G28")
+	port.request(t, "G1 X10 Y20 Z5 F3000")
+	port.request(t, "G91")
+	port.request(t, "G1 X5 E2")
+	port.request(t, "G92 E0")
+	position, err := parser.ParseM114(port.request(t, "M114"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, position, [4]float64{15, 20, 5, 0})
+
+	assert.Equal(t, port.request(t, "G999"), "echo:Unknown command: \"G999\"\nok")
+	assert.Equal(t, port.request(t, "M400"), "ok")
+}
+
+func TestFirmwareSettings(t *testing.T) {
+	port := openTestPort(t, nil)
+
+	settings, err := parser.ParseM503(port.request(t, "M503"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, settings.MaxAccel, [4]float64{3000, 3000, 100, 10000})
+	assert.DeepEqual(t, settings.MaxFeedrate, [4]float64{300, 300, 5, 25})
+	assert.DeepEqual(t, settings.HotendPid, &parser.PidSettings{P: 22.2, I: 1.08, D: 114})
+
+	port.request(t, "M92 E95.5")
+	port.request(t, "M204 S1500")
+	assert.Equal(t, port.request(t, "M851 Z-25"), "echo:?Z out of range (-20 to 20)\nok")
+	settings, err = parser.ParseM503(port.request(t, "M503"))
+	assert.NilError(t, err)
+	assert.Equal(t, settings.StepsPerUnit[3], 95.5)
+	assert.Equal(t, settings.Acceleration.Print, 1500.)
+	assert.Equal(t, settings.Acceleration.Travel, 1500.)
+	assert.DeepEqual(t, settings.ProbeOffset, &[3]float64{-40, -10, -1.5})
+
+	port.request(t, "M500")
+	port.request(t, "M502")
+	settings, err = parser.ParseM503(port.request(t, "M503"))
+	assert.NilError(t, err)
+	assert.Equal(t, settings.StepsPerUnit[3], 93.)
+	port.request(t, "M501")
+	settings, err = parser.ParseM503(port.request(t, "M503"))
+	assert.NilError(t, err)
+	assert.Equal(t, settings.StepsPerUnit[3], 95.5)
+}
+
+func TestFirmwareChecksum(t *testing.T) {
+	port := openTestPort(t, nil)
+
+	assert.Equal(t, port.request(t, frame(0, "M110 N0")), "ok")
+	assert.Equal(t, port.request(t, frame(1, "G28")), "ok")
+	assert.Equal(t, port.request(t, "N2 G1 X10*0"), "Error:checksum mismatch, Last Line: 1\nResend: 2\nok")
+	assert.Equal(t, port.request(t, "N2 G1 X10"), "Error:No Checksum with line number, Last Line: 1\nResend: 2\nok")
+	assert.Equal(t, port.request(t, frame(3, "G1 X10")), "Error:Line Number is not Last Line Number+1, Last Line: 1\nResend: 2\nok")
+	assert.Equal(t, port.request(t, frame(2, "G1 X10")), "ok")
+
+	position, err := parser.ParseM114(port.request(t, frame(3, "M114")))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, position, [4]float64{10, 0, 0, 0})
+}
+
+func TestFirmwareHeating(t *testing.T) {
+	port := openTestPort(t, nil)
+
+	port.request(t, "M104 S200")
+	port.request(t, "M190 S60")
+	assert.Equal(t, port.request(t, "M109 S200"), "ok")
+	temps, err := parser.ParseM105(port.request(t, "M105"))
+	assert.NilError(t, err)
+	assert.Equal(t, temps["extruder"].(parser.Heater).Temperature, 200.)
+	assert.Equal(t, temps["heater_bed"].(parser.Heater).Target, 60.)
+
+	_, err = port.Write([]byte("M112\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, <-port.lines, "Error:Printer halted. kill() called!")
+	_, err = port.Write([]byte("G28\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, <-port.lines, "echo:Printer halted. kill() called!")
+}
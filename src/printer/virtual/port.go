@@ -0,0 +1,108 @@
+package virtual
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"marlinraker/src/config"
+	"marlinraker/src/printer/parser"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const PortName = "virtual"
+
+var (
+	ErrPortClosed = errors.New("virtual port has been closed")
+	framingRegex  = regexp.MustCompile(`^N[0-9]+\s+(.*?)(?:\*[0-9]+)?$`)
+)
+
+type Port struct {
+	reader    *io.PipeReader
+	writer    *io.PipeWriter
+	firmware  *firmware
+	buffer    []byte
+	closeCh   chan struct{}
+	closeOnce *sync.Once
+	mu        *sync.Mutex
+}
+
+func Open(config config.VirtualPrinter) *Port {
+	reader, writer := io.Pipe()
+	port := &Port{
+		reader:    reader,
+		writer:    writer,
+		closeCh:   make(chan struct{}),
+		closeOnce: &sync.Once{},
+		mu:        &sync.Mutex{},
+	}
+	port.firmware = newFirmware(port, config)
+	go port.firmware.run()
+	go port.firmware.simulate()
+	return port
+}
+
+func (port *Port) Read(p []byte) (int, error) {
+	return port.reader.Read(p)
+}
+
+func (port *Port) Write(p []byte) (int, error) {
+	port.mu.Lock()
+	defer port.mu.Unlock()
+
+	select {
+	case <-port.closeCh:
+		return 0, ErrPortClosed
+	default:
+	}
+
+	port.buffer = append(port.buffer, p...)
+	for {
+		idx := bytes.IndexByte(port.buffer, '\n')
+		if idx == -1 {
+			break
+		}
+		line := strings.TrimSpace(string(port.buffer[:idx]))
+		port.buffer = port.buffer[idx+1:]
+		if line == "" {
+			continue
+		}
+
+		if port.firmware.capabilities["EMERGENCY_PARSER"] && parser.IsEmergencyCommand(unframe(line)) {
+			port.firmware.emergency(unframe(line))
+			continue
+		}
+
+		select {
+		case port.firmware.commandCh <- line:
+		case <-port.closeCh:
+			return 0, ErrPortClosed
+		}
+	}
+	return len(p), nil
+}
+
+func (port *Port) Close() error {
+	port.closeOnce.Do(func() {
+		close(port.closeCh)
+		_ = port.writer.Close()
+	})
+	return nil
+}
+
+func (port *Port) send(lines ...string) {
+	select {
+	case <-port.closeCh:
+		return
+	default:
+	}
+	_, _ = port.writer.Write([]byte(strings.Join(lines, "\n") + "\n"))
+}
+
+func unframe(line string) string {
+	if match := framingRegex.FindStringSubmatch(line); match != nil {
+		return match[1]
+	}
+	return line
+}
@@ -3,6 +3,7 @@ package printer_objects
 import (
 	"errors"
 	"fmt"
+	"github.com/samber/lo"
 	"marlinraker/src/api/notification"
 	"marlinraker/src/marlinraker/connections"
 	"marlinraker/src/system_info/procfs"
@@ -18,36 +19,97 @@ type PrinterObject interface {
 
 type Subscriptions map[*connections.Connection][]string
 
-var (
-	objects            = make(map[string]PrinterObject)
-	objectsMutex       = &sync.RWMutex{}
-	subscriptions      = make(map[string]Subscriptions)
-	subscriptionsMutex = &sync.RWMutex{}
-	lastEmitted        = make(map[*connections.Connection]map[string]QueryResult)
-	lastEmittedMutex   = &sync.RWMutex{}
-)
+type Registry struct {
+	name               string
+	parent             *Registry
+	children           []*Registry
+	childrenMutex      *sync.RWMutex
+	objects            map[string]PrinterObject
+	objectsMutex       *sync.RWMutex
+	subscriptions      map[string]Subscriptions
+	subscriptionsMutex *sync.RWMutex
+	lastEmitted        map[*connections.Connection]map[string]QueryResult
+	lastEmittedMutex   *sync.RWMutex
+}
 
-func GetObjects() map[string]PrinterObject {
-	objectsMutex.RLock()
-	defer objectsMutex.RUnlock()
+var global = newRegistry("", nil)
+
+func newRegistry(name string, parent *Registry) *Registry {
+	return &Registry{
+		name:               name,
+		parent:             parent,
+		children:           make([]*Registry, 0),
+		childrenMutex:      &sync.RWMutex{},
+		objects:            make(map[string]PrinterObject),
+		objectsMutex:       &sync.RWMutex{},
+		subscriptions:      make(map[string]Subscriptions),
+		subscriptionsMutex: &sync.RWMutex{},
+		lastEmitted:        make(map[*connections.Connection]map[string]QueryResult),
+		lastEmittedMutex:   &sync.RWMutex{},
+	}
+}
+
+func NewRegistry(name string) *Registry {
+	registry := newRegistry(name, global)
+	global.childrenMutex.Lock()
+	defer global.childrenMutex.Unlock()
+	global.children = append(global.children, registry)
+	return registry
+}
+
+func (registry *Registry) Close() {
+	global.childrenMutex.Lock()
+	defer global.childrenMutex.Unlock()
+	global.children = lo.Without(global.children, registry)
+}
+
+func (registry *Registry) Name() string {
+	return registry.name
+}
+
+func (registry *Registry) GetObjects() map[string]PrinterObject {
+	objects := make(map[string]PrinterObject)
+	if registry.parent != nil {
+		objects = registry.parent.GetObjects()
+	}
+
+	registry.objectsMutex.RLock()
+	defer registry.objectsMutex.RUnlock()
+	for name, object := range registry.objects {
+		objects[name] = object
+	}
 	return objects
 }
 
-func Query(name string) (QueryResult, error) {
-	objectsMutex.RLock()
-	defer objectsMutex.RUnlock()
+func (registry *Registry) Query(name string) (QueryResult, error) {
+	registry.objectsMutex.RLock()
+	object, exists := registry.objects[name]
+	registry.objectsMutex.RUnlock()
 
-	object, exists := objects[name]
 	if !exists {
+		if registry.parent != nil {
+			return registry.parent.Query(name)
+		}
 		return QueryResult{}, nil
 	}
 	return object.Query()
 }
 
-func EmitObject(names ...string) error {
+func (registry *Registry) EmitObject(names ...string) error {
+	errs := []error{registry.emit(names)}
 
-	subscriptionsMutex.RLock()
-	defer subscriptionsMutex.RUnlock()
+	registry.childrenMutex.RLock()
+	defer registry.childrenMutex.RUnlock()
+	for _, child := range registry.children {
+		errs = append(errs, child.EmitObject(names...))
+	}
+	return errors.Join(errs...)
+}
+
+func (registry *Registry) emit(names []string) error {
+
+	registry.subscriptionsMutex.RLock()
+	defer registry.subscriptionsMutex.RUnlock()
 
 	eventTime, err := procfs.GetUptime()
 	if err != nil {
@@ -62,15 +124,15 @@ func EmitObject(names ...string) error {
 	)
 	for _, name := range names {
 
-		result, err = Query(name)
+		result, err = registry.Query(name)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to query object: %w", err))
 			continue
 		}
 
-		for connection, attributes := range subscriptions[name] {
+		for connection, attributes := range registry.subscriptions[name] {
 
-			diff := getDiff(connection, name, result)
+			diff := registry.getDiff(connection, name, result)
 			if attributes != nil {
 				filtered := make(QueryResult)
 				for _, attribute := range attributes {
@@ -89,7 +151,11 @@ func EmitObject(names ...string) error {
 	}
 
 	for connection, status := range pending {
-		err = notification.Send(connection, notification.New("notify_status_update", []any{status, eventTime}))
+		params := []any{status, eventTime}
+		if registry.name != "" {
+			params = append(params, registry.name)
+		}
+		err = notification.Send(connection, notification.New("notify_status_update", params))
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to send notification: %w", err))
 		}
@@ -97,51 +163,60 @@ func EmitObject(names ...string) error {
 	return errors.Join(errs...)
 }
 
-func RegisterObject(name string, object PrinterObject) {
-	objectsMutex.Lock()
-	defer objectsMutex.Unlock()
-	objects[name] = object
+func (registry *Registry) RegisterObject(name string, object PrinterObject) {
+	registry.objectsMutex.Lock()
+	defer registry.objectsMutex.Unlock()
+	registry.objects[name] = object
 }
 
-func UnregisterObject(name string) {
-	objectsMutex.Lock()
-	defer objectsMutex.Unlock()
-	delete(objects, name)
+func (registry *Registry) UnregisterObject(name string) {
+	registry.objectsMutex.Lock()
+	defer registry.objectsMutex.Unlock()
+	delete(registry.objects, name)
 }
 
-func Subscribe(connection *connections.Connection, name string, attributes []string) {
-	subscriptionsMutex.Lock()
-	defer subscriptionsMutex.Unlock()
+func (registry *Registry) Subscribe(connection *connections.Connection, name string, attributes []string) {
+	registry.subscriptionsMutex.Lock()
+	defer registry.subscriptionsMutex.Unlock()
 
-	if _, exists := subscriptions[name]; !exists {
-		subscriptions[name] = make(Subscriptions)
+	if _, exists := registry.subscriptions[name]; !exists {
+		registry.subscriptions[name] = make(Subscriptions)
 	}
-	subscriptions[name][connection] = attributes
+	registry.subscriptions[name][connection] = attributes
 }
 
-func Unsubscribe(connection *connections.Connection) {
-	subscriptionsMutex.Lock()
-	defer subscriptionsMutex.Unlock()
-
-	for name, subscription := range subscriptions {
+func (registry *Registry) Unsubscribe(connection *connections.Connection) {
+	registry.subscriptionsMutex.Lock()
+	for name, subscription := range registry.subscriptions {
 		for _connection := range subscription {
 			if _connection == connection {
 				delete(subscription, _connection)
 			}
 		}
-		subscriptions[name] = subscription
+		registry.subscriptions[name] = subscription
+	}
+	registry.subscriptionsMutex.Unlock()
+
+	registry.lastEmittedMutex.Lock()
+	delete(registry.lastEmitted, connection)
+	registry.lastEmittedMutex.Unlock()
+
+	registry.childrenMutex.RLock()
+	defer registry.childrenMutex.RUnlock()
+	for _, child := range registry.children {
+		child.Unsubscribe(connection)
 	}
 }
 
-func getDiff(connection *connections.Connection, name string, result QueryResult) QueryResult {
+func (registry *Registry) getDiff(connection *connections.Connection, name string, result QueryResult) QueryResult {
 
-	lastEmittedMutex.Lock()
-	last, exists := lastEmitted[connection][name]
-	if _, exists := lastEmitted[connection]; !exists {
-		lastEmitted[connection] = make(map[string]QueryResult)
+	registry.lastEmittedMutex.Lock()
+	last, exists := registry.lastEmitted[connection][name]
+	if _, exists := registry.lastEmitted[connection]; !exists {
+		registry.lastEmitted[connection] = make(map[string]QueryResult)
 	}
-	lastEmitted[connection][name] = result
-	lastEmittedMutex.Unlock()
+	registry.lastEmitted[connection][name] = result
+	registry.lastEmittedMutex.Unlock()
 
 	if !exists {
 		return result
@@ -156,3 +231,27 @@ func getDiff(connection *connections.Connection, name string, result QueryResult
 
 	return diff
 }
+
+func GetObjects() map[string]PrinterObject {
+	return global.GetObjects()
+}
+
+func Query(name string) (QueryResult, error) {
+	return global.Query(name)
+}
+
+func EmitObject(names ...string) error {
+	return global.EmitObject(names...)
+}
+
+func RegisterObject(name string, object PrinterObject) {
+	global.RegisterObject(name, object)
+}
+
+func UnregisterObject(name string) {
+	global.UnregisterObject(name)
+}
+
+func Unsubscribe(connection *connections.Connection) {
+	global.Unsubscribe(connection)
+}
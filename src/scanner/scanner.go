@@ -11,7 +11,7 @@ import (
 
 var scanning = false
 
-func FindSerialPort(config *config.Config) (string, int) {
+func FindSerialPort(config config.Serial) (string, int) {
 
 	if scanning {
 		return "", 0
@@ -21,7 +21,7 @@ func FindSerialPort(config *config.Config) (string, int) {
 		scanning = false
 	}()
 
-	if config.Serial.Port == "auto" && config.Serial.BaudRate == "auto" {
+	if config.Port == "auto" && config.BaudRate == "auto" {
 		lastPath, _ := database.GetItem("marlinraker", "lastPath", true)
 		lastBaudRate, _ := database.GetItem("marlinraker", "lastBaudRate", true)
 
@@ -32,7 +32,7 @@ func FindSerialPort(config *config.Config) (string, int) {
 			baudRate := int(baudRateFloat)
 			log.Printf("Trying last used port %s @ %d...", path, baudRate)
 
-			success := tryPort(path, baudRate, config.Serial.ConnectionTimeout)
+			success := tryPort(path, baudRate, config.ConnectionTimeout)
 			if success {
 				log.Printf("Found printer at last used port %s @ %d", path, baudRate)
 				return path, baudRate
@@ -56,22 +56,22 @@ func FindSerialPort(config *config.Config) (string, int) {
 	return path, baudRate
 }
 
-func scan(config *config.Config) (string, int) {
+func scan(config config.Serial) (string, int) {
 
 	var err error
 	var ports []string
-	if config.Serial.Port == "" || config.Serial.Port == "auto" {
+	if config.Port == "" || config.Port == "auto" {
 		ports, err = serial.GetPortsList()
 		if err != nil {
 			log.Errorf("Failed to get serial port list: %v", err)
 			return "", 0
 		}
 	} else {
-		ports = []string{config.Serial.Port}
+		ports = []string{config.Port}
 	}
 
 	var baudRates []int
-	switch baudRate := config.Serial.BaudRate.(type) {
+	switch baudRate := config.BaudRate.(type) {
 	case int64:
 		baudRates = []int{int(baudRate)}
 		break
@@ -83,7 +83,7 @@ func scan(config *config.Config) (string, int) {
 	for _, path := range ports {
 		for _, baudRate := range baudRates {
 			log.Printf("Trying port %s @ %d...", path, baudRate)
-			success := tryPort(path, baudRate, config.Serial.ConnectionTimeout)
+			success := tryPort(path, baudRate, config.ConnectionTimeout)
 			if success {
 				log.Printf("Found printer at %s @ %d...", path, baudRate)
 				return path, baudRate
@@ -1,17 +1,30 @@
 package shared
 
+import "marlinraker/src/printer_objects"
+
 type Printer interface {
+	Name() string
+	GetObjects() *printer_objects.Registry
 	Respond(message string) error
 	GetPrintManager() PrintManager
 	GetGcodeState() GcodeState
+	GetBedMesh() BedMesh
 	SaveGcodeState(name string)
 	RestoreGcodeState(context ExecutorContext, name string) error
 	MainExecutorContext() ExecutorContext
 	EmergencyStop()
+	UpdateCheckpoint(fileName string, filePosition int64)
+	ClearCheckpoint()
+	RecoverPrint(context ExecutorContext) error
+	WatchLines(handler func(line string)) func()
+	ApplyFirmwareSettings(context ExecutorContext, changes map[string]float64, save bool) error
 }
 
 type GcodeState interface {
 	ExtrudedFilament() float64
+	GetGcodePosition() [4]float64
+	SkipMove(line string) error
+	ResumeGcode() string
 }
 
 type PrintManager interface {
@@ -24,11 +37,24 @@ type PrintManager interface {
 	CanPrint(fileName string) bool
 	IsPrinting() bool
 	GetState() string
+	ExcludeObject(name string) error
+	ResetExcludedObjects(name string) error
+	ExcludedObjects() []string
+	SetPrintStatsInfo(totalLayer *int, currentLayer *int) error
+}
+
+type BedMesh interface {
+	Calibrate(context ExecutorContext, profile string) error
+	LoadProfile(context ExecutorContext, name string) error
+	SaveProfile(context ExecutorContext, name string) error
+	RemoveProfile(name string) error
+	Clear(context ExecutorContext) error
 }
 
 type ExecutorContext interface {
 	Name() string
 	QueueGcode(gcodeRaw string, silent bool) chan string
+	QueueMacro(name string, run func(ExecutorContext) error) chan string
 	MakeSubContext(name string) (ExecutorContext, error)
 	ReleaseSubContext()
 	Pending() chan struct{}
@@ -0,0 +1,144 @@
+package update_manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"io"
+	"marlinraker/src/files"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type releaseInfo struct {
+	ProjectName  string `json:"project_name"`
+	ProjectOwner string `json:"project_owner"`
+	Version      string `json:"version"`
+}
+
+func installBinary(path string, data []byte) error {
+	stagedPath := path + ".new"
+	if err := afero.WriteFile(files.Fs, stagedPath, data, 0755); err != nil {
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	if err := files.Fs.Chmod(stagedPath, 0755); err != nil {
+		removeStaged(stagedPath)
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	if err := files.Fs.Rename(stagedPath, path); err != nil {
+		removeStaged(stagedPath)
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
+
+func installClient(path string, name string, version string, data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open release archive: %w", err)
+	}
+
+	stagedPath, oldPath := path+".new", path+".old"
+	removeStaged(stagedPath)
+	if err := extractArchive(reader, stagedPath); err != nil {
+		removeStaged(stagedPath)
+		return err
+	}
+
+	infoPath := filepath.Join(stagedPath, "release_info.json")
+	if exists, _ := afero.Exists(files.Fs, infoPath); !exists {
+		infoBytes, err := json.Marshal(releaseInfo{ProjectName: name, Version: version})
+		if err != nil {
+			removeStaged(stagedPath)
+			return err
+		}
+		if err := afero.WriteFile(files.Fs, infoPath, infoBytes, 0644); err != nil {
+			removeStaged(stagedPath)
+			return err
+		}
+	}
+
+	removeStaged(oldPath)
+	if exists, _ := afero.Exists(files.Fs, path); exists {
+		if err := files.Fs.Rename(path, oldPath); err != nil {
+			removeStaged(stagedPath)
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+	if err := files.Fs.Rename(stagedPath, path); err != nil {
+		if err := files.Fs.Rename(oldPath, path); err != nil {
+			log.Errorf("Failed to restore %s: %v", path, err)
+		}
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	removeStaged(oldPath)
+	return nil
+}
+
+func extractArchive(reader *zip.Reader, dest string) error {
+	if err := files.Fs.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		diskPath := filepath.Join(dest, file.Name)
+		if diskPath != dest && !strings.HasPrefix(diskPath, dest+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path %q in release archive", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := files.Fs.MkdirAll(diskPath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractFile(file, diskPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(file *zip.File, diskPath string) error {
+	if err := files.Fs.MkdirAll(filepath.Dir(diskPath), 0755); err != nil {
+		return err
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := files.Fs.OpenFile(diskPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, reader); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func readClientVersion(path string) string {
+	if infoBytes, err := afero.ReadFile(files.Fs, filepath.Join(path, "release_info.json")); err == nil {
+		info := releaseInfo{}
+		if err := json.Unmarshal(infoBytes, &info); err == nil && info.Version != "" {
+			return info.Version
+		}
+	}
+	if versionBytes, err := afero.ReadFile(files.Fs, filepath.Join(path, ".version")); err == nil {
+		if version := strings.TrimSpace(string(versionBytes)); version != "" {
+			return version
+		}
+	}
+	return "?"
+}
+
+func removeStaged(path string) {
+	if err := files.Fs.RemoveAll(path); err != nil {
+		log.Errorf("Failed to remove %s: %v", path, err)
+	}
+}
@@ -0,0 +1,223 @@
+package update_manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"io"
+	"marlinraker/src/files"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type releaseManifest struct {
+	Releases []release `json:"releases"`
+}
+
+type release struct {
+	Version string         `json:"version"`
+	Channel string         `json:"channel"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Os     string `json:"os"`
+	Arch   string `json:"arch"`
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+}
+
+var (
+	httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+	channelRanks = map[string]int{
+		"stable": 0,
+		"beta":   1,
+		"dev":    2,
+	}
+)
+
+func fetchLatestRelease(manifestLocation string, channel string) (*release, error) {
+	manifestLocation, err := resolveManifestLocation(manifestLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readLocation(manifestLocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release manifest: %w", err)
+	}
+	manifest := releaseManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse release manifest: %w", err)
+	}
+
+	maxRank, isKnown := channelRanks[channel]
+	if !isKnown {
+		return nil, fmt.Errorf("unknown channel %q", channel)
+	}
+
+	var latest *release
+	for i := range manifest.Releases {
+		candidate := &manifest.Releases[i]
+		rank, isKnown := channelRanks[candidate.Channel]
+		if candidate.Channel == "" {
+			rank, isKnown = 0, true
+		}
+		if !isKnown || rank > maxRank {
+			continue
+		}
+		if latest == nil || compareVersions(candidate.Version, latest.Version) > 0 {
+			latest = candidate
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no release found for channel %q", channel)
+	}
+
+	for i, asset := range latest.Assets {
+		if latest.Assets[i].Url, err = resolveLocation(manifestLocation, asset.Url); err != nil {
+			return nil, err
+		}
+	}
+	return latest, nil
+}
+
+func (release *release) findAsset(kind applicationType) (releaseAsset, error) {
+	for _, asset := range release.Assets {
+		if kind == webApplication {
+			return asset, nil
+		}
+		if (asset.Os == "" || asset.Os == runtime.GOOS) && (asset.Arch == "" || asset.Arch == runtime.GOARCH) {
+			return asset, nil
+		}
+	}
+	return releaseAsset{}, fmt.Errorf("release %s has no asset for %s/%s", release.Version, runtime.GOOS, runtime.GOARCH)
+}
+
+func (asset releaseAsset) download() ([]byte, error) {
+	if asset.Sha256 == "" {
+		return nil, errors.New("release asset has no checksum")
+	}
+	data, err := readLocation(asset.Url)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(checksum[:]); !strings.EqualFold(actual, asset.Sha256) {
+		return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", asset.Sha256, actual)
+	}
+	return data, nil
+}
+
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+func resolveManifestLocation(location string) (string, error) {
+	if isRemote(location) {
+		return location, nil
+	}
+	path, err := expandPath(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return "", err
+	}
+	if isDir, _ := afero.IsDir(files.Fs, path); isDir {
+		path = filepath.Join(path, "manifest.json")
+	}
+	return path, nil
+}
+
+func resolveLocation(base string, location string) (string, error) {
+	if isRemote(location) {
+		return location, nil
+	}
+	if isRemote(base) {
+		baseUrl, err := url.Parse(base)
+		if err != nil {
+			return "", err
+		}
+		locationUrl, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		return baseUrl.ResolveReference(locationUrl).String(), nil
+	}
+	location = strings.TrimPrefix(location, "file://")
+	if filepath.IsAbs(location) {
+		return location, nil
+	}
+	return filepath.Join(filepath.Dir(base), location), nil
+}
+
+func readLocation(location string) ([]byte, error) {
+	if !isRemote(location) {
+		return afero.ReadFile(files.Fs, location)
+	}
+
+	response, err := httpClient.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed: %s", location, response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
+
+func parseVersion(version string) ([3]int, string, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, preRelease, _ := strings.Cut(version, "-")
+
+	var parts [3]int
+	fields := strings.Split(version, ".")
+	if len(fields) > 3 {
+		return parts, "", false
+	}
+	for i, field := range fields {
+		part, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, "", false
+		}
+		parts[i] = part
+	}
+	return parts, preRelease, true
+}
+
+func compareVersions(a string, b string) int {
+	partsA, preReleaseA, validA := parseVersion(a)
+	partsB, preReleaseB, validB := parseVersion(b)
+	switch {
+	case !validA && !validB:
+		return 0
+	case !validA:
+		return -1
+	case !validB:
+		return 1
+	}
+
+	for i := range partsA {
+		if partsA[i] != partsB[i] {
+			return partsA[i] - partsB[i]
+		}
+	}
+	switch {
+	case preReleaseA == preReleaseB:
+		return 0
+	case preReleaseA == "":
+		return 1
+	case preReleaseB == "":
+		return -1
+	}
+	return strings.Compare(preReleaseA, preReleaseB)
+}
@@ -0,0 +1,352 @@
+package update_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/constants"
+	"marlinraker/src/marlinraker"
+	"marlinraker/src/service"
+	"marlinraker/src/util"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type ApplicationStatus struct {
+	Name              string   `json:"name"`
+	ConfiguredType    string   `json:"configured_type"`
+	Channel           string   `json:"channel"`
+	Version           string   `json:"version"`
+	RemoteVersion     string   `json:"remote_version"`
+	RollbackVersion   string   `json:"rollback_version"`
+	FullVersionString string   `json:"full_version_string"`
+	IsValid           bool     `json:"is_valid"`
+	Corrupt           bool     `json:"corrupt"`
+	DebugEnabled      bool     `json:"debug_enabled"`
+	InfoTags          []string `json:"info_tags"`
+	Warnings          []string `json:"warnings"`
+	Anomalies         []string `json:"anomalies"`
+}
+
+type Status struct {
+	Busy                    bool                         `json:"busy"`
+	GithubRateLimit         *int                         `json:"github_rate_limit"`
+	GithubRequestsRemaining *int                         `json:"github_requests_remaining"`
+	GithubLimitResetTime    *int                         `json:"github_limit_reset_time"`
+	VersionInfo             map[string]ApplicationStatus `json:"version_info"`
+}
+
+type applicationType string
+
+const (
+	binaryApplication applicationType = "zip"
+	webApplication    applicationType = "web"
+)
+
+type application struct {
+	name     string
+	kind     applicationType
+	path     string
+	manifest string
+	channel  string
+	version  string
+	rollback string
+	remote   *release
+	warnings []string
+}
+
+const marlinrakerName = "marlinraker"
+
+var (
+	enabled      bool
+	applications = make(map[string]*application)
+	busy         bool
+	nextProcId   int64
+	mu           = &sync.Mutex{}
+	isPrinting   = marlinraker.IsPrinting
+)
+
+func Init(config *config.Config) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cfg := config.UpdateManager
+	enabled, applications, busy = cfg.Enabled, make(map[string]*application), false
+	if !enabled {
+		return nil
+	}
+
+	binaryPath := cfg.BinaryPath
+	if binaryPath == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate marlinraker binary: %w", err)
+		}
+		binaryPath = executable
+	}
+	applications[marlinrakerName] = &application{
+		name:     marlinrakerName,
+		kind:     binaryApplication,
+		path:     binaryPath,
+		manifest: cfg.Manifest,
+		channel:  cfg.Channel,
+		version:  constants.Version,
+	}
+
+	for name, client := range cfg.Clients {
+		if name == marlinrakerName {
+			return fmt.Errorf("client name %q is reserved", name)
+		}
+		path, err := expandPath(client.Path)
+		if err != nil {
+			return err
+		}
+		applications[name] = &application{
+			name:     name,
+			kind:     webApplication,
+			path:     path,
+			manifest: client.Manifest,
+			channel:  client.Channel,
+			version:  readClientVersion(path),
+		}
+	}
+	return nil
+}
+
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+func GetStatus() (Status, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return Status{}, util.NewError(503, "update manager is not enabled")
+	}
+	return status(), nil
+}
+
+func Refresh(name string) error {
+	apps, err := acquire(name)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, app := range apps {
+		if err := app.refresh(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to refresh %s: %w", app.name, err))
+		}
+	}
+
+	mu.Lock()
+	busy = false
+	result := status()
+	mu.Unlock()
+
+	if err := notification.Publish(notification.New("notify_update_refreshed", []any{result})); err != nil {
+		log.Errorf("Failed to publish notification: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+func Upgrade(name string) error {
+	if isPrinting() {
+		return util.NewError(409, "cannot update while printing")
+	}
+	apps, err := acquire(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		mu.Lock()
+		busy = false
+		mu.Unlock()
+	}()
+
+	for _, app := range apps {
+		if err := app.upgrade(); err != nil {
+			return fmt.Errorf("failed to update %s: %w", app.name, err)
+		}
+	}
+	return nil
+}
+
+func acquire(name string) ([]*application, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return nil, util.NewError(503, "update manager is not enabled")
+	}
+
+	var apps []*application
+	if name == "" {
+		names := lo.Without(lo.Keys(applications), marlinrakerName)
+		sort.Strings(names)
+		names = append(names, marlinrakerName)
+		apps = lo.Map(names, func(name string, _ int) *application { return applications[name] })
+	} else if app, exists := applications[name]; exists {
+		apps = []*application{app}
+	} else {
+		return nil, util.NewErrorf(404, "updater %q not available", name)
+	}
+
+	if busy {
+		return nil, util.NewError(503, "update manager is busy")
+	}
+	busy = true
+	return apps, nil
+}
+
+func status() Status {
+	versionInfo := make(map[string]ApplicationStatus)
+	for name, app := range applications {
+		versionInfo[name] = app.status()
+	}
+	return Status{
+		Busy:        busy,
+		VersionInfo: versionInfo,
+	}
+}
+
+func (app *application) status() ApplicationStatus {
+	remoteVersion := "?"
+	if app.remote != nil {
+		remoteVersion = app.remote.Version
+	}
+	return ApplicationStatus{
+		Name:              app.name,
+		ConfiguredType:    string(app.kind),
+		Channel:           app.channel,
+		Version:           app.version,
+		RemoteVersion:     remoteVersion,
+		RollbackVersion:   app.rollback,
+		FullVersionString: app.version,
+		IsValid:           app.manifest != "" && len(app.warnings) == 0,
+		InfoTags:          []string{},
+		Warnings:          lo.Ternary(app.warnings != nil, app.warnings, []string{}),
+		Anomalies:         []string{},
+	}
+}
+
+func (app *application) refresh() error {
+	if app.manifest == "" {
+		app.setWarnings("no release manifest configured")
+		return nil
+	}
+	latest, err := fetchLatestRelease(app.manifest, app.channel)
+	if err != nil {
+		app.setWarnings(err.Error())
+		return err
+	}
+	mu.Lock()
+	app.remote, app.warnings = latest, nil
+	mu.Unlock()
+	return nil
+}
+
+func (app *application) upgrade() error {
+	mu.Lock()
+	remote, version := app.remote, app.version
+	mu.Unlock()
+
+	if remote == nil && app.manifest != "" {
+		if err := app.refresh(); err != nil {
+			return err
+		}
+		mu.Lock()
+		remote = app.remote
+		mu.Unlock()
+	}
+
+	procId := newProcId()
+	if remote == nil || compareVersions(remote.Version, version) <= 0 {
+		app.notify(procId, fmt.Sprintf("%s is already up to date", app.name), true)
+		return nil
+	}
+
+	asset, err := remote.findAsset(app.kind)
+	if err != nil {
+		return err
+	}
+
+	app.notify(procId, fmt.Sprintf("Downloading %s %s...", app.name, remote.Version), false)
+	data, err := asset.download()
+	if err != nil {
+		app.notify(procId, fmt.Sprintf("Failed to download %s: %v", app.name, err), true)
+		return err
+	}
+
+	app.notify(procId, fmt.Sprintf("Installing %s %s...", app.name, remote.Version), false)
+	switch app.kind {
+	case binaryApplication:
+		err = installBinary(app.path, data)
+	case webApplication:
+		err = installClient(app.path, app.name, remote.Version, data)
+	}
+	if err != nil {
+		app.notify(procId, fmt.Sprintf("Failed to install %s: %v", app.name, err), true)
+		return err
+	}
+
+	mu.Lock()
+	app.version, app.rollback = remote.Version, version
+	mu.Unlock()
+
+	if app.kind == binaryApplication {
+		if err := service.PerformAction(marlinrakerName, service.Restart); err != nil {
+			log.Warnf("Failed to restart marlinraker: %v", err)
+			app.notify(procId, fmt.Sprintf("Updated %s to %s, restart Marlinraker to apply the update", app.name, remote.Version), true)
+			return nil
+		}
+	}
+	app.notify(procId, fmt.Sprintf("Updated %s to %s", app.name, remote.Version), true)
+	return nil
+}
+
+func (app *application) setWarnings(warnings ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	app.warnings = warnings
+}
+
+func (app *application) notify(procId int64, message string, complete bool) {
+	log.Println(message)
+	err := notification.Publish(notification.New("notify_update_response", []any{map[string]any{
+		"application": app.name,
+		"proc_id":     procId,
+		"message":     message,
+		"complete":    complete,
+	}}))
+	if err != nil {
+		log.Errorf("Failed to publish notification: %v", err)
+	}
+}
+
+func newProcId() int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	nextProcId++
+	return nextProcId
+}
+
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
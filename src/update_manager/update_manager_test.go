@@ -0,0 +1,141 @@
+package update_manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+	"marlinraker/src/api/notification"
+	"marlinraker/src/config"
+	"marlinraker/src/constants"
+	"marlinraker/src/files"
+	"marlinraker/src/marlinraker"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeManifest(t *testing.T, write func(string, []byte) error, path string, manifest releaseManifest) {
+	data, err := json.Marshal(manifest)
+	assert.NilError(t, err)
+	assert.NilError(t, write(path, data))
+}
+
+func createZip(t *testing.T, contents map[string]string) []byte {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for name, content := range contents {
+		file, err := writer.Create(name)
+		assert.NilError(t, err)
+		_, err = file.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Assert(t, compareVersions("v1.2.3", "1.2.3") == 0)
+	assert.Assert(t, compareVersions("v1.10.0", "v1.9.9") > 0)
+	assert.Assert(t, compareVersions("1.0.0-beta", "1.0.0") < 0)
+	assert.Assert(t, compareVersions("0.1.0", constants.Version) > 0)
+	assert.Assert(t, compareVersions("v2.0.0", "?") > 0)
+}
+
+func TestUpdateManager(t *testing.T) {
+	files.Fs = afero.NewMemMapFs()
+	notification.Testing = true
+
+	releaseDir := t.TempDir()
+	writeOsFile := func(name string, data []byte) error {
+		return os.WriteFile(filepath.Join(releaseDir, name), data, 0644)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(releaseDir)))
+	defer server.Close()
+
+	binary := []byte("new marlinraker binary")
+	assert.NilError(t, writeOsFile("marlinraker_linux", binary))
+	writeManifest(t, writeOsFile, "manifest.json", releaseManifest{Releases: []release{
+		{Version: "v0.1.0", Channel: "stable", Assets: []releaseAsset{{Url: "marlinraker_linux", Sha256: "invalid"}}},
+		{Version: "v0.2.0", Channel: "stable", Assets: []releaseAsset{{Url: "marlinraker_linux", Sha256: checksum(binary)}}},
+		{Version: "v0.3.0-beta", Channel: "beta", Assets: []releaseAsset{{Url: "marlinraker_linux", Sha256: "invalid"}}},
+	}})
+
+	archive := createZip(t, map[string]string{"index.html": "mainsail v2.1.0", "js/app.js": "app"})
+	assert.NilError(t, afero.WriteFile(files.Fs, "/releases/mainsail/mainsail.zip", archive, 0644))
+	writeManifest(t, func(path string, data []byte) error {
+		return afero.WriteFile(files.Fs, path, data, 0644)
+	}, "/releases/mainsail/manifest.json", releaseManifest{Releases: []release{
+		{Version: "v2.1.0", Assets: []releaseAsset{{Url: "mainsail.zip", Sha256: checksum(archive)}}},
+	}})
+
+	assert.NilError(t, afero.WriteFile(files.Fs, "/opt/marlinraker", []byte("old marlinraker binary"), 0755))
+	assert.NilError(t, afero.WriteFile(files.Fs, "/home/pi/mainsail/release_info.json", []byte(`{"version":"v2.0.0"}`), 0644))
+	assert.NilError(t, afero.WriteFile(files.Fs, "/home/pi/mainsail/old.js", []byte("old"), 0644))
+
+	cfg := config.DefaultConfig()
+	cfg.UpdateManager.Enabled = true
+	cfg.UpdateManager.Manifest = server.URL + "/manifest.json"
+	cfg.UpdateManager.BinaryPath = "/opt/marlinraker"
+	cfg.UpdateManager.Clients = map[string]config.UpdateClient{
+		"mainsail": {Path: "/home/pi/mainsail", Manifest: "/releases/mainsail", Channel: "stable"},
+	}
+	assert.NilError(t, Init(cfg))
+	assert.NilError(t, Refresh(""))
+
+	status, err := GetStatus()
+	assert.NilError(t, err)
+	assert.Equal(t, status.Busy, false)
+	assert.Equal(t, status.VersionInfo["marlinraker"].Version, constants.Version)
+	assert.Equal(t, status.VersionInfo["marlinraker"].RemoteVersion, "v0.2.0")
+	assert.Equal(t, status.VersionInfo["mainsail"].ConfiguredType, "web")
+	assert.Equal(t, status.VersionInfo["mainsail"].Version, "v2.0.0")
+	assert.Equal(t, status.VersionInfo["mainsail"].RemoteVersion, "v2.1.0")
+
+	assert.Equal(t, status.VersionInfo["mainsail"].RollbackVersion, "")
+
+	assert.Error(t, Upgrade("fluidd"), `updater "fluidd" not available`)
+
+	isPrinting = func() bool { return true }
+	assert.Error(t, Upgrade("mainsail"), "cannot update while printing")
+	assert.Error(t, Upgrade(""), "cannot update while printing")
+	isPrinting = func() bool { return false }
+	t.Cleanup(func() { isPrinting = marlinraker.IsPrinting })
+
+	assert.NilError(t, Upgrade("mainsail"))
+	index, err := afero.ReadFile(files.Fs, "/home/pi/mainsail/index.html")
+	assert.NilError(t, err)
+	assert.Equal(t, string(index), "mainsail v2.1.0")
+	exists, err := afero.Exists(files.Fs, "/home/pi/mainsail/old.js")
+	assert.NilError(t, err)
+	assert.Equal(t, exists, false)
+	assert.Equal(t, readClientVersion("/home/pi/mainsail"), "v2.1.0")
+
+	assert.NilError(t, Upgrade(""))
+	installed, err := afero.ReadFile(files.Fs, "/opt/marlinraker")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, installed, binary)
+
+	status, err = GetStatus()
+	assert.NilError(t, err)
+	assert.Equal(t, status.VersionInfo["marlinraker"].Version, "v0.2.0")
+	assert.Equal(t, status.VersionInfo["mainsail"].Version, "v2.1.0")
+	assert.Equal(t, status.VersionInfo["mainsail"].RollbackVersion, "v2.0.0")
+
+	cfg.UpdateManager.Channel = "beta"
+	assert.NilError(t, Init(cfg))
+	assert.ErrorContains(t, Upgrade("marlinraker"), "checksum mismatch")
+	installed, err = afero.ReadFile(files.Fs, "/opt/marlinraker")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, installed, binary)
+}